@@ -9,7 +9,7 @@ import (
 	"github.com/imnulhaqueruman/opencode-poc/internal/app"
 	"github.com/imnulhaqueruman/opencode-poc/internal/config"
 	"github.com/imnulhaqueruman/opencode-poc/internal/db"
-	"github.com/imnulhaqueruman/opencode-poc/internal/llm/agent"
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools/shell"
 	"github.com/imnulhaqueruman/opencode-poc/internal/tui"
 	zone "github.com/lrstanley/bubblezone"
 	"github.com/spf13/cobra"
@@ -36,6 +36,7 @@ var rootCmd = &cobra.Command{
 		ctx := context.Background()
 
 		app := app.New(ctx, conn)
+		defer app.Shutdown()
 		app.Logger.Info("Starting termai...")
 		zone.NewGlobal()
 		tui := tea.NewProgram(
@@ -48,8 +49,6 @@ var rootCmd = &cobra.Command{
 		defer unsub()
 
 		go func() {
-			// Set this up once
-			agent.GetMcpTools(ctx)
 			for msg := range ch {
 				tui.Send(msg)
 			}
@@ -96,6 +95,16 @@ func setupSubscriptions(app *app.App) (chan tea.Msg, func()) {
 			wg.Done()
 		}()
 	}
+	{
+		sub := app.Todos.Subscribe(ctx)
+		wg.Add(1)
+		go func() {
+			for ev := range sub {
+				ch <- ev
+			}
+			wg.Done()
+		}()
+	}
 	{
 		sub := app.Permissions.Subscribe(ctx)
 		wg.Add(1)
@@ -106,6 +115,46 @@ func setupSubscriptions(app *app.App) (chan tea.Msg, func()) {
 			wg.Done()
 		}()
 	}
+	{
+		sub := shell.SubscribeProcesses(ctx)
+		wg.Add(1)
+		go func() {
+			for ev := range sub {
+				ch <- ev
+			}
+			wg.Done()
+		}()
+	}
+	{
+		sub := shell.SubscribeShells(ctx)
+		wg.Add(1)
+		go func() {
+			for ev := range sub {
+				ch <- ev
+			}
+			wg.Done()
+		}()
+	}
+	if app.Watcher != nil {
+		sub := app.Watcher.Subscribe(ctx)
+		wg.Add(1)
+		go func() {
+			for ev := range sub {
+				ch <- ev
+			}
+			wg.Done()
+		}()
+	}
+	if app.CoderAgent != nil {
+		sub := app.CoderAgent.Subscribe(ctx)
+		wg.Add(1)
+		go func() {
+			for ev := range sub {
+				ch <- ev
+			}
+			wg.Done()
+		}()
+	}
 	return ch, func() {
 		cancel()
 		wg.Wait()
@@ -3,13 +3,21 @@ package app
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/imnulhaqueruman/opencode-poc/internal/config"
 	"github.com/imnulhaqueruman/opencode-poc/internal/db"
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/agent"
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools"
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools/shell"
 	"github.com/imnulhaqueruman/opencode-poc/internal/logging"
+	"github.com/imnulhaqueruman/opencode-poc/internal/lsp"
 	"github.com/imnulhaqueruman/opencode-poc/internal/message"
 	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
 	"github.com/imnulhaqueruman/opencode-poc/internal/session"
+	"github.com/imnulhaqueruman/opencode-poc/internal/todo"
 )
 
 type App struct {
@@ -17,8 +25,18 @@ type App struct {
 
 	Sessions    session.Service
 	Messages    message.Service
+	Todos       todo.Service
+	Files       filerecord.Service
 	Permissions permission.Service
 
+	// Watcher is nil when the working directory cannot be watched
+	Watcher tools.FileWatcher
+
+	// CoderAgent is nil when no usable provider is configured
+	CoderAgent agent.Service
+
+	LSP *lsp.Manager
+
 	Logger logging.Interface
 }
 
@@ -29,12 +47,64 @@ func New(ctx context.Context, conn *sql.DB) *App {
 	})
 	sessions := session.NewService(ctx, q)
 	messages := message.NewService(ctx, q)
+	todos := todo.NewService(ctx, conn, q)
+	files := filerecord.NewService(ctx, q)
+
+	lspManager := lsp.NewManager(config.WorkingDirectory())
+	lspManager.Start(config.Get().LSP)
+
+	// shells, background processes and stored outputs do not outlive their
+	// session
+	go func() {
+		for ev := range sessions.Subscribe(ctx) {
+			if ev.Type == pubsub.DeletedEvent {
+				shell.CloseSessionShells(ev.Payload.ID)
+				shell.KillSessionProcesses(ev.Payload.ID)
+				if err := tools.RemoveSessionArtifacts(ev.Payload.ID); err != nil {
+					log.Error("Failed to remove the stored outputs of the session", "error", err)
+				}
+			}
+		}
+	}()
+
+	watcher, err := tools.NewFileWatcher(ctx, config.WorkingDirectory(), files)
+	if err != nil {
+		log.Error("Failed to watch the working directory", "error", err)
+	}
+
+	coderAgent, err := agent.NewCoderAgent(ctx, sessions, messages, todos, files, watcher, lspManager)
+	if err != nil {
+		log.Error("Failed to create coder agent", "error", err)
+	}
 
 	return &App{
 		Context:     ctx,
 		Sessions:    sessions,
 		Messages:    messages,
+		Todos:       todos,
+		Files:       files,
+		Watcher:     watcher,
 		Permissions: permission.Default,
+		CoderAgent:  coderAgent,
+		LSP:         lspManager,
 		Logger:      log,
 	}
 }
+
+// Shutdown cancels any running agent requests, closes the shells, kills the
+// background processes, stops watching the working directory and stops the
+// language servers.
+func (a *App) Shutdown() {
+	if a.CoderAgent != nil {
+		a.CoderAgent.CancelAll()
+	}
+	shell.CloseAllShells()
+	shell.KillAllProcesses()
+	if a.Watcher != nil {
+		a.Watcher.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	a.LSP.Shutdown(ctx)
+}
@@ -49,11 +49,62 @@ type Log struct {
 	Level string `json:"level"`
 }
 
+// LSPConfig configures a language server, it is used for files with one of
+// the listed extensions
+type LSPConfig struct {
+	Disabled   bool     `json:"disabled"`
+	Command    string   `json:"command"`
+	Args       []string `json:"args"`
+	Env        []string `json:"env"`
+	Extensions []string `json:"extensions"`
+	Options    any      `json:"options"`
+}
+
+// Fetch limits the domains the fetch tool can access. Denied domains take
+// precedence, and when allowed domains are set any other domain is denied.
+type Fetch struct {
+	AllowedDomains []string `json:"allowedDomains"`
+	DeniedDomains  []string `json:"deniedDomains"`
+}
+
+// Sandbox restricts the commands run by the bash tool on Linux. Writes are
+// limited to the working directory, the temp directories and WritablePaths,
+// and DisableNetwork removes network access.
+type Sandbox struct {
+	Enabled        bool     `json:"enabled"`
+	DisableNetwork bool     `json:"disableNetwork"`
+	WritablePaths  []string `json:"writablePaths"`
+}
+
+// TestRunner is a command of the test tool. Command is a Go template run by
+// the shell in the working directory, .Package and .Test hold the package and
+// the test name given to the tool, shell quoted, or are empty. Format is "go"
+// for commands printing go test -json events, or "junit" for commands writing
+// JUnit XML reports to the files matching Reports.
+type TestRunner struct {
+	Command string `json:"command"`
+	Format  string `json:"format"`
+	Reports string `json:"reports"`
+}
+
+// Redact configures the redaction of secrets from tool results and user
+// messages. On top of the known key formats, the values of the environment
+// variables listed in EnvVars are redacted.
+type Redact struct {
+	Disabled bool     `json:"disabled"`
+	EnvVars  []string `json:"envVars"`
+}
+
 type Config struct {
 	Data       *Data                             `json:"data,omitempty"`
 	Log        *Log                              `json:"log,omitempty"`
 	MCPServers map[string]MCPServer              `json:"mcpServers,omitempty"`
 	Providers  map[models.ModelProvider]Provider `json:"providers,omitempty"`
+	LSP        map[string]LSPConfig              `json:"lsp,omitempty"`
+	Fetch      *Fetch                            `json:"fetch,omitempty"`
+	Sandbox    *Sandbox                          `json:"sandbox,omitempty"`
+	Tests      map[string]TestRunner             `json:"tests,omitempty"`
+	Redact     *Redact                           `json:"redact,omitempty"`
 
 	Model *Model `json:"model,omitempty"`
 }
@@ -174,6 +225,16 @@ func Load(debug bool) error {
 		cfg.Model.TaskMaxTokens = defaultMaxTokens
 	}
 
+	// Use gopls for Go files unless language servers are configured
+	if cfg.LSP == nil {
+		cfg.LSP = map[string]LSPConfig{
+			"gopls": {
+				Command:    "gopls",
+				Extensions: []string{".go"},
+			},
+		}
+	}
+
 	for _, v := range cfg.MCPServers {
 		if v.Type == "" {
 			v.Type = MCPStdio
@@ -30,6 +30,12 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
+	if q.createTodoStmt, err = db.PrepareContext(ctx, createTodo); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTodo: %w", err)
+	}
+	if q.deleteFilesStmt, err = db.PrepareContext(ctx, deleteFiles); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteFiles: %w", err)
+	}
 	if q.deleteMessageStmt, err = db.PrepareContext(ctx, deleteMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteMessage: %w", err)
 	}
@@ -39,18 +45,36 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.deleteSessionMessagesStmt, err = db.PrepareContext(ctx, deleteSessionMessages); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSessionMessages: %w", err)
 	}
+	if q.deleteSessionTodosStmt, err = db.PrepareContext(ctx, deleteSessionTodos); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSessionTodos: %w", err)
+	}
+	if q.getFileStmt, err = db.PrepareContext(ctx, getFile); err != nil {
+		return nil, fmt.Errorf("error preparing query GetFile: %w", err)
+	}
 	if q.getMessageStmt, err = db.PrepareContext(ctx, getMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query GetMessage: %w", err)
 	}
 	if q.getSessionByIDStmt, err = db.PrepareContext(ctx, getSessionByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSessionByID: %w", err)
 	}
+	if q.listFilesByPathStmt, err = db.PrepareContext(ctx, listFilesByPath); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFilesByPath: %w", err)
+	}
 	if q.listMessagesBySessionStmt, err = db.PrepareContext(ctx, listMessagesBySession); err != nil {
 		return nil, fmt.Errorf("error preparing query ListMessagesBySession: %w", err)
 	}
 	if q.listSessionsStmt, err = db.PrepareContext(ctx, listSessions); err != nil {
 		return nil, fmt.Errorf("error preparing query ListSessions: %w", err)
 	}
+	if q.listTodosBySessionStmt, err = db.PrepareContext(ctx, listTodosBySession); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTodosBySession: %w", err)
+	}
+	if q.recordFileReadStmt, err = db.PrepareContext(ctx, recordFileRead); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordFileRead: %w", err)
+	}
+	if q.recordFileWriteStmt, err = db.PrepareContext(ctx, recordFileWrite); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordFileWrite: %w", err)
+	}
 	if q.updateMessageStmt, err = db.PrepareContext(ctx, updateMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateMessage: %w", err)
 	}
@@ -72,6 +96,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
 		}
 	}
+	if q.createTodoStmt != nil {
+		if cerr := q.createTodoStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTodoStmt: %w", cerr)
+		}
+	}
+	if q.deleteFilesStmt != nil {
+		if cerr := q.deleteFilesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteFilesStmt: %w", cerr)
+		}
+	}
 	if q.deleteMessageStmt != nil {
 		if cerr := q.deleteMessageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteMessageStmt: %w", cerr)
@@ -87,6 +121,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteSessionMessagesStmt: %w", cerr)
 		}
 	}
+	if q.deleteSessionTodosStmt != nil {
+		if cerr := q.deleteSessionTodosStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSessionTodosStmt: %w", cerr)
+		}
+	}
+	if q.getFileStmt != nil {
+		if cerr := q.getFileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getFileStmt: %w", cerr)
+		}
+	}
 	if q.getMessageStmt != nil {
 		if cerr := q.getMessageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getMessageStmt: %w", cerr)
@@ -97,6 +141,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getSessionByIDStmt: %w", cerr)
 		}
 	}
+	if q.listFilesByPathStmt != nil {
+		if cerr := q.listFilesByPathStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFilesByPathStmt: %w", cerr)
+		}
+	}
 	if q.listMessagesBySessionStmt != nil {
 		if cerr := q.listMessagesBySessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listMessagesBySessionStmt: %w", cerr)
@@ -107,6 +156,21 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listSessionsStmt: %w", cerr)
 		}
 	}
+	if q.listTodosBySessionStmt != nil {
+		if cerr := q.listTodosBySessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTodosBySessionStmt: %w", cerr)
+		}
+	}
+	if q.recordFileReadStmt != nil {
+		if cerr := q.recordFileReadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordFileReadStmt: %w", cerr)
+		}
+	}
+	if q.recordFileWriteStmt != nil {
+		if cerr := q.recordFileWriteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordFileWriteStmt: %w", cerr)
+		}
+	}
 	if q.updateMessageStmt != nil {
 		if cerr := q.updateMessageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateMessageStmt: %w", cerr)
@@ -158,13 +222,21 @@ type Queries struct {
 	tx                        *sql.Tx
 	createMessageStmt         *sql.Stmt
 	createSessionStmt         *sql.Stmt
+	createTodoStmt            *sql.Stmt
+	deleteFilesStmt           *sql.Stmt
 	deleteMessageStmt         *sql.Stmt
 	deleteSessionStmt         *sql.Stmt
 	deleteSessionMessagesStmt *sql.Stmt
+	deleteSessionTodosStmt    *sql.Stmt
+	getFileStmt               *sql.Stmt
 	getMessageStmt            *sql.Stmt
 	getSessionByIDStmt        *sql.Stmt
+	listFilesByPathStmt       *sql.Stmt
 	listMessagesBySessionStmt *sql.Stmt
 	listSessionsStmt          *sql.Stmt
+	listTodosBySessionStmt    *sql.Stmt
+	recordFileReadStmt        *sql.Stmt
+	recordFileWriteStmt       *sql.Stmt
 	updateMessageStmt         *sql.Stmt
 	updateSessionStmt         *sql.Stmt
 }
@@ -175,13 +247,21 @@ func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 		tx:                        tx,
 		createMessageStmt:         q.createMessageStmt,
 		createSessionStmt:         q.createSessionStmt,
+		createTodoStmt:            q.createTodoStmt,
+		deleteFilesStmt:           q.deleteFilesStmt,
 		deleteMessageStmt:         q.deleteMessageStmt,
 		deleteSessionStmt:         q.deleteSessionStmt,
 		deleteSessionMessagesStmt: q.deleteSessionMessagesStmt,
+		deleteSessionTodosStmt:    q.deleteSessionTodosStmt,
+		getFileStmt:               q.getFileStmt,
 		getMessageStmt:            q.getMessageStmt,
 		getSessionByIDStmt:        q.getSessionByIDStmt,
+		listFilesByPathStmt:       q.listFilesByPathStmt,
 		listMessagesBySessionStmt: q.listMessagesBySessionStmt,
 		listSessionsStmt:          q.listSessionsStmt,
+		listTodosBySessionStmt:    q.listTodosBySessionStmt,
+		recordFileReadStmt:        q.recordFileReadStmt,
+		recordFileWriteStmt:       q.recordFileWriteStmt,
 		updateMessageStmt:         q.updateMessageStmt,
 		updateSessionStmt:         q.updateSessionStmt,
 	}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS todos;
\ No newline at end of file
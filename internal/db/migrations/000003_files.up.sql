@@ -0,0 +1,12 @@
+-- Files read and written by the agent of each session
+CREATE TABLE IF NOT EXISTS files (
+    session_id TEXT NOT NULL,
+    path TEXT NOT NULL,
+    hash TEXT NOT NULL,  -- SHA-256 of the content the session last saw
+    read_at INTEGER NOT NULL DEFAULT 0,  -- Unix timestamp in milliseconds
+    written_at INTEGER NOT NULL DEFAULT 0,  -- Unix timestamp in milliseconds
+    created_at INTEGER NOT NULL,  -- Unix timestamp in milliseconds
+    updated_at INTEGER NOT NULL,  -- Unix timestamp in milliseconds
+    PRIMARY KEY (session_id, path),
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
@@ -8,6 +8,16 @@ import (
 	"database/sql"
 )
 
+type File struct {
+	SessionID string `json:"session_id"`
+	Path      string `json:"path"`
+	Hash      string `json:"hash"`
+	ReadAt    int64  `json:"read_at"`
+	WrittenAt int64  `json:"written_at"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
 type Message struct {
 	ID          string         `json:"id"`
 	SessionID   string         `json:"session_id"`
@@ -32,3 +42,13 @@ type Session struct {
 	UpdatedAt        int64          `json:"updated_at"`
 	CreatedAt        int64          `json:"created_at"`
 }
+
+type Todo struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id"`
+	Content   string `json:"content"`
+	Status    string `json:"status"`
+	Position  int64  `json:"position"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
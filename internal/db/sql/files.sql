@@ -0,0 +1,52 @@
+-- name: DeleteFiles :exec
+DELETE FROM files
+WHERE session_id = sqlc.arg(session_id)
+  AND (path = sqlc.arg(path) OR instr(path, sqlc.arg(dir)) = 1);
+
+-- name: GetFile :one
+SELECT *
+FROM files
+WHERE session_id = ? AND path = ?
+LIMIT 1;
+
+-- name: ListFilesByPath :many
+SELECT *
+FROM files
+WHERE path = ?
+ORDER BY session_id ASC;
+
+-- name: RecordFileRead :one
+INSERT INTO files (
+    session_id,
+    path,
+    hash,
+    read_at,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now'), strftime('%s', 'now')
+)
+ON CONFLICT (session_id, path) DO UPDATE SET
+    hash = excluded.hash,
+    read_at = excluded.read_at,
+    updated_at = excluded.updated_at
+RETURNING *;
+
+-- name: RecordFileWrite :one
+INSERT INTO files (
+    session_id,
+    path,
+    hash,
+    read_at,
+    written_at,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now'), strftime('%s', 'now'), strftime('%s', 'now')
+)
+ON CONFLICT (session_id, path) DO UPDATE SET
+    hash = excluded.hash,
+    read_at = excluded.read_at,
+    written_at = excluded.written_at,
+    updated_at = excluded.updated_at
+RETURNING *;
\ No newline at end of file
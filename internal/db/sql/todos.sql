@@ -0,0 +1,23 @@
+-- name: ListTodosBySession :many
+SELECT *
+FROM todos
+WHERE session_id = ?
+ORDER BY position ASC;
+
+-- name: CreateTodo :one
+INSERT INTO todos (
+    id,
+    session_id,
+    content,
+    status,
+    position,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+)
+RETURNING *;
+
+-- name: DeleteSessionTodos :exec
+DELETE FROM todos
+WHERE session_id = ?;
\ No newline at end of file
@@ -0,0 +1,124 @@
+package filerecord
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/db"
+)
+
+// ErrNotRecorded is returned by Get when the session never read nor wrote the
+// file.
+var ErrNotRecorded = errors.New("file not recorded")
+
+// Record is what a session last saw of a file, read or written by its agent.
+type Record struct {
+	SessionID string
+	Path      string
+	Hash      string // of the content the session last saw
+	ReadAt    int64
+	WrittenAt int64
+}
+
+type Service interface {
+	Get(sessionID, path string) (Record, error)
+	// ListByPath returns the records of a file in every session.
+	ListByPath(path string) ([]Record, error)
+	RecordRead(sessionID, path, hash string) (Record, error)
+	// RecordWrite also counts as a read, the session knows what it wrote.
+	RecordWrite(sessionID, path, hash string) (Record, error)
+	// Forget removes the records of a file, or of the files below a
+	// directory, after the session moved or deleted it.
+	Forget(sessionID, path string) error
+}
+
+type service struct {
+	q   db.Querier
+	ctx context.Context
+}
+
+func (s *service) Get(sessionID, path string) (Record, error) {
+	dbFile, err := s.q.GetFile(s.ctx, db.GetFileParams{
+		SessionID: sessionID,
+		Path:      path,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Record{}, ErrNotRecorded
+	}
+	if err != nil {
+		return Record{}, err
+	}
+	return s.fromDBItem(dbFile), nil
+}
+
+func (s *service) ListByPath(path string) ([]Record, error) {
+	dbFiles, err := s.q.ListFilesByPath(s.ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, len(dbFiles))
+	for i, dbFile := range dbFiles {
+		records[i] = s.fromDBItem(dbFile)
+	}
+	return records, nil
+}
+
+func (s *service) RecordRead(sessionID, path, hash string) (Record, error) {
+	dbFile, err := s.q.RecordFileRead(s.ctx, db.RecordFileReadParams{
+		SessionID: sessionID,
+		Path:      path,
+		Hash:      hash,
+	})
+	if err != nil {
+		return Record{}, err
+	}
+	return s.fromDBItem(dbFile), nil
+}
+
+func (s *service) RecordWrite(sessionID, path, hash string) (Record, error) {
+	dbFile, err := s.q.RecordFileWrite(s.ctx, db.RecordFileWriteParams{
+		SessionID: sessionID,
+		Path:      path,
+		Hash:      hash,
+	})
+	if err != nil {
+		return Record{}, err
+	}
+	return s.fromDBItem(dbFile), nil
+}
+
+func (s *service) Forget(sessionID, path string) error {
+	return s.q.DeleteFiles(s.ctx, db.DeleteFilesParams{
+		SessionID: sessionID,
+		Path:      path,
+		Dir:       strings.TrimSuffix(path, string(filepath.Separator)) + string(filepath.Separator),
+	})
+}
+
+func (s service) fromDBItem(item db.File) Record {
+	return Record{
+		SessionID: item.SessionID,
+		Path:      item.Path,
+		Hash:      item.Hash,
+		ReadAt:    item.ReadAt,
+		WrittenAt: item.WrittenAt,
+	}
+}
+
+// Hash returns the hash recorded for a file content.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func NewService(ctx context.Context, q db.Querier) Service {
+	return &service{
+		q,
+		ctx,
+	}
+}
@@ -5,32 +5,162 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"sync"
+	"time"
 
-	"github.com/imnulhaqueruman/opencode-poc/internal/app"
+	"github.com/google/uuid"
 	"github.com/imnulhaqueruman/opencode-poc/internal/config"
 	"github.com/imnulhaqueruman/opencode-poc/internal/llm/models"
 	"github.com/imnulhaqueruman/opencode-poc/internal/llm/prompt"
 	"github.com/imnulhaqueruman/opencode-poc/internal/llm/provider"
 	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools"
+	"github.com/imnulhaqueruman/opencode-poc/internal/logging"
 	"github.com/imnulhaqueruman/opencode-poc/internal/message"
+	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
+	"github.com/imnulhaqueruman/opencode-poc/internal/redact"
+	"github.com/imnulhaqueruman/opencode-poc/internal/session"
+	"github.com/imnulhaqueruman/opencode-poc/internal/todo"
 )
 
-type Agent interface {
-	Generate(sessionID string, content string) error
+const (
+	AgentEventStarted     pubsub.EventType = "agent_started"
+	AgentEventToolRunning pubsub.EventType = "agent_tool_running"
+	AgentEventCompleted   pubsub.EventType = "agent_completed"
+	AgentEventError       pubsub.EventType = "agent_error"
+)
+
+// titleGenerationTimeout bounds the request naming a new session
+const titleGenerationTimeout = time.Minute
+
+var (
+	ErrRequestCancelled = errors.New("request cancelled by user")
+	ErrSessionBusy      = errors.New("session is currently processing another request")
+)
+
+// AgentEvent describes the lifecycle of a single request made to an agent.
+type AgentEvent struct {
+	ID        string
+	Type      pubsub.EventType
+	SessionID string
+	// ToolName is set for tool running events
+	ToolName string
+	// Message is the last assistant message, set for completed events
+	Message message.Message
+	Error   error
+}
+
+type Service interface {
+	pubsub.Suscriber[AgentEvent]
+	// Run starts a request for the given session in the background. The
+	// returned channel receives the final completed or error event.
+	Run(ctx context.Context, sessionID string, content string) (<-chan AgentEvent, error)
+	Cancel(sessionID string)
+	CancelAll()
+	IsSessionBusy(sessionID string) bool
+	IsBusy() bool
+}
+
+type activeRequest struct {
+	id     string
+	cancel context.CancelFunc
 }
 
 type agent struct {
-	*app.App
+	*pubsub.Broker[AgentEvent]
+	// ctx lives as long as the agent, for the work outliving a request
+	ctx            context.Context
+	sessions       session.Service
+	messages       message.Service
+	todos          todo.Service      // nil for agents without the todo tool
+	watcher        tools.FileWatcher // nil when the workspace is not watched
+	redactor       *redact.Redactor  // nil when redaction is disabled
 	model          models.Model
 	tools          []tools.BaseTool
-	agent          provider.Provider
+	provider       provider.Provider
 	titleGenerator provider.Provider
+
+	activeRequests sync.Map // sessionID -> activeRequest
 }
 
+func (c *agent) Run(ctx context.Context, sessionID string, content string) (<-chan AgentEvent, error) {
+	genCtx, cancel := context.WithCancel(ctx)
+	request := activeRequest{
+		id:     uuid.New().String(),
+		cancel: cancel,
+	}
+	if _, busy := c.activeRequests.LoadOrStore(sessionID, request); busy {
+		cancel()
+		return nil, ErrSessionBusy
+	}
+
+	c.Publish(AgentEventStarted, AgentEvent{
+		ID:        request.id,
+		Type:      AgentEventStarted,
+		SessionID: sessionID,
+	})
+
+	events := make(chan AgentEvent, 1)
+	go func() {
+		defer close(events)
+		msg, err := c.generate(genCtx, request.id, sessionID, content)
+		c.activeRequests.Delete(sessionID)
+		cancel()
+
+		event := AgentEvent{
+			ID:        request.id,
+			Type:      AgentEventCompleted,
+			SessionID: sessionID,
+			Message:   msg,
+		}
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				err = ErrRequestCancelled
+			}
+			log.Printf("agent request %s failed: %s", request.id, err)
+			event.Type = AgentEventError
+			event.Error = err
+		}
+		c.Publish(event.Type, event)
+		events <- event
+	}()
+	return events, nil
+}
+
+func (c *agent) Cancel(sessionID string) {
+	if request, ok := c.activeRequests.Load(sessionID); ok {
+		request.(activeRequest).cancel()
+	}
+}
+
+func (c *agent) CancelAll() {
+	c.activeRequests.Range(func(_, request any) bool {
+		request.(activeRequest).cancel()
+		return true
+	})
+}
+
+func (c *agent) IsSessionBusy(sessionID string) bool {
+	_, busy := c.activeRequests.Load(sessionID)
+	return busy
+}
+
+func (c *agent) IsBusy() bool {
+	busy := false
+	c.activeRequests.Range(func(_, _ any) bool {
+		busy = true
+		return false
+	})
+	return busy
+}
+
+// handleTitleGeneration names a new session. It does not use the context of
+// the request, which is cancelled as soon as a short reply completes.
 func (c *agent) handleTitleGeneration(sessionID, content string) {
+	ctx, cancel := context.WithTimeout(c.ctx, titleGenerationTimeout)
+	defer cancel()
 	response, err := c.titleGenerator.SendMessages(
-		c.Context,
+		ctx,
 		[]message.Message{
 			{
 				Role:    message.User,
@@ -43,18 +173,18 @@ func (c *agent) handleTitleGeneration(sessionID, content string) {
 		return
 	}
 
-	session, err := c.Sessions.Get(sessionID)
+	session, err := c.sessions.Get(sessionID)
 	if err != nil {
 		return
 	}
 	if response.Content != "" {
 		session.Title = response.Content
-		c.Sessions.Save(session)
+		c.sessions.Save(session)
 	}
 }
 
 func (c *agent) TrackUsage(sessionID string, model models.Model, usage provider.TokenUsage) error {
-	session, err := c.Sessions.Get(sessionID)
+	session, err := c.sessions.Get(sessionID)
 	if err != nil {
 		return err
 	}
@@ -68,7 +198,7 @@ func (c *agent) TrackUsage(sessionID string, model models.Model, usage provider.
 	session.CompletionTokens += usage.OutputTokens
 	session.PromptTokens += usage.InputTokens
 
-	_, err = c.Sessions.Save(session)
+	_, err = c.sessions.Save(session)
 	return err
 }
 
@@ -80,17 +210,17 @@ func (c *agent) processEvent(
 	switch event.Type {
 	case provider.EventThinkingDelta:
 		assistantMsg.Thinking += event.Thinking
-		return c.Messages.Update(*assistantMsg)
+		return c.messages.Update(*assistantMsg)
 	case provider.EventContentDelta:
 		assistantMsg.Content += event.Content
-		return c.Messages.Update(*assistantMsg)
+		return c.messages.Update(*assistantMsg)
 	case provider.EventError:
 		log.Println("error", event.Error)
 		return event.Error
 
 	case provider.EventComplete:
 		assistantMsg.ToolCalls = event.Response.ToolCalls
-		err := c.Messages.Update(*assistantMsg)
+		err := c.messages.Update(*assistantMsg)
 		if err != nil {
 			return err
 		}
@@ -138,6 +268,8 @@ func (c *agent) ExecuteTools(ctx context.Context, toolCalls []message.ToolCall,
 				isError = true
 			}
 
+			response = c.redact(tools.GetSessionFromContext(ctx), "tool "+toolCall.Name, response)
+
 			mutex.Lock()
 			defer mutex.Unlock()
 
@@ -155,18 +287,28 @@ func (c *agent) ExecuteTools(ctx context.Context, toolCalls []message.ToolCall,
 
 func (c *agent) handleToolExecution(
 	ctx context.Context,
+	requestID string,
 	assistantMsg message.Message,
 ) (*message.Message, error) {
 	if len(assistantMsg.ToolCalls) == 0 {
 		return nil, nil
 	}
 
+	for _, toolCall := range assistantMsg.ToolCalls {
+		c.Publish(AgentEventToolRunning, AgentEvent{
+			ID:        requestID,
+			Type:      AgentEventToolRunning,
+			SessionID: assistantMsg.SessionID,
+			ToolName:  toolCall.Name,
+		})
+	}
+
 	toolResults, err := c.ExecuteTools(ctx, assistantMsg.ToolCalls, c.tools)
 	if err != nil {
 		return nil, err
 	}
 
-	msg, err := c.Messages.Create(assistantMsg.SessionID, message.CreateMessageParams{
+	msg, err := c.messages.Create(assistantMsg.SessionID, message.CreateMessageParams{
 		Role:        message.Tool,
 		ToolResults: toolResults,
 	})
@@ -174,57 +316,68 @@ func (c *agent) handleToolExecution(
 	return &msg, err
 }
 
-func (c *agent) generate(sessionID string, content string) error {
-	messages, err := c.Messages.List(sessionID)
+func (c *agent) generate(ctx context.Context, requestID string, sessionID string, content string) (message.Message, error) {
+	ctx = context.WithValue(ctx, tools.SessionIDContextKey, sessionID)
+	messages, err := c.messages.List(sessionID)
 	if err != nil {
-		return err
+		return message.Message{}, err
 	}
 
+	content = c.redact(sessionID, "user message", content)
 	if len(messages) == 0 {
 		go c.handleTitleGeneration(sessionID, content)
 	}
 
-	userMsg, err := c.Messages.Create(sessionID, message.CreateMessageParams{
+	userMsg, err := c.messages.Create(sessionID, message.CreateMessageParams{
 		Role:    message.User,
 		Content: content,
 	})
 	if err != nil {
-		return err
+		return message.Message{}, err
 	}
 
 	messages = append(messages, userMsg)
+	messages = c.withTodoReminder(sessionID, messages)
+	messages = c.withExternalChanges(sessionID, messages)
 	for {
-
-		eventChan, err := c.agent.StreamResponse(c.Context, messages, c.tools)
+		eventChan, err := c.provider.StreamResponse(ctx, messages, c.tools)
 		if err != nil {
-			return err
+			return message.Message{}, err
 		}
 
-		assistantMsg, err := c.Messages.Create(sessionID, message.CreateMessageParams{
+		assistantMsg, err := c.messages.Create(sessionID, message.CreateMessageParams{
 			Role:    message.Assistant,
 			Content: "",
 		})
 		if err != nil {
-			return err
+			return message.Message{}, err
 		}
 		for event := range eventChan {
 			err = c.processEvent(sessionID, &assistantMsg, event)
 			if err != nil {
 				assistantMsg.Finished = true
-				c.Messages.Update(assistantMsg)
-				return err
+				c.messages.Update(assistantMsg)
+				return assistantMsg, err
 			}
 		}
+		if ctx.Err() != nil {
+			assistantMsg.Finished = true
+			c.messages.Update(assistantMsg)
+			return assistantMsg, ctx.Err()
+		}
 
-		msg, err := c.handleToolExecution(c.Context, assistantMsg)
+		msg, err := c.handleToolExecution(ctx, requestID, assistantMsg)
 		assistantMsg.Finished = true
-		c.Messages.Update(assistantMsg)
+		c.messages.Update(assistantMsg)
 		if err != nil {
-			return err
+			return assistantMsg, err
+		}
+		if ctx.Err() != nil {
+			return assistantMsg, ctx.Err()
 		}
 
 		if len(assistantMsg.ToolCalls) == 0 {
-			break
+			return assistantMsg, nil
 		}
 
 		messages = append(messages, assistantMsg)
@@ -232,7 +385,80 @@ func (c *agent) generate(sessionID string, content string) error {
 			messages = append(messages, *msg)
 		}
 	}
-	return nil
+}
+
+// redact replaces the secrets in content before it is saved and sent to the
+// provider. Each redaction is logged with its counts, for the logs page.
+func (c *agent) redact(sessionID, source, content string) string {
+	if c.redactor == nil {
+		return content
+	}
+	redacted, counts := c.redactor.Redact(content)
+	if count := counts.Total(); count > 0 {
+		logging.Get().Info("Redacted secrets",
+			"session", sessionID,
+			"source", source,
+			"count", count,
+			"kinds", counts.String(),
+			"total", redact.TotalRedacted(),
+		)
+	}
+	return redacted
+}
+
+// withTodoReminder adds the open todos of the session to the last message
+// when no todo tool call is left in the history, like after the history was
+// compacted, so long tasks stay on track. The reminder is only sent to the
+// provider, it is not saved.
+func (c *agent) withTodoReminder(sessionID string, messages []message.Message) []message.Message {
+	if c.todos == nil || len(messages) == 0 {
+		return messages
+	}
+	for _, msg := range messages {
+		for _, call := range msg.ToolCalls {
+			if call.Name == tools.TodoToolName {
+				return messages
+			}
+		}
+	}
+	list, err := c.todos.Get(sessionID)
+	if err != nil || !list.Open() {
+		return messages
+	}
+
+	last := messages[len(messages)-1]
+	last.Content = fmt.Sprintf(
+		"<todo-reminder>\nThis is the todo list of the current task, keep it up to date with the %s tool.\n%s\n</todo-reminder>\n\n%s",
+		tools.TodoToolName,
+		tools.FormatTodoList(list),
+		last.Content,
+	)
+	result := slices.Clone(messages)
+	result[len(result)-1] = last
+	return result
+}
+
+// withExternalChanges tells the agent about the files of the session changed
+// outside termai since the last request, before it tries to edit them. Like
+// the todo reminder, the note is only sent to the provider.
+func (c *agent) withExternalChanges(sessionID string, messages []message.Message) []message.Message {
+	if c.watcher == nil || len(messages) == 0 {
+		return messages
+	}
+	changes := c.watcher.TakeExternalChanges(sessionID)
+	if len(changes) == 0 {
+		return messages
+	}
+
+	last := messages[len(messages)-1]
+	last.Content = fmt.Sprintf(
+		"<file-changes>\n%s\n</file-changes>\n\n%s",
+		tools.FormatExternalChanges(changes),
+		last.Content,
+	)
+	result := slices.Clone(messages)
+	result[len(result)-1] = last
+	return result
 }
 
 func getAgentProviders(ctx context.Context, model models.Model) (provider.Provider, provider.Provider, error) {
@@ -1,67 +1,79 @@
 package agent
 
 import (
+	"context"
 	"errors"
 
-	"github.com/imnulhaqueruman/opencode-poc/internal/app"
 	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
 	"github.com/imnulhaqueruman/opencode-poc/internal/llm/models"
 	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools"
+	"github.com/imnulhaqueruman/opencode-poc/internal/lsp"
+	"github.com/imnulhaqueruman/opencode-poc/internal/message"
+	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
+	"github.com/imnulhaqueruman/opencode-poc/internal/redact"
+	"github.com/imnulhaqueruman/opencode-poc/internal/session"
+	"github.com/imnulhaqueruman/opencode-poc/internal/todo"
 )
 
-type coderAgent struct {
-	*agent
-}
-
-func (c *coderAgent) setAgentTool(sessionID string) {
-	inx := -1
-	for i, tool := range c.tools {
-		if tool.Info().Name == AgentToolName {
-			inx = i
-			break
-		}
-	}
-	if inx == -1 {
-		c.tools = append(c.tools, NewAgentTool(sessionID, c.App))
-	} else {
-		c.tools[inx] = NewAgentTool(sessionID, c.App)
-	}
-}
-
-func (c *coderAgent) Generate(sessionID string, content string) error {
-	c.setAgentTool(sessionID)
-	return c.generate(sessionID, content)
-}
-
-func NewCoderAgent(app *app.App) (Agent, error) {
+func NewCoderAgent(ctx context.Context, sessions session.Service, messages message.Service, todos todo.Service, files filerecord.Service, watcher tools.FileWatcher, lspManager *lsp.Manager) (Service, error) {
 	model, ok := models.SupportedModels[config.Get().Model.Coder]
 	if !ok {
 		return nil, errors.New("model not supported")
 	}
 
-	agentProvider, titleGenerator, err := getAgentProviders(app.Context, model)
+	agentProvider, titleGenerator, err := getAgentProviders(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+
+	taskAgent, err := NewTaskAgent(ctx, sessions, messages, files, lspManager)
 	if err != nil {
 		return nil, err
 	}
 
-	mcpTools := GetMcpTools(app.Context)
-	return &coderAgent{
-		agent: &agent{
-			App: app,
-			tools: append(
-				[]tools.BaseTool{
-					tools.NewBashTool(),
-					tools.NewEditTool(),
-					tools.NewGlobTool(),
-					tools.NewGrepTool(),
-					tools.NewLsTool(),
-					tools.NewViewTool(),
-					tools.NewWriteTool(),
-				}, mcpTools...,
-			),
-			model:          model,
-			agent:          agentProvider,
-			titleGenerator: titleGenerator,
-		},
+	mcpTools := GetMcpTools(ctx)
+	return &agent{
+		Broker:   pubsub.NewBroker[AgentEvent](),
+		ctx:      ctx,
+		sessions: sessions,
+		messages: messages,
+		redactor: redact.FromConfig(),
+		todos:    todos,
+		watcher:  watcher,
+		tools: append(
+			[]tools.BaseTool{
+				tools.NewBashTool(config.Get().Sandbox),
+				tools.NewBashInputTool(),
+				tools.NewBashKillTool(),
+				tools.NewBashOutputTool(),
+				tools.NewCopyTool(files),
+				tools.NewDefinitionTool(lspManager),
+				tools.NewDeleteTool(files),
+				tools.NewDiagnosticsTool(lspManager),
+				tools.NewEditTool(lspManager, files),
+				tools.NewFetchTool(),
+				tools.NewGitTool(),
+				tools.NewGlobTool(),
+				tools.NewGrepTool(),
+				tools.NewLsTool(),
+				tools.NewMkdirTool(),
+				tools.NewMoveTool(files),
+				tools.NewMultiEditTool(lspManager, files),
+				tools.NewOutlineTool(),
+				tools.NewPatchTool(lspManager, files),
+				tools.NewReadOutputTool(),
+				tools.NewReferencesTool(lspManager),
+				tools.NewSymbolsTool(lspManager),
+				tools.NewTestTool(config.Get().Sandbox),
+				tools.NewTodoTool(todos),
+				tools.NewViewTool(lspManager, files),
+				tools.NewWriteTool(lspManager, files),
+				NewAgentTool(taskAgent, sessions, messages),
+			}, mcpTools...,
+		),
+		model:          model,
+		provider:       agentProvider,
+		titleGenerator: titleGenerator,
 	}, nil
-}
\ No newline at end of file
+}
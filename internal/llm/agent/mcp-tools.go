@@ -82,6 +82,7 @@ func (b *mcpTool) Run(ctx context.Context, params tools.ToolCall) (tools.ToolRes
 	permissionDescription := fmt.Sprintf("execute %s with the following parameters: %s", b.Info().Name, params.Input)
 	p := permission.Default.Request(
 		permission.CreatePermissionRequest{
+			SessionID:   tools.GetSessionFromContext(ctx),
 			Path:        config.WorkingDirectory(),
 			ToolName:    b.Info().Name,
 			Action:      "execute",
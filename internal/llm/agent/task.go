@@ -1,44 +1,50 @@
 package agent
 
 import (
+	"context"
 	"errors"
 
-	"github.com/imnulhaqueruman/opencode-poc/internal/app"
 	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
 	"github.com/imnulhaqueruman/opencode-poc/internal/llm/models"
 	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools"
+	"github.com/imnulhaqueruman/opencode-poc/internal/lsp"
+	"github.com/imnulhaqueruman/opencode-poc/internal/message"
+	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
+	"github.com/imnulhaqueruman/opencode-poc/internal/redact"
+	"github.com/imnulhaqueruman/opencode-poc/internal/session"
 )
 
-type taskAgent struct {
-	*agent
-}
-
-func (c *taskAgent) Generate(sessionID string, content string) error {
-	return c.generate(sessionID, content)
-}
-
-func NewTaskAgent(app *app.App) (Agent, error) {
+func NewTaskAgent(ctx context.Context, sessions session.Service, messages message.Service, files filerecord.Service, lspManager *lsp.Manager) (Service, error) {
 	model, ok := models.SupportedModels[config.Get().Model.Coder]
 	if !ok {
 		return nil, errors.New("model not supported")
 	}
 
-	agentProvider, titleGenerator, err := getAgentProviders(app.Context, model)
+	agentProvider, titleGenerator, err := getAgentProviders(ctx, model)
 	if err != nil {
 		return nil, err
 	}
-	return &taskAgent{
-		agent: &agent{
-			App: app,
-			tools: []tools.BaseTool{
-				tools.NewGlobTool(),
-				tools.NewGrepTool(),
-				tools.NewLsTool(),
-				tools.NewViewTool(),
-			},
-			model:          model,
-			agent:          agentProvider,
-			titleGenerator: titleGenerator,
+	return &agent{
+		Broker:   pubsub.NewBroker[AgentEvent](),
+		ctx:      ctx,
+		sessions: sessions,
+		messages: messages,
+		redactor: redact.FromConfig(),
+		tools: []tools.BaseTool{
+			tools.NewDefinitionTool(lspManager),
+			tools.NewGitTool(),
+			tools.NewGlobTool(),
+			tools.NewGrepTool(),
+			tools.NewLsTool(),
+			tools.NewOutlineTool(),
+			tools.NewReadOutputTool(),
+			tools.NewReferencesTool(lspManager),
+			tools.NewSymbolsTool(lspManager),
+			tools.NewViewTool(lspManager, files),
 		},
+		model:          model,
+		provider:       agentProvider,
+		titleGenerator: titleGenerator,
 	}, nil
-}
\ No newline at end of file
+}
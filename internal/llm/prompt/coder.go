@@ -176,6 +176,10 @@ func getEnvironmentInfo() string {
 	isGit := isGitRepo(cwd)
 	platform := runtime.GOOS
 	date := time.Now().Format("1/2/2006")
+	gitInfo := ""
+	if isGit {
+		gitInfo = getGitInfo()
+	}
 	ls := tools.NewLsTool()
 	r, _ := ls.Run(context.Background(), tools.ToolCall{
 		Input: `{"path":"."}`,
@@ -184,13 +188,31 @@ func getEnvironmentInfo() string {
 <env>
 Working directory: %s
 Is directory a git repo: %s
-Platform: %s
+%sPlatform: %s
 Today's date: %s
 </env>
 <project>
 %s
 </project>
-		`, cwd, boolToYesNo(isGit), platform, date, r.Content)
+		`, cwd, boolToYesNo(isGit), gitInfo, platform, date, r.Content)
+}
+
+// getGitInfo returns the branch and the state of the working tree, one
+// line each.
+func getGitInfo() string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	summary, err := tools.GetGitStatusSummary(ctx)
+	if err != nil {
+		return ""
+	}
+	state := "clean"
+	if summary.Changes == 1 {
+		state = "dirty, 1 changed file"
+	} else if summary.Changes > 1 {
+		state = fmt.Sprintf("dirty, %d changed files", summary.Changes)
+	}
+	return fmt.Sprintf("Git branch: %s\nGit working tree: %s\n", summary.Branch, state)
 }
 
 func isGitRepo(dir string) bool {
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// artifactIDPattern matches the ids given to stored outputs, the name of the
+// tool that produced the output followed by random hex digits.
+var artifactIDPattern = regexp.MustCompile(`^[a-z_]+-[0-9a-f]{8}$`)
+
+// artifactsDir returns the directory holding the stored outputs of a session,
+// in the data directory whatever the current directory of the process.
+func artifactsDir(sessionID string) string {
+	return filepath.Join(dataDirectory(), "artifacts", sessionID)
+}
+
+// artifactPath returns the file of a stored output, an error if the id is not
+// one of an artifact.
+func artifactPath(sessionID, id string) (string, error) {
+	if !artifactIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid artifact id %q", id)
+	}
+	return filepath.Join(artifactsDir(sessionID), id+".txt"), nil
+}
+
+// storeArtifact saves the full output of a tool for the session and returns
+// its id and path.
+func storeArtifact(sessionID, toolName, content string) (string, string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", "", err
+	}
+	id := toolName + "-" + hex.EncodeToString(suffix)
+	path, err := artifactPath(sessionID, id)
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		return "", "", err
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return id, path, nil
+}
+
+// spillOutput returns content as is when it fits in a tool response. Longer
+// content is stored as an artifact of the session, and the response keeps the
+// start and the end of it with a note telling how to read the rest.
+func spillOutput(ctx context.Context, toolName, content string) string {
+	if len(content) <= MaxOutputLength {
+		return content
+	}
+	sessionID := GetSessionFromContext(ctx)
+	if sessionID == "" {
+		return truncateOutput(content)
+	}
+	id, path, err := storeArtifact(sessionID, toolName, content)
+	if err != nil {
+		return truncateOutput(content)
+	}
+	return fmt.Sprintf("%s\n\n[The full output (%d bytes, %d lines) is stored in %s. Use the %s tool with artifact_id %q to page through or search it]",
+		truncateOutput(content), len(content), countLines(content), path, ReadOutputToolName, id)
+}
+
+// RemoveSessionArtifacts deletes the stored outputs of a session.
+func RemoveSessionArtifacts(sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+	return os.RemoveAll(artifactsDir(sessionID))
+}
@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools/shell"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+)
+
+const (
+	BashOutputToolName = "bash_output"
+	BashInputToolName  = "bash_input"
+	BashKillToolName   = "bash_kill"
+)
+
+type BashOutputParams struct {
+	ProcessID string `json:"process_id"`
+}
+
+type BashInputParams struct {
+	ProcessID string `json:"process_id"`
+	Input     string `json:"input"`
+}
+
+type BashInputPermissionsParams struct {
+	ProcessID string `json:"process_id"`
+	Command   string `json:"command"`
+	Input     string `json:"input"`
+}
+
+type BashKillParams struct {
+	ProcessID string `json:"process_id"`
+}
+
+type bashOutputTool struct{}
+
+type bashInputTool struct{}
+
+type bashKillTool struct{}
+
+func (b *bashOutputTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        BashOutputToolName,
+		Description: bashOutputDescription(),
+		Parameters: map[string]any{
+			"process_id": map[string]any{
+				"type":        "string",
+				"description": "The id of the background process, leave empty to list all background processes of the session",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+// Run implements Tool.
+func (b *bashOutputTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params BashOutputParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	sessionID := GetSessionFromContext(ctx)
+	if params.ProcessID == "" {
+		infos := shell.ListBackgroundProcesses(sessionID)
+		if len(infos) == 0 {
+			return NewTextResponse("No background processes"), nil
+		}
+		var sb strings.Builder
+		for _, info := range infos {
+			fmt.Fprintf(&sb, "%s: %s\n", info.ID, formatProcessStatus(info))
+			fmt.Fprintf(&sb, "  %s\n", info.Command)
+		}
+		return NewTextResponse(strings.TrimSuffix(sb.String(), "\n")), nil
+	}
+
+	process, err := shell.GetBackgroundProcess(sessionID, params.ProcessID)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("process %s not found", params.ProcessID)), nil
+	}
+	output, skipped := process.ReadOutput()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<status>%s</status>\n", formatProcessStatus(process.Info()))
+	if skipped > 0 {
+		fmt.Fprintf(&sb, "[%d bytes of output were dropped because the process wrote too much since the last read]\n", skipped)
+	}
+	if output == "" {
+		sb.WriteString("No new output")
+	} else {
+		fmt.Fprintf(&sb, "<output>\n%s\n</output>", strings.TrimSuffix(spillOutput(ctx, BashOutputToolName, output), "\n"))
+	}
+	return NewTextResponse(sb.String()), nil
+}
+
+func (b *bashInputTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        BashInputToolName,
+		Description: bashInputDescription(),
+		Parameters: map[string]any{
+			"process_id": map[string]any{
+				"type":        "string",
+				"description": "The id of the background process",
+			},
+			"input": map[string]any{
+				"type":        "string",
+				"description": "The text to write to the process stdin, end it with a newline to submit a line",
+			},
+		},
+		Required: []string{"process_id", "input"},
+	}
+}
+
+// Run implements Tool.
+func (b *bashInputTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params BashInputParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.Input == "" {
+		return NewTextErrorResponse("input is required"), nil
+	}
+
+	sessionID := GetSessionFromContext(ctx)
+	process, err := shell.GetBackgroundProcess(sessionID, params.ProcessID)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("process %s not found", params.ProcessID)), nil
+	}
+	info := process.Info()
+
+	p := permission.Default.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        info.Dir,
+			ToolName:    BashInputToolName,
+			Action:      "input",
+			Description: fmt.Sprintf("Send input to %s: %s", info.ID, info.Command),
+			Params: BashInputPermissionsParams{
+				ProcessID: info.ID,
+				Command:   info.Command,
+				Input:     params.Input,
+			},
+		},
+	)
+	if !p {
+		return NewTextErrorResponse("permission denied"), nil
+	}
+
+	if err := process.WriteInput(params.Input); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error sending input: %s", err)), nil
+	}
+	return NewTextResponse(fmt.Sprintf("Sent %d bytes to %s", len(params.Input), info.ID)), nil
+}
+
+func (b *bashKillTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        BashKillToolName,
+		Description: bashKillDescription(),
+		Parameters: map[string]any{
+			"process_id": map[string]any{
+				"type":        "string",
+				"description": "The id of the background process to stop",
+			},
+		},
+		Required: []string{"process_id"},
+	}
+}
+
+// Run implements Tool.
+func (b *bashKillTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params BashKillParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	sessionID := GetSessionFromContext(ctx)
+	process, err := shell.GetBackgroundProcess(sessionID, params.ProcessID)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("process %s not found", params.ProcessID)), nil
+	}
+	output, _ := process.ReadOutput()
+	if err := shell.RemoveBackgroundProcess(sessionID, params.ProcessID); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error stopping process: %s", err)), nil
+	}
+
+	result := fmt.Sprintf("Process %s %s", params.ProcessID, formatProcessStatus(process.Info()))
+	if output != "" {
+		result += fmt.Sprintf("\n<output>\n%s\n</output>", strings.TrimSuffix(spillOutput(ctx, BashKillToolName, output), "\n"))
+	}
+	return NewTextResponse(result), nil
+}
+
+func formatProcessStatus(info shell.ProcessInfo) string {
+	switch info.Status {
+	case shell.ProcessRunning:
+		return fmt.Sprintf("running for %s (pid %d)", time.Since(info.StartedAt).Round(time.Second), info.PID)
+	case shell.ProcessKilled:
+		return "was killed"
+	default:
+		return fmt.Sprintf("exited with code %d after %s", info.ExitCode, info.EndedAt.Sub(info.StartedAt).Round(time.Millisecond))
+	}
+}
+
+func bashOutputDescription() string {
+	return `Reads the output and status of background processes started with the Bash tool.
+
+WHEN TO USE THIS TOOL:
+- Use to check on a dev server, watcher or build started with run_in_background
+- Use without a process_id to list the background processes of the session
+
+HOW TO USE:
+- Provide the process_id returned when the process was started
+- Only the output written since the previous read is returned
+- The status tells if the process is still running or its exit code
+
+LIMITATIONS:
+- Stdout and stderr are combined
+- Only the latest 1MB of output is kept, long outputs are truncated
+
+TIPS:
+- Give slow processes a moment to start before reading their output
+- Stop processes you no longer need with the BashKill tool`
+}
+
+func bashInputDescription() string {
+	return `Sends input to the stdin of a background process started with the Bash tool.
+
+WHEN TO USE THIS TOOL:
+- Use to answer prompts of interactive programs or to send commands to a running REPL
+
+HOW TO USE:
+- Provide the process_id and the text to send
+- The text is sent exactly as given, end it with a newline to submit a line
+- Read the response with the BashOutput tool
+
+LIMITATIONS:
+- The process is not attached to a terminal, programs that need one may not work`
+}
+
+func bashKillDescription() string {
+	return `Stops a background process started with the Bash tool.
+
+WHEN TO USE THIS TOOL:
+- Use when a dev server, watcher or other background process is no longer needed
+
+HOW TO USE:
+- Provide the process_id of the process
+- The process and its children get SIGTERM, then SIGKILL if they are still running after 5 seconds
+- Any output not read yet is returned`
+}
+
+func NewBashOutputTool() BaseTool {
+	return &bashOutputTool{}
+}
+
+func NewBashInputTool() BaseTool {
+	return &bashInputTool{}
+}
+
+func NewBashKillTool() BaseTool {
+	return &bashKillTool{}
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools/shell"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackgroundTools_Info(t *testing.T) {
+	output := NewBashOutputTool().Info()
+	assert.Equal(t, BashOutputToolName, output.Name)
+	assert.NotEmpty(t, output.Description)
+	assert.Contains(t, output.Parameters, "process_id")
+
+	input := NewBashInputTool().Info()
+	assert.Equal(t, BashInputToolName, input.Name)
+	assert.Contains(t, input.Required, "process_id")
+	assert.Contains(t, input.Required, "input")
+
+	kill := NewBashKillTool().Info()
+	assert.Equal(t, BashKillToolName, kill.Name)
+	assert.Contains(t, kill.Required, "process_id")
+}
+
+func TestBackgroundTools_Run(t *testing.T) {
+	origPermission := permission.Default
+	defer func() {
+		permission.Default = origPermission
+	}()
+	permission.Default = newMockPermissionService(true)
+
+	sessionID := "background-test-session"
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, sessionID)
+	defer shell.KillSessionProcesses(sessionID)
+
+	run := func(t *testing.T, ctx context.Context, tool BaseTool, params any) ToolResponse {
+		paramsJSON, err := json.Marshal(params)
+		require.NoError(t, err)
+		response, err := tool.Run(ctx, ToolCall{
+			Name:  tool.Info().Name,
+			Input: string(paramsJSON),
+		})
+		require.NoError(t, err)
+		return response
+	}
+	processIDPattern := regexp.MustCompile(`Started background process (bg-\d+)`)
+	start := func(t *testing.T, command string) string {
+		response := run(t, ctx, NewBashTool(nil), BashParams{Command: command, RunInBackground: true})
+		require.False(t, response.IsError, response.Content)
+		match := processIDPattern.FindStringSubmatch(response.Content)
+		require.NotNil(t, match, response.Content)
+		return match[1]
+	}
+	// waitForOutput reads the process output until it contains want
+	waitForOutput := func(t *testing.T, id, want string) string {
+		var all string
+		require.Eventually(t, func() bool {
+			response := run(t, ctx, NewBashOutputTool(), BashOutputParams{ProcessID: id})
+			all += response.Content
+			return regexp.MustCompile(regexp.QuoteMeta(want)).MatchString(all)
+		}, 5*time.Second, 20*time.Millisecond)
+		return all
+	}
+
+	t.Run("returns immediately and reads incremental output", func(t *testing.T) {
+		started := time.Now()
+		id := start(t, "echo first; sleep 0.3; echo second; sleep 30")
+		assert.Less(t, time.Since(started), 5*time.Second)
+
+		output := waitForOutput(t, id, "first")
+		assert.NotContains(t, output, "second")
+
+		output = waitForOutput(t, id, "second")
+		assert.Contains(t, output, "running for")
+		assert.NotContains(t, output, "first")
+
+		response := run(t, ctx, NewBashKillTool(), BashKillParams{ProcessID: id})
+		assert.False(t, response.IsError)
+		assert.Contains(t, response.Content, "was killed")
+
+		response = run(t, ctx, NewBashOutputTool(), BashOutputParams{ProcessID: id})
+		assert.True(t, response.IsError)
+	})
+
+	t.Run("reports the exit code", func(t *testing.T) {
+		id := start(t, "echo done; exit 3")
+		waitForOutput(t, id, "exited with code 3")
+	})
+
+	t.Run("sends input", func(t *testing.T) {
+		id := start(t, "read line; echo \"got $line\"")
+		response := run(t, ctx, NewBashInputTool(), BashInputParams{ProcessID: id, Input: "hello\n"})
+		require.False(t, response.IsError, response.Content)
+		waitForOutput(t, id, "got hello")
+	})
+
+	t.Run("handles permission denied for input", func(t *testing.T) {
+		id := start(t, "sleep 30")
+		permission.Default = newMockPermissionService(false)
+		defer func() { permission.Default = newMockPermissionService(true) }()
+
+		response := run(t, ctx, NewBashInputTool(), BashInputParams{ProcessID: id, Input: "hello\n"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "permission denied")
+	})
+
+	t.Run("lists processes of the session only", func(t *testing.T) {
+		id := start(t, "sleep 30")
+
+		response := run(t, ctx, NewBashOutputTool(), BashOutputParams{})
+		assert.Contains(t, response.Content, id+": running")
+
+		otherCtx := context.WithValue(context.Background(), SessionIDContextKey, "other-session")
+		response = run(t, otherCtx, NewBashOutputTool(), BashOutputParams{})
+		assert.Equal(t, "No background processes", response.Content)
+		response = run(t, otherCtx, NewBashKillTool(), BashKillParams{ProcessID: id})
+		assert.True(t, response.IsError)
+	})
+
+	t.Run("kills every process of the session", func(t *testing.T) {
+		id := start(t, "sleep 30")
+		process, err := shell.GetBackgroundProcess(sessionID, id)
+		require.NoError(t, err)
+
+		shell.KillSessionProcesses(sessionID)
+		select {
+		case <-process.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("process was not killed")
+		}
+		assert.Empty(t, shell.ListBackgroundProcesses(sessionID))
+	})
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/imnulhaqueruman/opencode-poc/internal/config"
@@ -11,7 +14,9 @@ import (
 	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
 )
 
-type bashTool struct{}
+type bashTool struct {
+	sandbox *config.Sandbox
+}
 
 const (
 	BashToolName = "bash"
@@ -22,13 +27,20 @@ const (
 )
 
 type BashParams struct {
-	Command string `json:"command"`
-	Timeout int    `json:"timeout"`
+	Command         string `json:"command"`
+	Timeout         int    `json:"timeout"`
+	RunInBackground bool   `json:"run_in_background"`
+	OutsideSandbox  bool   `json:"outside_sandbox"`
 }
 
 type BashPermissionsParams struct {
-	Command string `json:"command"`
-	Timeout int    `json:"timeout"`
+	Command         string `json:"command"`
+	Timeout         int    `json:"timeout"`
+	RunInBackground bool   `json:"run_in_background"`
+	OutsideSandbox  bool   `json:"outside_sandbox"`
+	// Commands are the commands found in Command, the user sees every
+	// command that will run
+	Commands []string `json:"commands"`
 }
 
 var BannedCommands = []string{
@@ -55,6 +67,14 @@ func (b *bashTool) Info() ToolInfo {
 				"type":       "number",
 				"desription": "Optional timeout in milliseconds (max 600000)",
 			},
+			"run_in_background": map[string]any{
+				"type":        "boolean",
+				"description": "Start the command in the background and return its process id instead of waiting for it",
+			},
+			"outside_sandbox": map[string]any{
+				"type":        "boolean",
+				"description": "Run the command without the sandbox, only when it failed because of the sandbox, the user always has to approve it",
+			},
 		},
 		Required: []string{"command"},
 	}
@@ -77,41 +97,85 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		return NewTextErrorResponse("missing command"), nil
 	}
 
-	baseCmd := strings.Fields(params.Command)[0]
-	for _, banned := range BannedCommands {
-		if strings.EqualFold(baseCmd, banned) {
-			return NewTextErrorResponse(fmt.Sprintf("command '%s' is not allowed", baseCmd)), nil
-		}
+	commands, err := parseBashCommands(params.Command)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing command: %s", err)), nil
 	}
-	isSafeReadOnly := false
-	for _, safe := range SafeReadOnlyCommands {
-		if strings.EqualFold(baseCmd, safe) {
-			isSafeReadOnly = true
-			break
-		}
+	if banned := bannedBashCommand(commands); banned != "" {
+		return NewTextErrorResponse(fmt.Sprintf("command '%s' is not allowed", banned)), nil
 	}
-	if !isSafeReadOnly {
+	if shell := stdinShellCommand(commands); shell != "" {
+		return NewTextErrorResponse(fmt.Sprintf("command '%s' reads its script from the standard input, its commands cannot be checked. Pass the script with '%s -c' instead", shell, shell)), nil
+	}
+	sandbox := shellSandbox(b.sandbox)
+	if sandbox == nil {
+		// there is no sandbox to leave
+		params.OutsideSandbox = false
+	}
+	permissionParams := BashPermissionsParams{
+		Command:         params.Command,
+		Timeout:         params.Timeout,
+		RunInBackground: params.RunInBackground,
+		OutsideSandbox:  params.OutsideSandbox,
+	}
+	for _, command := range commands {
+		permissionParams.Commands = append(permissionParams.Commands, command.Source)
+	}
+	if params.OutsideSandbox {
+		// a separate action so approving sandboxed commands for the session
+		// does not approve these
+		p := permission.Default.Request(
+			permission.CreatePermissionRequest{
+				SessionID:   GetSessionFromContext(ctx),
+				Path:        config.WorkingDirectory(),
+				ToolName:    BashToolName,
+				Action:      "execute outside sandbox",
+				Description: fmt.Sprintf("Execute command outside the sandbox: %s", params.Command),
+				Params:      permissionParams,
+			},
+		)
+		if !p {
+			return NewTextErrorResponse("permission denied"), nil
+		}
+		sandbox = nil
+	} else if !isSafeReadOnly(commands) {
 		p := permission.Default.Request(
 			permission.CreatePermissionRequest{
+				SessionID:   GetSessionFromContext(ctx),
 				Path:        config.WorkingDirectory(),
 				ToolName:    BashToolName,
 				Action:      "execute",
 				Description: fmt.Sprintf("Execute command: %s", params.Command),
-				Params:      BashPermissionsParams(params),
+				Params:      permissionParams,
 			},
 		)
 		if !p {
 			return NewTextErrorResponse("permission denied"), nil
 		}
 	}
-	shell := shell.GetPersistentShell(config.WorkingDirectory())
-	stdout, stderr, exitCode, interrupted, err := shell.Exec(ctx, params.Command, params.Timeout)
+	sessionID := GetSessionFromContext(ctx)
+	persistentShell, err := shell.GetPersistentShell(sessionID, config.WorkingDirectory(), sandbox)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error starting shell: %s", err)), nil
+	}
+	if params.RunInBackground {
+		process, err := shell.StartBackground(sessionID, persistentShell.Cwd(), params.Command, sandbox)
+		if err != nil {
+			return NewTextErrorResponse(fmt.Sprintf("error starting background process: %s", err)), nil
+		}
+		info := process.Info()
+		return NewTextResponse(fmt.Sprintf(
+			"Started background process %s (pid %d) in %s.\nUse the %s tool with process_id %q to read its output and status, %s to send input and %s to stop it.",
+			info.ID, info.PID, info.Dir, BashOutputToolName, info.ID, BashInputToolName, BashKillToolName,
+		)), nil
+	}
+	stdout, stderr, exitCode, interrupted, err := persistentShell.Exec(ctx, params.Command, params.Timeout)
 	if err != nil {
 		return NewTextErrorResponse(fmt.Sprintf("error executing command: %s", err)), nil
 	}
 
-	stdout = truncateOutput(stdout)
-	stderr = truncateOutput(stderr)
+	stdout = spillOutput(ctx, BashToolName, stdout)
+	stderr = spillOutput(ctx, BashToolName, stderr)
 
 	errorMessage := stderr
 	if interrupted {
@@ -124,6 +188,9 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 			errorMessage += "\n"
 		}
 		errorMessage += fmt.Sprintf("Exit code %d", exitCode)
+		if hint := sandbox.BlockedHint(stdout + stderr); hint != "" {
+			errorMessage += "\n" + hint
+		}
 	}
 
 	hasBothOutputs := stdout != "" && stderr != ""
@@ -142,6 +209,30 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 	return NewTextResponse(stdout), nil
 }
 
+// shellSandbox returns the sandbox for the commands, or nil when it is not
+// enabled. Writes are allowed in the working directory, the temp directories
+// and the configured paths.
+func shellSandbox(cfg *config.Sandbox) *shell.Sandbox {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	wd := config.WorkingDirectory()
+	writable := []string{wd, os.TempDir(), "/tmp", "/var/tmp"}
+	home, _ := os.UserHomeDir()
+	for _, path := range cfg.WritablePaths {
+		if path == "~" || strings.HasPrefix(path, "~/") {
+			path = filepath.Join(home, path[1:])
+		} else if !filepath.IsAbs(path) {
+			path = filepath.Join(wd, path)
+		}
+		writable = append(writable, path)
+	}
+	return &shell.Sandbox{
+		WritablePaths:  writable,
+		DisableNetwork: cfg.DisableNetwork,
+	}
+}
+
 func truncateOutput(content string) string {
 	if len(content) <= MaxOutputLength {
 		return content
@@ -162,6 +253,52 @@ func countLines(s string) int {
 	return len(strings.Split(s, "\n"))
 }
 
+// bannedBashCommand returns the first banned program run by the commands, or
+// an empty string.
+func bannedBashCommand(commands []bashCommand) string {
+	for _, command := range commands {
+		for _, name := range command.Names {
+			for _, banned := range BannedCommands {
+				if strings.EqualFold(name, banned) {
+					return name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// stdinShellCommand returns the first shell reading its script from the
+// standard input, or an empty string. The script would escape the checks of
+// the banned commands.
+func stdinShellCommand(commands []bashCommand) string {
+	for _, command := range commands {
+		if command.StdinScript {
+			return command.Names[len(command.Names)-1]
+		}
+	}
+	return ""
+}
+
+// isSafeReadOnly reports if every command only runs safe read only programs,
+// without writing files, setting variables or running programs that are only
+// known when the command runs.
+func isSafeReadOnly(commands []bashCommand) bool {
+	for _, command := range commands {
+		if command.Dynamic || command.Writes || command.Assigns {
+			return false
+		}
+		for _, name := range command.Names {
+			if !slices.ContainsFunc(SafeReadOnlyCommands, func(safe string) bool {
+				return strings.EqualFold(name, safe)
+			}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func bashDescription() string {
 	bannedCommandsStr := strings.Join(BannedCommands, ", ")
 	return fmt.Sprintf(`Executes a given bash command in a persistent shell session with optional timeout, ensuring proper handling and security measures.
@@ -174,14 +311,14 @@ Before executing the command, please follow these steps:
 
 2. Security Check:
  - For security and to limit the threat of a prompt injection attack, some commands are limited or banned. If you use a disallowed command, you will receive an error message explaining the restriction. Explain the error to the User.
- - Verify that the command is not one of the banned commands: %s.
+ - Verify that the command is not one of the banned commands: %s. Every command is checked, including the ones in pipelines, command substitutions, wrappers like env or sudo and scripts run with "bash -c" or eval. Scripts a shell reads from its standard input, like "echo ls | sh", cannot be checked and are refused.
 
 3. Command Execution:
  - After ensuring proper quoting, execute the command.
  - Capture the output of the command.
 
 4. Output Processing:
- - If the output exceeds %d characters, output will be truncated before being returned to you.
+ - If the output exceeds %d characters, its middle will be truncated before being returned to you. The full output is stored and can be read with the %s tool.
  - Prepare the output for display to the user.
 
 5. Return Result:
@@ -191,9 +328,11 @@ Before executing the command, please follow these steps:
 Usage notes:
 - The command argument is required.
 - You can specify an optional timeout in milliseconds (up to 600000ms / 10 minutes). If not specified, commands will timeout after 30 minutes.
-- VERY IMPORTANT: You MUST avoid using search commands like 'find' and 'grep'. Instead use Grep, Glob, or Agent tools to search. You MUST avoid read tools like 'cat', 'head', 'tail', and 'ls', and use FileRead and LS tools to read files.
+- VERY IMPORTANT: You MUST avoid using search commands like 'find' and 'grep'. Instead use Grep, Glob, or Agent tools to search. You MUST avoid read tools like 'cat', 'head', 'tail', and 'ls', and use FileRead and LS tools to read files. You MUST avoid 'mv', 'cp', 'rm' and 'mkdir', and use the %s, %s, %s and %s tools, which check the paths and keep deleted files in a trash.
 - When issuing multiple commands, use the ';' or '&&' operator to separate them. DO NOT use newlines (newlines are ok in quoted strings).
-- IMPORTANT: All commands share the same shell session. Shell state (environment variables, virtual environments, current directory, etc.) persist between commands. For example, if you set an environment variable as part of a command, the environment variable will persist for subsequent commands.
+- IMPORTANT: All commands share the same shell session. Shell state (environment variables, virtual environments, current directory, etc.) persist between commands. For example, if you set an environment variable as part of a command, the environment variable will persist for subsequent commands. A shell unused for 30 minutes, or reset by the user, is replaced by a new one starting in the working directory.
+- Long running commands such as dev servers, watchers or builds that do not exit on their own MUST be started with run_in_background set to true, otherwise they block until the timeout and are killed. Background commands run in the current directory of the shell but do not see variables exported in it. Use the %s tool to read their output and status, %s to send them input and %s to stop them. They are stopped when the session or the app ends.
+- The commands may run in a sandbox configured by the user. It only allows writes in the working directory and the temp directories and can disable network access. When a command fails because of the sandbox the output says so, run it again with outside_sandbox set to true only if it really needs more access, the user has to approve every such command. Commands outside the sandbox run in a separate shell that does not share its state with the sandboxed one.
 - Try to maintain your current working directory throughout the session by using absolute paths and avoiding usage of 'cd'. You may use 'cd' if the User explicitly requests it.
 <good-example>
 pytest /foo/bar/tests
@@ -308,9 +447,11 @@ EOF
 
 Important:
 - Return an empty response - the user will see the gh output directly
-- Never update git config`, bannedCommandsStr, MaxOutputLength)
+- Never update git config`, bannedCommandsStr, MaxOutputLength, ReadOutputToolName, MoveToolName, CopyToolName, DeleteToolName, MkdirToolName, BashOutputToolName, BashInputToolName, BashKillToolName)
 }
 
-func NewBashTool() BaseTool {
-	return &bashTool{}
+func NewBashTool(sandbox *config.Sandbox) BaseTool {
+	return &bashTool{
+		sandbox: sandbox,
+	}
 }
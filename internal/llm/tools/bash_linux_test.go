@@ -0,0 +1,51 @@
+//go:build linux
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools/shell"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBashTool_CloseShellKillsCommands(t *testing.T) {
+	origPermission := permission.Default
+	defer func() {
+		permission.Default = origPermission
+	}()
+	permission.Default = newMockPermissionService(true)
+
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "shells-closed")
+	defer shell.CloseSessionShells("shells-closed")
+
+	input, err := json.Marshal(BashParams{Command: "sleep 60 >/dev/null 2>&1 & echo $!"})
+	require.NoError(t, err)
+	response, err := NewBashTool(nil).Run(ctx, ToolCall{Name: BashToolName, Input: string(input)})
+	require.NoError(t, err)
+	require.False(t, response.IsError, response.Content)
+	pid, err := strconv.Atoi(strings.TrimSpace(response.Content))
+	require.NoError(t, err)
+
+	// the process may stay a zombie without a parent reaping it
+	running := func() bool {
+		stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		return err == nil && !strings.Contains(string(stat), ") Z ")
+	}
+	require.NoError(t, syscall.Kill(pid, 0))
+
+	shell.CloseSessionShells("shells-closed")
+	assert.Eventually(t, func() bool {
+		return !running()
+	}, 5*time.Second, 50*time.Millisecond)
+}
@@ -3,19 +3,26 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools/shell"
 	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
 	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestBashTool_Info(t *testing.T) {
-	tool := NewBashTool()
+	tool := NewBashTool(nil)
 	info := tool.Info()
 
 	assert.Equal(t, BashToolName, info.Name)
@@ -42,7 +49,7 @@ func TestBashTool_Run(t *testing.T) {
 
 	t.Run("executes command successfully", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewBashTool()
+		tool := NewBashTool(nil)
 		params := BashParams{
 			Command: "echo 'Hello World'",
 		}
@@ -63,7 +70,7 @@ func TestBashTool_Run(t *testing.T) {
 	t.Run("handles invalid parameters", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
 
-		tool := NewBashTool()
+		tool := NewBashTool(nil)
 		call := ToolCall{
 			Name:  BashToolName,
 			Input: "invalid json",
@@ -77,7 +84,7 @@ func TestBashTool_Run(t *testing.T) {
 	t.Run("handles missing command", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
 
-		tool := NewBashTool()
+		tool := NewBashTool(nil)
 		params := BashParams{
 			Command: "",
 		}
@@ -98,7 +105,7 @@ func TestBashTool_Run(t *testing.T) {
 	t.Run("handles banned commands", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
 
-		tool := NewBashTool()
+		tool := NewBashTool(nil)
 
 		for _, bannedCmd := range BannedCommands {
 			params := BashParams{
@@ -122,7 +129,7 @@ func TestBashTool_Run(t *testing.T) {
 	t.Run("handles safe read-only commands without permission check", func(t *testing.T) {
 		permission.Default = newMockPermissionService(false)
 
-		tool := NewBashTool()
+		tool := NewBashTool(nil)
 
 		// Test with a safe read-only command
 		params := BashParams{
@@ -142,10 +149,55 @@ func TestBashTool_Run(t *testing.T) {
 		assert.Equal(t, "test\n", response.Content)
 	})
 
+	t.Run("asks permission for every command that will run", func(t *testing.T) {
+		recorder := &recordingPermissionService{Service: newMockPermissionService(false)}
+		permission.Default = recorder
+
+		tool := NewBashTool(nil)
+		params := BashParams{
+			Command: "ls && echo $(rm -rf test_dir)",
+		}
+
+		paramsJSON, err := json.Marshal(params)
+		require.NoError(t, err)
+
+		call := ToolCall{
+			Name:  BashToolName,
+			Input: string(paramsJSON),
+		}
+
+		response, err := tool.Run(context.Background(), call)
+		require.NoError(t, err)
+		assert.Contains(t, response.Content, "permission denied")
+		require.Len(t, recorder.requests, 1)
+		assert.Equal(t, []string{"ls", "echo $(rm -rf test_dir)", "rm -rf test_dir"}, recorder.requests[0].Params.(BashPermissionsParams).Commands)
+	})
+
+	t.Run("handles commands that cannot be parsed", func(t *testing.T) {
+		permission.Default = newMockPermissionService(true)
+
+		tool := NewBashTool(nil)
+		params := BashParams{
+			Command: "echo 'unterminated",
+		}
+
+		paramsJSON, err := json.Marshal(params)
+		require.NoError(t, err)
+
+		call := ToolCall{
+			Name:  BashToolName,
+			Input: string(paramsJSON),
+		}
+
+		response, err := tool.Run(context.Background(), call)
+		require.NoError(t, err)
+		assert.Contains(t, response.Content, "error parsing command")
+	})
+
 	t.Run("handles permission denied", func(t *testing.T) {
 		permission.Default = newMockPermissionService(false)
 
-		tool := NewBashTool()
+		tool := NewBashTool(nil)
 
 		// Test with a command that requires permission
 		params := BashParams{
@@ -167,7 +219,7 @@ func TestBashTool_Run(t *testing.T) {
 
 	t.Run("handles command timeout", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewBashTool()
+		tool := NewBashTool(nil)
 		params := BashParams{
 			Command: "sleep 2",
 			Timeout: 100, // 100ms timeout
@@ -188,7 +240,7 @@ func TestBashTool_Run(t *testing.T) {
 
 	t.Run("handles command with stderr output", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewBashTool()
+		tool := NewBashTool(nil)
 		params := BashParams{
 			Command: "echo 'error message' >&2",
 		}
@@ -208,7 +260,7 @@ func TestBashTool_Run(t *testing.T) {
 
 	t.Run("handles command with both stdout and stderr", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewBashTool()
+		tool := NewBashTool(nil)
 		params := BashParams{
 			Command: "echo 'stdout message' && echo 'stderr message' >&2",
 		}
@@ -229,7 +281,7 @@ func TestBashTool_Run(t *testing.T) {
 
 	t.Run("handles context cancellation", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewBashTool()
+		tool := NewBashTool(nil)
 		params := BashParams{
 			Command: "sleep 5",
 		}
@@ -257,7 +309,7 @@ func TestBashTool_Run(t *testing.T) {
 
 	t.Run("respects max timeout", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewBashTool()
+		tool := NewBashTool(nil)
 		params := BashParams{
 			Command: "echo 'test'",
 			Timeout: MaxTimeout + 1000, // Exceeds max timeout
@@ -278,7 +330,7 @@ func TestBashTool_Run(t *testing.T) {
 
 	t.Run("uses default timeout for zero or negative timeout", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewBashTool()
+		tool := NewBashTool(nil)
 		params := BashParams{
 			Command: "echo 'test'",
 			Timeout: -100, // Negative timeout
@@ -298,6 +350,751 @@ func TestBashTool_Run(t *testing.T) {
 	})
 }
 
+func TestBashTool_SessionShells(t *testing.T) {
+	origPermission := permission.Default
+	defer func() {
+		permission.Default = origPermission
+	}()
+	permission.Default = newMockPermissionService(true)
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o755))
+	first := context.WithValue(context.Background(), SessionIDContextKey, "shells-first")
+	second := context.WithValue(context.Background(), SessionIDContextKey, "shells-second")
+	defer shell.CloseSessionShells("shells-first")
+	defer shell.CloseSessionShells("shells-second")
+
+	run := func(t *testing.T, ctx context.Context, command string) string {
+		input, err := json.Marshal(BashParams{Command: command})
+		require.NoError(t, err)
+		response, err := NewBashTool(nil).Run(ctx, ToolCall{Name: BashToolName, Input: string(input)})
+		require.NoError(t, err)
+		require.False(t, response.IsError, response.Content)
+		return strings.TrimSpace(response.Content)
+	}
+	run(t, first, "cd "+dir)
+	run(t, second, "cd "+dir)
+
+	t.Run("keeps the state of each session", func(t *testing.T) {
+		events, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		sub := shell.SubscribeShells(events)
+
+		run(t, first, "cd sub && export SHELLS_TEST=first")
+		assert.Equal(t, filepath.Join(dir, "sub"), run(t, first, "pwd"))
+		assert.Equal(t, dir, run(t, second, "pwd"))
+		assert.Equal(t, "first", run(t, first, "echo $SHELLS_TEST"))
+		assert.Empty(t, run(t, second, "echo $SHELLS_TEST"))
+
+		event := <-sub
+		assert.Equal(t, "shells-first", event.Payload.SessionID)
+		assert.Equal(t, filepath.Join(dir, "sub"), event.Payload.Cwd)
+		shells := shell.ListShells("shells-first")
+		require.Len(t, shells, 1)
+		assert.Equal(t, filepath.Join(dir, "sub"), shells[0].Cwd)
+	})
+
+	t.Run("resets the shells of a session", func(t *testing.T) {
+		shell.CloseSessionShells("shells-first")
+		assert.Empty(t, shell.ListShells("shells-first"))
+		// new shells start in the working directory, the one of the test
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		assert.Equal(t, wd, run(t, first, "pwd"))
+		assert.Empty(t, run(t, first, "echo $SHELLS_TEST"))
+		assert.Equal(t, dir, run(t, second, "pwd"))
+	})
+}
+
+func TestBashTool_Sandbox(t *testing.T) {
+	if err := shell.SandboxSupported(); err != nil {
+		t.Skip(err)
+	}
+
+	origPermission := permission.Default
+	origWd := viper.GetString("wd")
+	defer func() {
+		permission.Default = origPermission
+		viper.Set("wd", origWd)
+	}()
+	permission.Default = newMockPermissionService(true)
+
+	wd := t.TempDir()
+	viper.Set("wd", wd)
+	// keep the login profile of the user out of the sandboxed shells, it may
+	// wait for files it cannot write
+	t.Setenv("HOME", t.TempDir())
+	// temp dirs are writable in the sandbox, use a directory outside of them
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	outside, err := os.MkdirTemp(cwd, "sandbox-outside-")
+	require.NoError(t, err)
+	defer os.RemoveAll(outside)
+
+	run := func(t *testing.T, tool BaseTool, sessionID string, params BashParams) ToolResponse {
+		paramsJSON, err := json.Marshal(params)
+		require.NoError(t, err)
+		ctx := context.WithValue(context.Background(), SessionIDContextKey, sessionID)
+		response, err := tool.Run(ctx, ToolCall{
+			Name:  BashToolName,
+			Input: string(paramsJSON),
+		})
+		require.NoError(t, err)
+		return response
+	}
+	tool := NewBashTool(&config.Sandbox{Enabled: true, WritablePaths: []string{"extra"}})
+
+	t.Run("allows writes in the working directory and temp dirs", func(t *testing.T) {
+		response := run(t, tool, "sandbox-writes", BashParams{
+			Command: "mkdir -p extra && echo a > inside.txt && echo b > extra/file.txt && echo c > /tmp/termai-sandbox-test && rm /tmp/termai-sandbox-test && cat inside.txt",
+		})
+		assert.Equal(t, "a\n", response.Content)
+		assert.FileExists(t, filepath.Join(wd, "extra", "file.txt"))
+	})
+
+	t.Run("blocks writes elsewhere with a clear message", func(t *testing.T) {
+		target := filepath.Join(outside, "blocked.txt")
+		response := run(t, tool, "sandbox-blocked", BashParams{
+			Command: "echo a > " + target,
+		})
+		assert.Contains(t, response.Content, "Permission denied")
+		assert.Contains(t, response.Content, "blocked by the sandbox")
+		assert.Contains(t, response.Content, "outside_sandbox")
+		assert.NoFileExists(t, target)
+
+		response = run(t, tool, "sandbox-blocked", BashParams{
+			Command:         "echo a > " + target,
+			RunInBackground: true,
+		})
+		require.False(t, response.IsError, response.Content)
+		require.Eventually(t, func() bool {
+			return len(shell.ListBackgroundProcesses("sandbox-blocked")) == 1 &&
+				shell.ListBackgroundProcesses("sandbox-blocked")[0].Status == shell.ProcessExited
+		}, 5*time.Second, 20*time.Millisecond)
+		shell.KillSessionProcesses("sandbox-blocked")
+		assert.NoFileExists(t, target)
+	})
+
+	t.Run("runs commands outside the sandbox with an explicit permission", func(t *testing.T) {
+		target := filepath.Join(outside, "allowed.txt")
+		recorder := &recordingPermissionService{Service: newMockPermissionService(true)}
+		permission.Default = recorder
+		defer func() { permission.Default = newMockPermissionService(true) }()
+
+		// safe read only commands need the permission too
+		response := run(t, tool, "sandbox-outside", BashParams{
+			Command:        "echo a > " + target,
+			OutsideSandbox: true,
+		})
+		assert.False(t, response.IsError, response.Content)
+		assert.FileExists(t, target)
+		require.Len(t, recorder.requests, 1)
+		assert.Equal(t, "execute outside sandbox", recorder.requests[0].Action)
+
+		permission.Default = newMockPermissionService(false)
+		response = run(t, tool, "sandbox-outside", BashParams{
+			Command:        "rm " + target,
+			OutsideSandbox: true,
+		})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "permission denied")
+		assert.FileExists(t, target)
+	})
+
+	t.Run("disables the network", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		port := listener.Addr().(*net.TCPAddr).Port
+		command := fmt.Sprintf("exec 3<>/dev/tcp/127.0.0.1/%d && echo connected", port)
+
+		response := run(t, tool, "sandbox-network-on", BashParams{Command: command})
+		assert.Equal(t, "connected\n", response.Content)
+
+		noNetwork := NewBashTool(&config.Sandbox{Enabled: true, DisableNetwork: true})
+		response = run(t, noNetwork, "sandbox-network-off", BashParams{Command: command})
+		assert.NotContains(t, response.Content, "connected")
+		assert.Contains(t, response.Content, "network access is disabled")
+	})
+}
+
+func TestSandbox_BlockedHint(t *testing.T) {
+	var disabled *shell.Sandbox
+	assert.Empty(t, disabled.BlockedHint("Permission denied"))
+
+	sandbox := &shell.Sandbox{WritablePaths: []string{"/work", "/tmp"}}
+	assert.Empty(t, sandbox.BlockedHint("no such file or directory"))
+	assert.Empty(t, sandbox.BlockedHint("Could not resolve host: example.com"))
+	assert.Equal(t,
+		"The command may have been blocked by the sandbox. Writes are only allowed in /work, /tmp. If the command needs more access, run it again with outside_sandbox set to true, the user will be asked to approve it.",
+		sandbox.BlockedHint("touch: cannot touch '/etc/x': Permission denied"),
+	)
+
+	sandbox.DisableNetwork = true
+	assert.Contains(t, sandbox.BlockedHint("Could not resolve host: example.com"), "network access is disabled")
+}
+
+func TestParseBashCommands(t *testing.T) {
+	type cmd struct {
+		source  string
+		names   []string
+		dynamic bool
+		writes  bool
+		assigns bool
+	}
+	tests := []struct {
+		name     string
+		script   string
+		expected []cmd
+	}{
+		{
+			name:     "simple command",
+			script:   "ls -la",
+			expected: []cmd{{source: "ls -la", names: []string{"ls"}}},
+		},
+		{
+			name:   "and list",
+			script: "ls && curl example.com",
+			expected: []cmd{
+				{source: "ls", names: []string{"ls"}},
+				{source: "curl example.com", names: []string{"curl"}},
+			},
+		},
+		{
+			name:   "semicolons and or list",
+			script: "echo a; pwd || date",
+			expected: []cmd{
+				{source: "echo a", names: []string{"echo"}},
+				{source: "pwd", names: []string{"pwd"}},
+				{source: "date", names: []string{"date"}},
+			},
+		},
+		{
+			name:   "pipeline",
+			script: "ls | grep foo | wc -l",
+			expected: []cmd{
+				{source: "ls", names: []string{"ls"}},
+				{source: "grep foo", names: []string{"grep"}},
+				{source: "wc -l", names: []string{"wc"}},
+			},
+		},
+		{
+			name:   "command substitution",
+			script: "echo $(wget -qO- example.com)",
+			expected: []cmd{
+				{source: "echo $(wget -qO- example.com)", names: []string{"echo"}},
+				{source: "wget -qO- example.com", names: []string{"wget"}},
+			},
+		},
+		{
+			name:   "backquotes",
+			script: "echo `whoami`",
+			expected: []cmd{
+				{source: "echo $(whoami)", names: []string{"echo"}},
+				{source: "whoami", names: []string{"whoami"}},
+			},
+		},
+		{
+			name:   "substitution in double quotes",
+			script: `echo "user: $(id -u)"`,
+			expected: []cmd{
+				{source: `echo "user: $(id -u)"`, names: []string{"echo"}},
+				{source: "id -u", names: []string{"id"}},
+			},
+		},
+		{
+			name:   "process substitution",
+			script: "diff <(ls a) <(ls b)",
+			expected: []cmd{
+				{source: "diff <(ls a) <(ls b)", names: []string{"diff"}},
+				{source: "ls a", names: []string{"ls"}},
+				{source: "ls b", names: []string{"ls"}},
+			},
+		},
+		{
+			name:   "subshell and group",
+			script: "(cd /tmp && rm -rf x); { echo a; }",
+			expected: []cmd{
+				{source: "cd /tmp", names: []string{"cd"}},
+				{source: "rm -rf x", names: []string{"rm"}},
+				{source: "echo a", names: []string{"echo"}},
+			},
+		},
+		{
+			name:   "function body",
+			script: "f() { curl example.com; }; f",
+			expected: []cmd{
+				{source: "curl example.com", names: []string{"curl"}},
+				{source: "f", names: []string{"f"}},
+			},
+		},
+		{
+			name:   "if and loop",
+			script: "if test -f x; then cat x; fi; for f in *; do echo $f; done; while true; do sleep 1; done",
+			expected: []cmd{
+				{source: "test -f x", names: []string{"test"}},
+				{source: "cat x", names: []string{"cat"}},
+				{source: "echo $f", names: []string{"echo"}},
+				{source: "true", names: []string{"true"}},
+				{source: "sleep 1", names: []string{"sleep"}},
+			},
+		},
+		{
+			name:     "background command",
+			script:   "sleep 10 &",
+			expected: []cmd{{source: "sleep 10 &", names: []string{"sleep"}}},
+		},
+		{
+			name:     "double quoted name",
+			script:   `"curl" example.com`,
+			expected: []cmd{{source: `"curl" example.com`, names: []string{"curl"}}},
+		},
+		{
+			name:     "single quoted name",
+			script:   `'cu'rl example.com`,
+			expected: []cmd{{source: `'cu'rl example.com`, names: []string{"curl"}}},
+		},
+		{
+			name:     "escaped name",
+			script:   `c\url example.com`,
+			expected: []cmd{{source: `c\url example.com`, names: []string{"curl"}}},
+		},
+		{
+			name:     "absolute path",
+			script:   "/usr/bin/curl example.com",
+			expected: []cmd{{source: "/usr/bin/curl example.com", names: []string{"curl"}}},
+		},
+		{
+			name:     "relative path",
+			script:   "./node_modules/.bin/jest",
+			expected: []cmd{{source: "./node_modules/.bin/jest", names: []string{"jest"}}},
+		},
+		{
+			name:     "variable as command",
+			script:   "$CMD example.com",
+			expected: []cmd{{source: "$CMD example.com", dynamic: true}},
+		},
+		{
+			name:   "substitution as command",
+			script: "$(echo curl) example.com",
+			expected: []cmd{
+				{source: "$(echo curl) example.com", dynamic: true},
+				{source: "echo curl", names: []string{"echo"}},
+			},
+		},
+		{
+			name:     "ansi-c quoted name",
+			script:   `$'\x63url' example.com`,
+			expected: []cmd{{source: `$'\x63url' example.com`, dynamic: true}},
+		},
+		{
+			name:     "brace expansion in name",
+			script:   "{curl,example.com}",
+			expected: []cmd{{source: "{curl,example.com}", dynamic: true}},
+		},
+		{
+			name:     "glob in name",
+			script:   "/usr/bin/cur? example.com",
+			expected: []cmd{{source: "/usr/bin/cur? example.com", dynamic: true}},
+		},
+		{
+			name:     "escaped glob in name",
+			script:   `echo\? a`,
+			expected: []cmd{{source: `echo\? a`, names: []string{"echo?"}}},
+		},
+		{
+			name:     "glob in argument",
+			script:   "ls *.go",
+			expected: []cmd{{source: "ls *.go", names: []string{"ls"}}},
+		},
+		{
+			name:     "assignment before command",
+			script:   "PATH=/tmp ls",
+			expected: []cmd{{source: "PATH=/tmp ls", names: []string{"ls"}, assigns: true}},
+		},
+		{
+			name:     "assignment only",
+			script:   "CMD=curl",
+			expected: []cmd{{source: "CMD=curl", assigns: true}},
+		},
+		{
+			name:     "export",
+			script:   "export FOO=bar",
+			expected: []cmd{{source: "export FOO=bar", names: []string{"export"}, assigns: true}},
+		},
+		{
+			name:   "local with substitution",
+			script: "f() { local x=$(wget example.com); }",
+			expected: []cmd{
+				{source: "local x=$(wget example.com)", names: []string{"local"}, assigns: true},
+				{source: "wget example.com", names: []string{"wget"}},
+			},
+		},
+		{
+			name:     "redirect to file",
+			script:   "echo hi > out.txt",
+			expected: []cmd{{source: "echo hi >out.txt", names: []string{"echo"}, writes: true}},
+		},
+		{
+			name:     "append to file",
+			script:   "echo hi >> out.txt",
+			expected: []cmd{{source: "echo hi >>out.txt", names: []string{"echo"}, writes: true}},
+		},
+		{
+			name:     "redirect all output",
+			script:   "ls &> out.txt",
+			expected: []cmd{{source: "ls &>out.txt", names: []string{"ls"}, writes: true}},
+		},
+		{
+			name:     "clobber",
+			script:   "ls >| out.txt",
+			expected: []cmd{{source: "ls >|out.txt", names: []string{"ls"}, writes: true}},
+		},
+		{
+			name:     "redirect to dynamic file",
+			script:   "ls > $OUT",
+			expected: []cmd{{source: "ls >$OUT", names: []string{"ls"}, writes: true}},
+		},
+		{
+			name:     "redirect to dev null",
+			script:   "ls > /dev/null 2>&1",
+			expected: []cmd{{source: "ls >/dev/null 2>&1", names: []string{"ls"}}},
+		},
+		{
+			name:     "close file descriptor",
+			script:   "ls 2>&-",
+			expected: []cmd{{source: "ls 2>&-", names: []string{"ls"}}},
+		},
+		{
+			name:     "duplicate to file",
+			script:   "ls >& out.txt",
+			expected: []cmd{{source: "ls >&out.txt", names: []string{"ls"}, writes: true}},
+		},
+		{
+			name:     "read from file",
+			script:   "wc -l < in.txt",
+			expected: []cmd{{source: "wc -l <in.txt", names: []string{"wc"}}},
+		},
+		{
+			name:   "redirected group",
+			script: "{ echo a; ls; } > out.txt",
+			expected: []cmd{
+				{source: "echo a", names: []string{"echo"}, writes: true},
+				{source: "ls", names: []string{"ls"}, writes: true},
+			},
+		},
+		{
+			name:   "same command with and without redirect",
+			script: "echo a > f; echo a",
+			expected: []cmd{
+				{source: "echo a >f", names: []string{"echo"}, writes: true},
+				{source: "echo a", names: []string{"echo"}},
+			},
+		},
+		{
+			name:     "heredoc",
+			script:   "cat <<EOF\nhello\nEOF",
+			expected: []cmd{{source: "cat <<EOF\nhello\nEOF", names: []string{"cat"}}},
+		},
+		{
+			name:     "env",
+			script:   "env FOO=bar curl example.com",
+			expected: []cmd{{source: "env FOO=bar curl example.com", names: []string{"env", "curl"}}},
+		},
+		{
+			name:     "env with options",
+			script:   "env -i -u HOME -C /tmp wget example.com",
+			expected: []cmd{{source: "env -i -u HOME -C /tmp wget example.com", names: []string{"env", "wget"}}},
+		},
+		{
+			name:     "env alone",
+			script:   "env",
+			expected: []cmd{{source: "env", names: []string{"env"}}},
+		},
+		{
+			name:     "env split string",
+			script:   "env -S 'curl example.com'",
+			expected: []cmd{{source: "env -S 'curl example.com'", names: []string{"env"}}, {source: "curl example.com", names: []string{"curl"}}},
+		},
+		{
+			name:     "sudo",
+			script:   "sudo -u root curl example.com",
+			expected: []cmd{{source: "sudo -u root curl example.com", names: []string{"sudo", "curl"}}},
+		},
+		{
+			name:     "nested wrappers",
+			script:   "nohup nice -n 10 timeout -s KILL 5 /usr/bin/wget example.com",
+			expected: []cmd{{source: "nohup nice -n 10 timeout -s KILL 5 /usr/bin/wget example.com", names: []string{"nohup", "nice", "timeout", "wget"}}},
+		},
+		{
+			name:     "command builtin",
+			script:   "command curl example.com",
+			expected: []cmd{{source: "command curl example.com", names: []string{"command", "curl"}}},
+		},
+		{
+			name:     "exec",
+			script:   "exec nc -l 8080",
+			expected: []cmd{{source: "exec nc -l 8080", names: []string{"exec", "nc"}}},
+		},
+		{
+			name:     "xargs",
+			script:   "xargs -n 1 curl",
+			expected: []cmd{{source: "xargs -n 1 curl", names: []string{"xargs", "curl"}}},
+		},
+		{
+			name:     "wrapper with dynamic command",
+			script:   "sudo $CMD",
+			expected: []cmd{{source: "sudo $CMD", names: []string{"sudo"}, dynamic: true}},
+		},
+		{
+			name:     "find exec",
+			script:   `find . -name '*.go' -exec rm {} \;`,
+			expected: []cmd{{source: `find . -name '*.go' -exec rm {} \;`, names: []string{"find", "rm"}}},
+		},
+		{
+			name:     "find without exec",
+			script:   "find . -name '*.go'",
+			expected: []cmd{{source: "find . -name '*.go'", names: []string{"find"}}},
+		},
+		{
+			name:   "bash -c",
+			script: "bash -c 'ls && curl example.com'",
+			expected: []cmd{
+				{source: "bash -c 'ls && curl example.com'", names: []string{"bash"}},
+				{source: "ls", names: []string{"ls"}},
+				{source: "curl example.com", names: []string{"curl"}},
+			},
+		},
+		{
+			name:   "sh -ec",
+			script: `sh -ec "wget example.com"`,
+			expected: []cmd{
+				{source: `sh -ec "wget example.com"`, names: []string{"sh"}},
+				{source: "wget example.com", names: []string{"wget"}},
+			},
+		},
+		{
+			name:   "bash -o option before -c",
+			script: "bash -o pipefail -c 'ls | wc -l'",
+			expected: []cmd{
+				{source: "bash -o pipefail -c 'ls | wc -l'", names: []string{"bash"}},
+				{source: "ls", names: []string{"ls"}},
+				{source: "wc -l", names: []string{"wc"}},
+			},
+		},
+		{
+			name:     "bash script file",
+			script:   "bash build.sh",
+			expected: []cmd{{source: "bash build.sh", names: []string{"bash"}}},
+		},
+		{
+			name:     "bash -c with dynamic script",
+			script:   `bash -c "$SCRIPT"`,
+			expected: []cmd{{source: `bash -c "$SCRIPT"`, names: []string{"bash"}, dynamic: true}},
+		},
+		{
+			name:   "nested shells",
+			script: `sudo bash -c "sh -c 'curl example.com'"`,
+			expected: []cmd{
+				{source: `sudo bash -c "sh -c 'curl example.com'"`, names: []string{"sudo", "bash"}},
+				{source: "sh -c 'curl example.com'", names: []string{"sh"}},
+				{source: "curl example.com", names: []string{"curl"}},
+			},
+		},
+		{
+			name:   "redirected bash -c",
+			script: "bash -c 'echo a' > out.txt",
+			expected: []cmd{
+				{source: "bash -c 'echo a' >out.txt", names: []string{"bash"}, writes: true},
+				{source: "echo a", names: []string{"echo"}, writes: true},
+			},
+		},
+		{
+			name:   "eval",
+			script: `eval "curl example.com"`,
+			expected: []cmd{
+				{source: `eval "curl example.com"`, names: []string{"eval"}},
+				{source: "curl example.com", names: []string{"curl"}},
+			},
+		},
+		{
+			name:     "eval with dynamic script",
+			script:   "eval $CMD",
+			expected: []cmd{{source: "eval $CMD", names: []string{"eval"}, dynamic: true}},
+		},
+		{
+			name:   "test clause",
+			script: "[[ -f x ]] && rm x",
+			expected: []cmd{
+				{source: "rm x", names: []string{"rm"}},
+			},
+		},
+		{
+			name:   "arithmetic with substitution",
+			script: "echo $(( $(wc -l < f) + 1 ))",
+			expected: []cmd{
+				{source: "echo $(($(wc -l <f) + 1))", names: []string{"echo"}},
+				{source: "wc -l <f", names: []string{"wc"}},
+			},
+		},
+		{
+			name:     "comment",
+			script:   "ls # curl example.com",
+			expected: []cmd{{source: "ls", names: []string{"ls"}}},
+		},
+		{
+			name:     "curl in an argument",
+			script:   "echo curl",
+			expected: []cmd{{source: "echo curl", names: []string{"echo"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands, err := parseBashCommands(tt.script)
+			require.NoError(t, err)
+
+			got := make([]cmd, len(commands))
+			for i, c := range commands {
+				got[i] = cmd{
+					source:  c.Source,
+					names:   c.Names,
+					dynamic: c.Dynamic,
+					writes:  c.Writes,
+					assigns: c.Assigns,
+				}
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	t.Run("syntax error", func(t *testing.T) {
+		_, err := parseBashCommands("echo 'unterminated")
+		assert.Error(t, err)
+	})
+
+	t.Run("nesting limit", func(t *testing.T) {
+		script := "ls"
+		for range maxBashParseDepth + 1 {
+			script = "bash -c " + strconv.Quote(script)
+		}
+		_, err := parseBashCommands(script)
+		assert.Error(t, err)
+	})
+}
+
+func TestBashCommandChecks(t *testing.T) {
+	tests := []struct {
+		command  string
+		banned   string
+		readOnly bool
+	}{
+		{command: "ls -la", readOnly: true},
+		{command: "echo hello", readOnly: true},
+		{command: "ls | echo", readOnly: true},
+		{command: "pwd && date; whoami", readOnly: true},
+		{command: "echo $(pwd)", readOnly: true},
+		{command: "ls > /dev/null 2>&1", readOnly: true},
+		{command: "LS -la", readOnly: true},
+		{command: "env", readOnly: true},
+		{command: "echo curl", readOnly: true},
+		{command: "ls # curl", readOnly: true},
+		{command: "which curl", readOnly: true},
+		{command: "echo hi > out.txt"},
+		{command: "echo hi >> ~/.bashrc"},
+		{command: "ls && rm -rf /"},
+		{command: "echo $(rm -rf x)"},
+		{command: "ls | xargs rm"},
+		{command: "env rm x"},
+		{command: "$CMD"},
+		{command: "echo x | $SHELL"},
+		{command: "PATH=/tmp ls"},
+		{command: "{curl,example.com}"},
+		{command: "/usr/bin/cur? example.com"},
+		{command: "export PATH=/tmp"},
+		{command: "go test ./..."},
+		{command: "curl example.com", banned: "curl"},
+		{command: "CURL example.com", banned: "CURL"},
+		{command: "ls && curl example.com", banned: "curl"},
+		{command: "ls; wget example.com", banned: "wget"},
+		{command: "ls | nc example.com 80", banned: "nc"},
+		{command: "echo $(curl example.com)", banned: "curl"},
+		{command: "echo `wget example.com`", banned: "wget"},
+		{command: "cat <(curl example.com)", banned: "curl"},
+		{command: "(cd /tmp && curl example.com)", banned: "curl"},
+		{command: "f() { curl example.com; }", banned: "curl"},
+		{command: "if true; then telnet host; fi", banned: "telnet"},
+		{command: `"curl" example.com`, banned: "curl"},
+		{command: `c\url example.com`, banned: "curl"},
+		{command: "/usr/bin/curl example.com", banned: "curl"},
+		{command: "env curl example.com", banned: "curl"},
+		{command: "env FOO=1 curl example.com", banned: "curl"},
+		{command: "sudo curl example.com", banned: "curl"},
+		{command: "nohup wget example.com &", banned: "wget"},
+		{command: "timeout 5 curl example.com", banned: "curl"},
+		{command: "xargs curl < urls.txt", banned: "curl"},
+		{command: "find . -exec curl {} \\;", banned: "curl"},
+		{command: "bash -c 'curl example.com'", banned: "curl"},
+		{command: "sh -c \"ls; wget example.com\"", banned: "wget"},
+		{command: "eval 'curl example.com'", banned: "curl"},
+		{command: "alias ll='ls -l'", banned: "alias"},
+		{command: "ls\ncurl example.com", banned: "curl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			commands, err := parseBashCommands(tt.command)
+			require.NoError(t, err)
+			assert.Equal(t, tt.banned, bannedBashCommand(commands))
+			if tt.banned == "" {
+				assert.Equal(t, tt.readOnly, isSafeReadOnly(commands))
+			}
+		})
+	}
+}
+
+func TestStdinShellCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		shell   string
+	}{
+		{command: "echo curl x | sh", shell: "sh"},
+		{command: "echo curl x | bash", shell: "bash"},
+		{command: "cat script.sh | bash -s -- arg", shell: "bash"},
+		{command: "bash -e - < script.sh", shell: "bash"},
+		{command: "bash --norc <<EOF\ncurl x\nEOF", shell: "bash"},
+		{command: "echo curl x | sudo sh", shell: "sh"},
+		{command: "echo x | /bin/zsh -o errexit", shell: "zsh"},
+		{command: "bash -c 'ls'"},
+		{command: "bash -ec 'ls'"},
+		{command: "sh script.sh"},
+		{command: "bash -- script.sh arg"},
+		{command: "echo sh | cat"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			commands, err := parseBashCommands(tt.command)
+			require.NoError(t, err)
+			assert.Equal(t, tt.shell, stdinShellCommand(commands))
+		})
+	}
+
+	t.Run("refuses the command", func(t *testing.T) {
+		origPermission := permission.Default
+		defer func() {
+			permission.Default = origPermission
+		}()
+		permission.Default = newMockPermissionService(true)
+
+		paramsJSON, err := json.Marshal(BashParams{Command: "echo 'curl example.com' | sh"})
+		require.NoError(t, err)
+		response, err := NewBashTool(nil).Run(context.Background(), ToolCall{Name: BashToolName, Input: string(paramsJSON)})
+		require.NoError(t, err)
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "reads its script from the standard input")
+	})
+}
+
 func TestTruncateOutput(t *testing.T) {
 	t.Run("does not truncate short output", func(t *testing.T) {
 		output := "short output"
@@ -380,6 +1177,17 @@ func (m *mockPermissionService) Request(opts permission.CreatePermissionRequest)
 	return m.allow
 }
 
+// recordingPermissionService records the permission requests it receives
+type recordingPermissionService struct {
+	permission.Service
+	requests []permission.CreatePermissionRequest
+}
+
+func (r *recordingPermissionService) Request(opts permission.CreatePermissionRequest) bool {
+	r.requests = append(r.requests, opts)
+	return r.Service.Request(opts)
+}
+
 func newMockPermissionService(allow bool) permission.Service {
 	return &mockPermissionService{
 		Broker: pubsub.NewBroker[permission.PermissionRequest](),
@@ -0,0 +1,406 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// bashCommand is a command found in a bash script.
+type bashCommand struct {
+	// Source is the command as written, with its redirections
+	Source string
+	// Names are the programs the command runs, a wrapper like sudo or env
+	// comes before the program it starts
+	Names []string
+	// Dynamic is set when a program is only known when the command runs,
+	// for example "$CMD" or "$(echo ls)"
+	Dynamic bool
+	// Writes is set when the output is redirected to a file
+	Writes bool
+	// Assigns is set when the command sets variables, which can change what
+	// later commands run
+	Assigns bool
+	// StdinScript is set when a shell reads its script from the standard
+	// input, for example "echo ls | sh", its commands are unknown
+	StdinScript bool
+
+	stmt *syntax.Stmt
+}
+
+// maxBashParseDepth limits the nesting of scripts passed to "bash -c" or eval
+const maxBashParseDepth = 5
+
+var (
+	fdPattern = regexp.MustCompile(`^[0-9]+-?$|^-$`)
+	// unescapedPattern matches escaped characters, they do not expand
+	unescapedPattern = regexp.MustCompile(`\\.`)
+)
+
+// parseBashCommands returns every command the script runs, in order,
+// including the ones in pipelines, subshells, command substitutions,
+// functions and the scripts given to "bash -c" or eval.
+func parseBashCommands(script string) ([]bashCommand, error) {
+	return parseBashScript(script, 0)
+}
+
+func parseBashScript(script string, depth int) ([]bashCommand, error) {
+	if depth > maxBashParseDepth {
+		return nil, fmt.Errorf("commands are nested too deeply")
+	}
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(script), "")
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		commands []bashCommand
+		// writing holds the statements redirecting their output to a file,
+		// every command inside them writes
+		writing  []*syntax.Stmt
+		parseErr error
+	)
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if parseErr != nil {
+			return false
+		}
+		stmt, ok := node.(*syntax.Stmt)
+		if !ok {
+			return true
+		}
+		if stmtWrites(stmt) {
+			writing = append(writing, stmt)
+		}
+
+		switch cmd := stmt.Cmd.(type) {
+		case *syntax.CallExpr:
+			command := bashCommand{
+				Source:  printBashNode(stmt),
+				Assigns: len(cmd.Assigns) > 0,
+				stmt:    stmt,
+			}
+			nested, err := command.resolve(cmd.Args, depth)
+			if err != nil {
+				parseErr = err
+				return false
+			}
+			// commands of nested scripts belong to the statement running them
+			for i := range nested {
+				nested[i].stmt = stmt
+			}
+			commands = append(commands, command)
+			commands = append(commands, nested...)
+		case *syntax.DeclClause:
+			commands = append(commands, bashCommand{
+				Source:  printBashNode(stmt),
+				Names:   []string{cmd.Variant.Value},
+				Assigns: true,
+				stmt:    stmt,
+			})
+		}
+		return true
+	})
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	for i := range commands {
+		for _, w := range writing {
+			if containsNode(w, commands[i].stmt) {
+				commands[i].Writes = true
+				break
+			}
+		}
+	}
+	return commands, nil
+}
+
+func containsNode(outer, inner syntax.Node) bool {
+	return inner.Pos().Offset() >= outer.Pos().Offset() && inner.End().Offset() <= outer.End().Offset()
+}
+
+// resolve fills the names of the programs run by the words of a call,
+// looking through wrappers. Commands of nested scripts are returned.
+func (c *bashCommand) resolve(args []*syntax.Word, depth int) ([]bashCommand, error) {
+	words := make([]string, len(args))
+	literal := make([]bool, len(args))
+	for i, arg := range args {
+		words[i], literal[i] = bashWordLiteral(arg)
+	}
+
+	for i := 0; i < len(words); {
+		if !literal[i] {
+			c.Dynamic = true
+			return nil, nil
+		}
+		name := filepath.Base(words[i])
+		c.Names = append(c.Names, name)
+
+		wrapper, ok := bashWrappers[name]
+		if !ok {
+			return nil, nil
+		}
+		next, script := wrapper(words[i+1:])
+		if next < 0 && script < 0 && bashShells[name] && shellReadsStdin(words[i+1:]) {
+			c.StdinScript = true
+		}
+		if script >= 0 {
+			script += i + 1
+			if !literal[script] {
+				c.Dynamic = true
+				return nil, nil
+			}
+			return parseBashScript(words[script], depth+1)
+		}
+		if next < 0 {
+			return nil, nil
+		}
+		i += 1 + next
+	}
+	return nil, nil
+}
+
+// bashWrapper returns the index in args of the command run by a wrapper, or
+// -1 if it runs none, and the index of the script it runs, or -1.
+type bashWrapper func(args []string) (next int, script int)
+
+var bashWrappers map[string]bashWrapper
+
+// bashShells are the shells running the script given with -c
+var bashShells = map[string]bool{"bash": true, "sh": true, "zsh": true, "dash": true, "ksh": true}
+
+func init() {
+	bashWrappers = map[string]bashWrapper{
+		"sudo":    optionsWrapper("ugCDhprtUT", 0),
+		"doas":    optionsWrapper("uC", 0),
+		"nice":    optionsWrapper("n", 0),
+		"nohup":   optionsWrapper("", 0),
+		"time":    optionsWrapper("fo", 0),
+		"timeout": optionsWrapper("sk", 1),
+		"stdbuf":  optionsWrapper("ioe", 0),
+		"command": optionsWrapper("", 0),
+		"builtin": optionsWrapper("", 0),
+		"exec":    optionsWrapper("a", 0),
+		"setsid":  optionsWrapper("", 0),
+		"ionice":  optionsWrapper("cnp", 0),
+		"chrt":    optionsWrapper("", 1),
+		"taskset": optionsWrapper("", 1),
+		"flock":   optionsWrapper("wEc", 1),
+		"watch":   optionsWrapper("nd", 0),
+		"xargs":   optionsWrapper("adEeIiLlnPs", 0),
+		"env":     envWrapper,
+		"find":    findWrapper,
+		"eval":    evalWrapper,
+	}
+	for shell := range bashShells {
+		bashWrappers[shell] = shellWrapper
+	}
+}
+
+// optionsWrapper skips the options of a wrapper, the letters of withValue
+// are the short options taking a value, then skips the given number of
+// positional arguments.
+func optionsWrapper(withValue string, positional int) bashWrapper {
+	return func(args []string) (int, int) {
+		i := 0
+		for i < len(args) && strings.HasPrefix(args[i], "-") && args[i] != "-" {
+			arg := args[i]
+			i++
+			if arg == "--" {
+				break
+			}
+			if !strings.HasPrefix(arg, "--") && len(arg) == 2 && strings.ContainsRune(withValue, rune(arg[1])) {
+				i++
+			}
+		}
+		i += positional
+		if i >= len(args) {
+			return -1, -1
+		}
+		return i, -1
+	}
+}
+
+func envWrapper(args []string) (int, int) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-S" || arg == "--split-string":
+			// the value is split into the command and its arguments
+			if i+1 < len(args) {
+				return -1, i + 1
+			}
+			return -1, -1
+		case arg == "-u" || arg == "-C" || arg == "--unset" || arg == "--chdir":
+			i++
+		case strings.HasPrefix(arg, "-"):
+		case strings.Contains(arg, "="):
+		default:
+			return i, -1
+		}
+	}
+	return -1, -1
+}
+
+func findWrapper(args []string) (int, int) {
+	for i, arg := range args {
+		switch arg {
+		case "-exec", "-execdir", "-ok", "-okdir":
+			if i+1 < len(args) {
+				return i + 1, -1
+			}
+		}
+	}
+	return -1, -1
+}
+
+func evalWrapper(args []string) (int, int) {
+	if len(args) == 0 {
+		return -1, -1
+	}
+	return -1, 0
+}
+
+func shellWrapper(args []string) (int, int) {
+	skip := false
+	for i, arg := range args {
+		if skip {
+			// the value of -o
+			skip = false
+			continue
+		}
+		if !strings.HasPrefix(arg, "-") {
+			// a script file, its commands are unknown
+			return -1, -1
+		}
+		if strings.HasPrefix(arg, "--") {
+			continue
+		}
+		if arg == "-o" {
+			skip = true
+			continue
+		}
+		if strings.Contains(arg, "c") && i+1 < len(args) {
+			return -1, i + 1
+		}
+	}
+	return -1, -1
+}
+
+// shellReadsStdin reports if a shell run with args reads its script from the
+// standard input, when it is given neither -c nor a script file or with -s.
+func shellReadsStdin(args []string) bool {
+	skip := false
+	for i, arg := range args {
+		if skip {
+			skip = false
+			continue
+		}
+		switch {
+		case arg == "-":
+			return true
+		case arg == "--":
+			return i+1 == len(args)
+		case !strings.HasPrefix(arg, "-"):
+			// a script file
+			return false
+		case strings.HasPrefix(arg, "--"):
+		case arg == "-o":
+			skip = true
+		case strings.Contains(arg, "s"):
+			return true
+		case strings.Contains(arg, "c"):
+			return false
+		}
+	}
+	return true
+}
+
+// bashWordLiteral returns the value of a word after quote removal, and false
+// if it depends on expansions.
+func bashWordLiteral(word *syntax.Word) (string, bool) {
+	var sb strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			if bashExpands(part.Value) {
+				return "", false
+			}
+			sb.WriteString(unescapeBash(part.Value))
+		case *syntax.SglQuoted:
+			if part.Dollar {
+				return "", false
+			}
+			sb.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", false
+				}
+				sb.WriteString(lit.Value)
+			}
+		default:
+			return "", false
+		}
+	}
+	return sb.String(), true
+}
+
+// bashExpands reports if an unquoted literal contains a glob or a brace
+// expansion, "/usr/bin/cur?" or "{curl,url}" can run curl.
+func bashExpands(s string) bool {
+	s = unescapedPattern.ReplaceAllString(s, "")
+	if strings.ContainsAny(s, "*?[") {
+		return true
+	}
+	return strings.Contains(s, "{") && (strings.Contains(s, ",") || strings.Contains(s, ".."))
+}
+
+func unescapeBash(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == '\n' {
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// stmtWrites reports if the statement redirects its output to a file.
+func stmtWrites(stmt *syntax.Stmt) bool {
+	for _, redir := range stmt.Redirs {
+		switch redir.Op {
+		case syntax.RdrOut, syntax.AppOut, syntax.RdrInOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll:
+		case syntax.DplOut:
+			// duplicating or closing a file descriptor, like 2>&1
+			if target, ok := bashWordLiteral(redir.Word); ok && fdPattern.MatchString(target) {
+				continue
+			}
+		default:
+			continue
+		}
+		if target, ok := bashWordLiteral(redir.Word); ok && target == "/dev/null" {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func printBashNode(node syntax.Node) string {
+	var buf bytes.Buffer
+	syntax.NewPrinter(syntax.SingleLine(true)).Print(&buf, node)
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+)
+
+type copyTool struct {
+	files filerecord.Service
+}
+
+const (
+	CopyToolName = "copy"
+)
+
+type CopyParams struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Overwrite   bool   `json:"overwrite"`
+}
+
+func (c *copyTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        CopyToolName,
+		Description: copyDescription(),
+		Parameters: map[string]any{
+			"source": map[string]any{
+				"type":        "string",
+				"description": "The path of the file or directory to copy",
+			},
+			"destination": map[string]any{
+				"type":        "string",
+				"description": "The path of the copy, not the directory to copy into",
+			},
+			"overwrite": map[string]any{
+				"type":        "boolean",
+				"description": "Replace an existing destination file, which is moved to the trash (default false)",
+			},
+		},
+		Required: []string{"source", "destination"},
+	}
+}
+
+// Run implements Tool.
+func (c *copyTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params CopyParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.Source == "" {
+		return NewTextErrorResponse("source is required"), nil
+	}
+	if params.Destination == "" {
+		return NewTextErrorResponse("destination is required"), nil
+	}
+
+	source, err := resolveWorkspacePath(params.Source, true)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	destination, err := resolveWorkspacePath(params.Destination, false)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if source == destination {
+		return NewTextErrorResponse("source and destination are the same path"), nil
+	}
+	if within(destination, source) {
+		return NewTextErrorResponse(fmt.Sprintf("cannot copy %s into itself", source)), nil
+	}
+
+	permissionParams, err := operationParams("copy", source, destination)
+	if os.IsNotExist(err) {
+		return NewTextErrorResponse(fmt.Sprintf("Source not found: %s", source)), nil
+	} else if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to access source: %s", err)), nil
+	}
+	if err := checkDestination(ctx, c.files, destination, params.Overwrite); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	p := permission.Default.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   GetSessionFromContext(ctx),
+			Path:        destination,
+			ToolName:    CopyToolName,
+			Action:      "copy",
+			Description: fmt.Sprintf("Copy %s %s to %s", describeFiles(permissionParams), source, destination),
+			Params:      permissionParams,
+		},
+	)
+	if !p {
+		return NewTextErrorResponse(fmt.Sprintf("Permission denied to copy %s", source)), nil
+	}
+
+	trashed, err := replaceDestination(destination)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to move the existing destination to the trash: %s", err)), nil
+	}
+	if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to create parent directories: %s", err)), nil
+	}
+	if err := copyPath(source, destination); err != nil {
+		os.RemoveAll(destination)
+		return NewTextErrorResponse(fmt.Sprintf("Failed to copy %s: %s", source, err)), nil
+	}
+	moveFileRecord(ctx, c.files, source, destination, false)
+
+	output := fmt.Sprintf("Copied %s to %s", source, destination)
+	if trashed != "" {
+		output += fmt.Sprintf("\nThe previous %s was moved to the trash: %s", destination, trashed)
+	}
+	return NewTextResponse(output), nil
+}
+
+func copyDescription() string {
+	return `File copying tool that copies a file or a directory inside the working directory.
+
+WHEN TO USE THIS TOOL:
+- Use when you need a copy of a file to start a new one from, like a configuration template
+- Helpful for duplicating a directory of fixtures or examples
+
+HOW TO USE:
+- Provide the path of the file or directory to copy as source
+- Provide the path of the copy as destination, parent directories are created if needed
+- Set overwrite to replace an existing destination file
+
+FEATURES:
+- Copies files and whole directories, keeping the file permissions
+- Symbolic links are copied as links
+- A replaced destination file is moved to the trash, so it can be restored
+
+LIMITATIONS:
+- Both paths must be inside the working directory
+- The destination is the path of the copy, a file is not copied into an existing directory with the same name
+- Cannot copy into the .git directory
+- You must read a destination file before overwriting it
+
+TIPS:
+- Use the LS tool to verify the destination before copying files
+- Use this tool instead of cp in the Bash tool, so the copy is tracked`
+}
+
+func NewCopyTool(files filerecord.Service) BaseTool {
+	return &copyTool{files: files}
+}
@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyTool_Info(t *testing.T) {
+	info := NewCopyTool(nil).Info()
+
+	assert.Equal(t, CopyToolName, info.Name)
+	assert.NotEmpty(t, info.Description)
+	assert.Contains(t, info.Parameters, "source")
+	assert.Contains(t, info.Parameters, "destination")
+	assert.Equal(t, []string{"source", "destination"}, info.Required)
+}
+
+func TestCopyTool_Run(t *testing.T) {
+	dir := setupWorkspace(t)
+	files := newMockFileRecordService()
+	tool := NewCopyTool(files)
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "session")
+
+	t.Run("copies a file and keeps the source record", func(t *testing.T) {
+		source := writeWorkspaceFile(t, dir, "config.example.json", "{}\n")
+		recordFileRead(ctx, files, source)
+
+		response := runFileTool(t, tool, ctx, CopyParams{Source: "config.example.json", Destination: "config.json"})
+		require.False(t, response.IsError, response.Content)
+		assert.Contains(t, response.Content, "Copied")
+
+		content, err := os.ReadFile(filepath.Join(dir, "config.json"))
+		require.NoError(t, err)
+		assert.Equal(t, "{}\n", string(content))
+		assert.NoError(t, checkFileFresh(ctx, files, source))
+		assert.NoError(t, checkFileFresh(ctx, files, filepath.Join(dir, "config.json")))
+	})
+
+	t.Run("copies a directory", func(t *testing.T) {
+		writeWorkspaceFile(t, dir, "fixtures/a.txt", "a")
+		writeWorkspaceFile(t, dir, "fixtures/b/c.txt", "c")
+
+		response := runFileTool(t, tool, ctx, CopyParams{Source: "fixtures", Destination: "fixtures2"})
+		require.False(t, response.IsError, response.Content)
+		assert.FileExists(t, filepath.Join(dir, "fixtures/b/c.txt"))
+		assert.FileExists(t, filepath.Join(dir, "fixtures2/b/c.txt"))
+	})
+
+	t.Run("refuses an existing destination", func(t *testing.T) {
+		response := runFileTool(t, tool, ctx, CopyParams{Source: "config.example.json", Destination: "config.json"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "already exists")
+	})
+
+	t.Run("refuses a missing source", func(t *testing.T) {
+		response := runFileTool(t, tool, ctx, CopyParams{Source: "missing", Destination: "other"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "Source not found")
+	})
+
+	t.Run("refuses to copy into the data directory", func(t *testing.T) {
+		response := runFileTool(t, tool, ctx, CopyParams{Source: "config.json", Destination: ".termai/config.json"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "data directory")
+	})
+}
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/lsp"
+)
+
+type definitionTool struct {
+	lspManager *lsp.Manager
+}
+
+const (
+	DefinitionToolName = "definition"
+)
+
+type DefinitionParams struct {
+	Symbol   string `json:"symbol"`
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Path     string `json:"path"`
+}
+
+func (d *definitionTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        DefinitionToolName,
+		Description: definitionDescription(),
+		Parameters: map[string]any{
+			"symbol": map[string]any{
+				"type":        "string",
+				"description": "The name of the symbol, methods and fields can be qualified with their type (e.g. \"Client.Call\")",
+			},
+			"file_path": map[string]any{
+				"type":        "string",
+				"description": "A file where the symbol is used, to resolve it precisely together with line",
+			},
+			"line": map[string]any{
+				"type":        "integer",
+				"description": "The line number (1-based) in file_path where the symbol is used",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "The directory to limit results to. Defaults to the current working directory.",
+			},
+		},
+		Required: []string{"symbol"},
+	}
+}
+
+// Run implements Tool.
+func (d *definitionTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params DefinitionParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.Symbol == "" {
+		return NewTextErrorResponse("symbol is required"), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, navigationTimeout)
+	defer cancel()
+
+	root := navigationRoot(params.Path)
+	var locations []codeLocation
+	if params.FilePath != "" && params.Line > 0 {
+		if !filepath.IsAbs(params.FilePath) {
+			params.FilePath = filepath.Join(config.WorkingDirectory(), params.FilePath)
+		}
+		found, err := lspPositionLookup(ctx, d.lspManager, params.FilePath, params.Line, params.Symbol,
+			func(c *lsp.Client, pos lsp.Position) ([]lsp.Location, error) {
+				return c.Definition(ctx, params.FilePath, pos)
+			})
+		if err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		locations = found
+	}
+	if len(locations) == 0 {
+		locations = lspDefinitions(ctx, d.lspManager, params.Symbol)
+	}
+	if len(locations) == 0 {
+		locations = goDefinitions(root, params.Symbol)
+	}
+
+	output := formatLocations(root, locations)
+	if output == "" {
+		return NewTextResponse(fmt.Sprintf("No definition found for %s", params.Symbol)), nil
+	}
+	return NewTextResponse(output), nil
+}
+
+func definitionDescription() string {
+	return `Finds where a symbol (function, method, type, variable, constant or field) is defined, returning file:line:column locations with the source line.
+
+WHEN TO USE THIS TOOL:
+- Use when you need to jump to the declaration of a symbol you saw in the code
+- Faster and more precise than searching with grep
+
+HOW TO USE:
+- Provide the symbol name, qualify methods and fields with their type (e.g. "Client.Call") or package (e.g. "config.Get")
+- To resolve a specific usage, also provide the file_path and line where the symbol appears
+- Optionally limit results to a directory with path
+
+FEATURES:
+- Uses the configured language servers when available
+- Falls back to parsing Go files when no language server answers
+- Results are limited to the working directory and skip hidden and common build directories
+
+LIMITATIONS:
+- The Go fallback matches declarations by name, so it can return several candidates
+- Other languages need a configured and installed language server
+- Results are limited to 100 locations
+
+TIPS:
+- Use the References tool to find where the symbol is used
+- Use the View tool with the returned line as offset to read the declaration`
+}
+
+func NewDefinitionTool(lspManager *lsp.Manager) BaseTool {
+	return &definitionTool{lspManager: lspManager}
+}
@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+)
+
+type deleteTool struct {
+	files filerecord.Service
+}
+
+const (
+	DeleteToolName = "delete"
+)
+
+type DeleteParams struct {
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive"`
+}
+
+func (d *deleteTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        DeleteToolName,
+		Description: deleteDescription(),
+		Parameters: map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "The path of the file or directory to delete",
+			},
+			"recursive": map[string]any{
+				"type":        "boolean",
+				"description": "Delete a directory that is not empty with all its content (default false)",
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+// Run implements Tool.
+func (d *deleteTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params DeleteParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.Path == "" {
+		return NewTextErrorResponse("path is required"), nil
+	}
+
+	path, err := resolveWorkspacePath(params.Path, false)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	permissionParams, err := operationParams("delete", path, "")
+	if os.IsNotExist(err) {
+		return NewTextErrorResponse(fmt.Sprintf("Path not found: %s", path)), nil
+	} else if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to access path: %s", err)), nil
+	}
+	if len(permissionParams.Files) > 0 && !params.Recursive {
+		return NewTextErrorResponse(fmt.Sprintf("%s is a directory with %d file%s, set recursive to delete it",
+			path, permissionParams.TotalFiles, pluralize(permissionParams.TotalFiles))), nil
+	}
+
+	p := permission.Default.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   GetSessionFromContext(ctx),
+			Path:        path,
+			ToolName:    DeleteToolName,
+			Action:      "delete",
+			Description: fmt.Sprintf("Delete %s %s", describeFiles(permissionParams), path),
+			Params:      permissionParams,
+		},
+	)
+	if !p {
+		return NewTextErrorResponse(fmt.Sprintf("Permission denied to delete %s", path)), nil
+	}
+
+	trashed, err := moveToTrash(path)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to delete %s: %s", path, err)), nil
+	}
+	_ = d.files.Forget(GetSessionFromContext(ctx), path)
+
+	return NewTextResponse(fmt.Sprintf("Deleted %s\nIt was moved to the trash and can be restored with the %s tool: %s", path, MoveToolName, trashed)), nil
+}
+
+func deleteDescription() string {
+	return `File deletion tool that deletes a file or a directory inside the working directory, keeping it in a trash.
+
+WHEN TO USE THIS TOOL:
+- Use when you need to remove a file that is no longer used
+- Helpful for cleaning up generated files or directories
+
+HOW TO USE:
+- Provide the path of the file or directory to delete
+- Set recursive to delete a directory that is not empty
+
+FEATURES:
+- Deleted files are moved to the trash in the data directory instead of being removed
+- The response gives the path in the trash, move it back with the Move tool to restore it
+
+LIMITATIONS:
+- The path must be inside the working directory
+- Cannot delete the working directory itself, the .git directory nor the data directory
+- Ignored files that may hold secrets cannot be deleted
+
+TIPS:
+- Use the LS tool to check what a directory holds before deleting it
+- Use this tool instead of rm in the Bash tool, so the deletion can be undone`
+}
+
+func NewDeleteTool(files filerecord.Service) BaseTool {
+	return &deleteTool{files: files}
+}
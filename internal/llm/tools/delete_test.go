@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteTool_Info(t *testing.T) {
+	info := NewDeleteTool(nil).Info()
+
+	assert.Equal(t, DeleteToolName, info.Name)
+	assert.NotEmpty(t, info.Description)
+	assert.Contains(t, info.Parameters, "path")
+	assert.Contains(t, info.Parameters, "recursive")
+	assert.Equal(t, []string{"path"}, info.Required)
+}
+
+func TestDeleteTool_Run(t *testing.T) {
+	dir := setupWorkspace(t)
+	files := newMockFileRecordService()
+	tool := NewDeleteTool(files)
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "session")
+
+	t.Run("moves a file to the trash", func(t *testing.T) {
+		path := writeWorkspaceFile(t, dir, "pkg/unused.go", "package pkg\n")
+		recordFileRead(ctx, files, path)
+
+		response := runFileTool(t, tool, ctx, DeleteParams{Path: "pkg/unused.go"})
+		require.False(t, response.IsError, response.Content)
+		assert.Contains(t, response.Content, "can be restored")
+
+		assert.NoFileExists(t, path)
+		trashed, err := filepath.Glob(filepath.Join(dir, ".termai/trash/*/pkg/unused.go"))
+		require.NoError(t, err)
+		require.Len(t, trashed, 1)
+		assert.Contains(t, response.Content, trashed[0])
+		_, err = files.Get("session", path)
+		assert.Error(t, err)
+
+		// the file can be restored with the move tool
+		response = runFileTool(t, NewMoveTool(files), ctx, MoveParams{Source: trashed[0], Destination: "pkg/unused.go"})
+		require.False(t, response.IsError, response.Content)
+		assert.FileExists(t, path)
+	})
+
+	t.Run("restores from a data directory outside the working directory", func(t *testing.T) {
+		data := t.TempDir()
+		config.Get().Data.Directory = data
+		defer func() { config.Get().Data.Directory = ".termai" }()
+		path := writeWorkspaceFile(t, dir, "pkg/old.go", "package pkg\n")
+
+		response := runFileTool(t, tool, ctx, DeleteParams{Path: "pkg/old.go"})
+		require.False(t, response.IsError, response.Content)
+		trashed, err := filepath.Glob(filepath.Join(data, "trash/*/pkg/old.go"))
+		require.NoError(t, err)
+		require.Len(t, trashed, 1)
+
+		response = runFileTool(t, NewMoveTool(files), ctx, MoveParams{Source: trashed[0], Destination: "pkg/old.go"})
+		require.False(t, response.IsError, response.Content)
+		assert.FileExists(t, path)
+
+		response = runFileTool(t, NewMoveTool(files), ctx, MoveParams{Source: filepath.Join(data, "termai.db"), Destination: "termai.db"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "data directory")
+	})
+
+	t.Run("requires recursive for a directory with files", func(t *testing.T) {
+		writeWorkspaceFile(t, dir, "build/a.o", "a")
+		writeWorkspaceFile(t, dir, "build/b.o", "b")
+
+		response := runFileTool(t, tool, ctx, DeleteParams{Path: "build"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "directory with 2 files")
+		assert.DirExists(t, filepath.Join(dir, "build"))
+	})
+
+	t.Run("describes the files in the permission request", func(t *testing.T) {
+		recorder := &recordingPermissionService{Service: newMockPermissionService(true)}
+		permission.Default = recorder
+		defer func() { permission.Default = newMockPermissionService(true) }()
+
+		response := runFileTool(t, tool, ctx, DeleteParams{Path: "build", Recursive: true})
+		require.False(t, response.IsError, response.Content)
+		assert.NoDirExists(t, filepath.Join(dir, "build"))
+
+		require.Len(t, recorder.requests, 1)
+		request := recorder.requests[0]
+		assert.Equal(t, "delete", request.Action)
+		assert.Equal(t, "Delete directory with 2 files "+filepath.Join(dir, "build"), request.Description)
+		params := request.Params.(FileOperationPermissionsParams)
+		assert.Equal(t, []string{"a.o", "b.o"}, params.Files)
+		assert.Equal(t, 2, params.TotalFiles)
+	})
+
+	t.Run("refuses protected paths", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+		for _, path := range []string{".", ".git", ".termai", "../other"} {
+			response := runFileTool(t, tool, ctx, DeleteParams{Path: path, Recursive: true})
+			assert.True(t, response.IsError, path)
+		}
+		assert.DirExists(t, filepath.Join(dir, ".git"))
+	})
+
+	t.Run("refuses a missing path", func(t *testing.T) {
+		response := runFileTool(t, tool, ctx, DeleteParams{Path: "missing"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "Path not found")
+	})
+}
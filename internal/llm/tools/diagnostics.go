@@ -0,0 +1,178 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/lsp"
+)
+
+type diagnosticsTool struct {
+	lspManager *lsp.Manager
+}
+
+const (
+	DiagnosticsToolName = "diagnostics"
+
+	// diagnosticsTimeout bounds how long a tool waits for language servers
+	diagnosticsTimeout = 5 * time.Second
+	maxDiagnostics     = 200
+)
+
+type DiagnosticsParams struct {
+	FilePath string `json:"file_path"`
+}
+
+func (d *diagnosticsTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        DiagnosticsToolName,
+		Description: diagnosticsDescription(),
+		Parameters: map[string]any{
+			"file_path": map[string]any{
+				"type":        "string",
+				"description": "The path to a file to get diagnostics for, leave empty for the whole workspace",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+// Run implements Tool.
+func (d *diagnosticsTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params DiagnosticsParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, diagnosticsTimeout)
+	defer cancel()
+
+	diagnostics := make(map[string][]lsp.Diagnostic)
+	if params.FilePath != "" {
+		if !filepath.IsAbs(params.FilePath) {
+			params.FilePath = filepath.Join(config.WorkingDirectory(), params.FilePath)
+		}
+		if !d.lspManager.HasServerFor(params.FilePath) {
+			return NewTextErrorResponse(fmt.Sprintf("no language server is configured for %s", params.FilePath)), nil
+		}
+		diagnostics[params.FilePath] = d.lspManager.FileDiagnostics(ctx, params.FilePath)
+	} else {
+		diagnostics = d.lspManager.Diagnostics(ctx)
+	}
+
+	output := formatDiagnostics(diagnostics, nil)
+	if output == "" {
+		return NewTextResponse("No diagnostics found"), nil
+	}
+	return NewTextResponse(output), nil
+}
+
+// formatDiagnostics lists diagnostics grouped by file, keeping only the given
+// severities when severities is not empty.
+func formatDiagnostics(diagnostics map[string][]lsp.Diagnostic, severities []lsp.DiagnosticSeverity) string {
+	paths := make([]string, 0, len(diagnostics))
+	for path := range diagnostics {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var sb strings.Builder
+	total, shown := 0, 0
+	for _, path := range paths {
+		diags := diagnostics[path]
+		sort.SliceStable(diags, func(i, j int) bool {
+			if diags[i].Severity != diags[j].Severity {
+				return diags[i].Severity < diags[j].Severity
+			}
+			return diags[i].Range.Start.Line < diags[j].Range.Start.Line
+		})
+
+		header := false
+		for _, diag := range diags {
+			if len(severities) > 0 && !containsSeverity(severities, diag.Severity) {
+				continue
+			}
+			total++
+			if shown >= maxDiagnostics {
+				continue
+			}
+			if !header {
+				fmt.Fprintf(&sb, "%s:\n", path)
+				header = true
+			}
+			fmt.Fprintf(&sb, "  %s\n", diag)
+			shown++
+		}
+	}
+	if total > shown {
+		fmt.Fprintf(&sb, "(%d more diagnostics not shown)\n", total-shown)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func containsSeverity(severities []lsp.DiagnosticSeverity, severity lsp.DiagnosticSeverity) bool {
+	for _, s := range severities {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// appendDiagnostics adds the errors and warnings language servers report for
+// the changed files to a successful tool response.
+func appendDiagnostics(ctx context.Context, lspManager *lsp.Manager, response ToolResponse, paths ...string) ToolResponse {
+	if response.IsError {
+		return response
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, diagnosticsTimeout)
+	defer cancel()
+
+	diagnostics := make(map[string][]lsp.Diagnostic)
+	for _, path := range paths {
+		if lspManager.HasServerFor(path) {
+			diagnostics[path] = lspManager.FileDiagnostics(ctx, path)
+		}
+	}
+
+	output := formatDiagnostics(diagnostics, []lsp.DiagnosticSeverity{lsp.SeverityError, lsp.SeverityWarning})
+	if output != "" {
+		response.Content += fmt.Sprintf("\n\n<file_diagnostics>\n%s\n</file_diagnostics>", output)
+	}
+	return response
+}
+
+func diagnosticsDescription() string {
+	return `Gets errors, warnings and hints reported by language servers (such as gopls) for a file or the whole workspace.
+
+WHEN TO USE THIS TOOL:
+- Use after making changes to check the code still compiles and has no new problems
+- Helpful to find errors across the workspace before running a build
+
+HOW TO USE:
+- Provide a file_path to check a single file, it is synced with the language server first
+- Leave file_path empty to list the diagnostics of every file the language servers know about
+
+FEATURES:
+- Diagnostics are grouped by file and sorted by severity and line
+- Line and column numbers start at 1
+
+LIMITATIONS:
+- Only works for file types with a configured language server that is installed
+- Some language servers only report diagnostics for files that have been opened
+- Diagnostics can take a few seconds to arrive after a change
+
+TIPS:
+- The Edit and Write tools already include the errors and warnings of the changed file in their response`
+}
+
+func NewDiagnosticsTool(lspManager *lsp.Manager) BaseTool {
+	return &diagnosticsTool{lspManager: lspManager}
+}
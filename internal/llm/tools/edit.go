@@ -3,18 +3,23 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
+	"github.com/imnulhaqueruman/opencode-poc/internal/lsp"
 	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
-type editTool struct{}
+type editTool struct {
+	lspManager *lsp.Manager
+	files      filerecord.Service
+}
 
 const (
 	EditToolName = "edit"
@@ -70,31 +75,34 @@ func (e *editTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		wd := config.WorkingDirectory()
 		params.FilePath = filepath.Join(wd, params.FilePath)
 	}
+	if err := checkFileAccess(params.FilePath); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
 
 	if params.OldString == "" {
-		result, err := createNewFile(params.FilePath, params.NewString)
+		result, err := e.createNewFile(ctx, params.FilePath, params.NewString)
 		if err != nil {
 			return NewTextErrorResponse(fmt.Sprintf("error creating file: %s", err)), nil
 		}
-		return NewTextErrorResponse(result), nil
+		return appendDiagnostics(ctx, e.lspManager, NewTextResponse(result), params.FilePath), nil
 	}
 
 	if params.NewString == "" {
-		result, err := deleteContent(params.FilePath, params.OldString)
+		result, err := e.deleteContent(ctx, params.FilePath, params.OldString)
 		if err != nil {
 			return NewTextErrorResponse(fmt.Sprintf("error deleting content: %s", err)), nil
 		}
-		return NewTextErrorResponse(result), nil
+		return appendDiagnostics(ctx, e.lspManager, NewTextResponse(result), params.FilePath), nil
 	}
 
-	result, err := replaceContent(params.FilePath, params.OldString, params.NewString)
+	result, err := e.replaceContent(ctx, params.FilePath, params.OldString, params.NewString)
 	if err != nil {
 		return NewTextErrorResponse(fmt.Sprintf("error replacing content: %s", err)), nil
 	}
-	return NewTextResponse(result), nil
+	return appendDiagnostics(ctx, e.lspManager, NewTextResponse(result), params.FilePath), nil
 }
 
-func createNewFile(filePath, content string) (string, error) {
+func (e *editTool) createNewFile(ctx context.Context, filePath, content string) (string, error) {
 	fileInfo, err := os.Stat(filePath)
 	if err == nil {
 		if fileInfo.IsDir() {
@@ -112,6 +120,7 @@ func createNewFile(filePath, content string) (string, error) {
 
 	p := permission.Default.Request(
 		permission.CreatePermissionRequest{
+			SessionID:   GetSessionFromContext(ctx),
 			Path:        filepath.Dir(filePath),
 			ToolName:    EditToolName,
 			Action:      "create",
@@ -128,18 +137,17 @@ func createNewFile(filePath, content string) (string, error) {
 		return "", fmt.Errorf("permission denied")
 	}
 
-	err = os.WriteFile(filePath, []byte(content), 0o644)
+	written, err := writeFileText(filePath, content, textFormat{mode: defaultFileMode})
 	if err != nil {
 		return "", fmt.Errorf("failed to write file: %w", err)
 	}
 
-	recordFileWrite(filePath)
-	recordFileRead(filePath)
+	recordFileWrite(ctx, e.files, filePath, written)
 
 	return "File created: " + filePath, nil
 }
 
-func deleteContent(filePath, oldString string) (string, error) {
+func (e *editTool) deleteContent(ctx context.Context, filePath, oldString string) (string, error) {
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -152,38 +160,25 @@ func deleteContent(filePath, oldString string) (string, error) {
 		return "", fmt.Errorf("path is a directory, not a file: %s", filePath)
 	}
 
-	if getLastReadTime(filePath).IsZero() {
-		return "", fmt.Errorf("you must read the file before editing it. Use the View tool first")
-	}
-
-	modTime := fileInfo.ModTime()
-	lastRead := getLastReadTime(filePath)
-	if modTime.After(lastRead) {
-		return "", fmt.Errorf("file %s has been modified since it was last read (mod time: %s, last read: %s)",
-			filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339))
+	if err := checkFileFresh(ctx, e.files, filePath); err != nil {
+		return "", err
 	}
 
-	content, err := os.ReadFile(filePath)
+	oldContent, format, err := readFileText(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
 
-	oldContent := string(content)
-
-	index := strings.Index(oldContent, oldString)
-	if index == -1 {
-		return "", fmt.Errorf("old_string not found in file. Make sure it matches exactly, including whitespace and line breaks")
-	}
-
-	lastIndex := strings.LastIndex(oldContent, oldString)
-	if index != lastIndex {
-		return "", fmt.Errorf("old_string appears multiple times in the file. Please provide more context to ensure a unique match")
+	match, err := findEditMatch(oldContent, oldString, "")
+	if err != nil {
+		return "", err
 	}
 
-	newContent := oldContent[:index] + oldContent[index+len(oldString):]
+	newContent := oldContent[:match.start] + oldContent[match.end:]
 
 	p := permission.Default.Request(
 		permission.CreatePermissionRequest{
+			SessionID:   GetSessionFromContext(ctx),
 			Path:        filepath.Dir(filePath),
 			ToolName:    EditToolName,
 			Action:      "delete",
@@ -200,18 +195,17 @@ func deleteContent(filePath, oldString string) (string, error) {
 		return "", fmt.Errorf("permission denied")
 	}
 
-	err = os.WriteFile(filePath, []byte(newContent), 0o644)
+	written, err := writeFileText(filePath, newContent, format)
 	if err != nil {
 		return "", fmt.Errorf("failed to write file: %w", err)
 	}
 
-	recordFileWrite(filePath)
-	recordFileRead(filePath)
+	recordFileWrite(ctx, e.files, filePath, written)
 
 	return "Content deleted from file: " + filePath, nil
 }
 
-func replaceContent(filePath, oldString, newString string) (string, error) {
+func (e *editTool) replaceContent(ctx context.Context, filePath, oldString, newString string) (string, error) {
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -224,39 +218,26 @@ func replaceContent(filePath, oldString, newString string) (string, error) {
 		return "", fmt.Errorf("path is a directory, not a file: %s", filePath)
 	}
 
-	if getLastReadTime(filePath).IsZero() {
-		return "", fmt.Errorf("you must read the file before editing it. Use the View tool first")
+	if err := checkFileFresh(ctx, e.files, filePath); err != nil {
+		return "", err
 	}
 
-	modTime := fileInfo.ModTime()
-	lastRead := getLastReadTime(filePath)
-	if modTime.After(lastRead) {
-		return "", fmt.Errorf("file %s has been modified since it was last read (mod time: %s, last read: %s)",
-			filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339))
-	}
-
-	content, err := os.ReadFile(filePath)
+	oldContent, format, err := readFileText(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
 
-	oldContent := string(content)
-
-	index := strings.Index(oldContent, oldString)
-	if index == -1 {
-		return "", fmt.Errorf("old_string not found in file. Make sure it matches exactly, including whitespace and line breaks")
-	}
-
-	lastIndex := strings.LastIndex(oldContent, oldString)
-	if index != lastIndex {
-		return "", fmt.Errorf("old_string appears multiple times in the file. Please provide more context to ensure a unique match")
+	match, err := findEditMatch(oldContent, oldString, newString)
+	if err != nil {
+		return "", err
 	}
 
-	newContent := oldContent[:index] + newString + oldContent[index+len(oldString):]
-	diff := GenerateDiff(oldString, newContent)
+	newContent := oldContent[:match.start] + match.replacement + oldContent[match.end:]
+	diff := GenerateDiff(oldContent, newContent)
 
 	p := permission.Default.Request(
 		permission.CreatePermissionRequest{
+			SessionID:   GetSessionFromContext(ctx),
 			Path:        filepath.Dir(filePath),
 			ToolName:    EditToolName,
 			Action:      "replace",
@@ -273,17 +254,282 @@ func replaceContent(filePath, oldString, newString string) (string, error) {
 		return "", fmt.Errorf("permission denied")
 	}
 
-	err = os.WriteFile(filePath, []byte(newContent), 0o644)
+	written, err := writeFileText(filePath, newContent, format)
 	if err != nil {
 		return "", fmt.Errorf("failed to write file: %w", err)
 	}
 
-	recordFileWrite(filePath)
-	recordFileRead(filePath)
+	recordFileWrite(ctx, e.files, filePath, written)
 
 	return "Content replaced in file: " + filePath, nil
 }
 
+// editMatch is the region of a file that old_string was matched against, and
+// the text to put in its place.
+type editMatch struct {
+	start       int
+	end         int
+	replacement string
+}
+
+type editMatcher struct {
+	// ignoring describes what the matcher tolerates, for error messages
+	ignoring string
+	find     func(content, oldString, newString string) []editMatch
+}
+
+// editMatchers are tried in order when old_string has no exact match. Each one
+// is more tolerant than the previous, and a match is only used when it is unique.
+var editMatchers = []editMatcher{
+	{
+		ignoring: "trailing whitespace",
+		find: func(content, oldString, newString string) []editMatch {
+			return findLineMatches(content, oldString, newString, false)
+		},
+	},
+	{
+		ignoring: "indentation",
+		find: func(content, oldString, newString string) []editMatch {
+			return findLineMatches(content, oldString, newString, true)
+		},
+	},
+	{
+		ignoring: "line endings",
+		find:     findCRLFMatches,
+	},
+}
+
+// findEditMatch locates oldString in content. An exact match is preferred,
+// otherwise the whitespace tolerant matchers are tried. When nothing matches,
+// the error describes the closest region of the file.
+func findEditMatch(content, oldString, newString string) (editMatch, error) {
+	switch strings.Count(content, oldString) {
+	case 0:
+	case 1:
+		index := strings.Index(content, oldString)
+		return editMatch{start: index, end: index + len(oldString), replacement: newString}, nil
+	default:
+		return editMatch{}, fmt.Errorf("old_string appears multiple times in the file. Please provide more context to ensure a unique match")
+	}
+
+	for _, matcher := range editMatchers {
+		matches := matcher.find(content, oldString, newString)
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return matches[0], nil
+		default:
+			return editMatch{}, fmt.Errorf("old_string matches %d locations in the file when ignoring %s. Please provide more context to ensure a unique match", len(matches), matcher.ignoring)
+		}
+	}
+
+	return editMatch{}, notFoundError(content, oldString)
+}
+
+type contentLine struct {
+	text  string // without the line terminator
+	start int
+	end   int // offset of the line terminator
+}
+
+func splitContentLines(content string) []contentLine {
+	var lines []contentLine
+	start := 0
+	for start <= len(content) {
+		end := strings.IndexByte(content[start:], '\n')
+		if end == -1 {
+			end = len(content)
+		} else {
+			end += start
+		}
+		text := content[start:end]
+		lineEnd := end
+		if strings.HasSuffix(text, "\r") {
+			text = text[:len(text)-1]
+			lineEnd--
+		}
+		lines = append(lines, contentLine{text: text, start: start, end: lineEnd})
+		start = end + 1
+	}
+	return lines
+}
+
+func splitTargetLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// commonIndent returns the leading whitespace shared by all non blank lines.
+func commonIndent(lines []string) string {
+	indent, found := "", false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lineIndent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !found {
+			indent, found = lineIndent, true
+			continue
+		}
+		for !strings.HasPrefix(lineIndent, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+	return indent
+}
+
+func linesEqual(fileLines, targetLines []string, ignoreIndent bool) bool {
+	fileIndent, targetIndent := "", ""
+	if ignoreIndent {
+		fileIndent, targetIndent = commonIndent(fileLines), commonIndent(targetLines)
+	}
+	for i := range targetLines {
+		a := strings.TrimRight(fileLines[i], " \t")
+		b := strings.TrimRight(targetLines[i], " \t\r")
+		if a == "" && b == "" {
+			continue
+		}
+		if !strings.HasPrefix(a, fileIndent) || !strings.HasPrefix(b, targetIndent) ||
+			a[len(fileIndent):] != b[len(targetIndent):] {
+			return false
+		}
+	}
+	return true
+}
+
+// findLineMatches compares whole lines, ignoring trailing whitespace and line
+// endings, and optionally a difference in indentation shared by every line.
+// The replacement is adapted to the indentation and line endings of the file.
+func findLineMatches(content, oldString, newString string, ignoreIndent bool) []editMatch {
+	lines := splitContentLines(content)
+	target := splitTargetLines(oldString)
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.text
+	}
+
+	var matches []editMatch
+	for i := 0; i+len(target) <= len(lines); i++ {
+		window := texts[i : i+len(target)]
+		if !linesEqual(window, target, ignoreIndent) {
+			continue
+		}
+
+		last := i + len(target) - 1
+		match := editMatch{start: lines[i].start, end: lines[last].end}
+		if strings.HasSuffix(oldString, "\n") {
+			match.end = len(content)
+			if last+1 < len(lines) {
+				match.end = lines[last+1].start
+			}
+		}
+
+		replacement := strings.ReplaceAll(newString, "\r\n", "\n")
+		if ignoreIndent {
+			replacement = reindent(replacement, commonIndent(target), commonIndent(window))
+		}
+		if strings.Contains(content[lines[i].start:match.end], "\r\n") {
+			replacement = strings.ReplaceAll(replacement, "\n", "\r\n")
+		}
+		match.replacement = replacement
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+func reindent(s, from, to string) string {
+	if from == to {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" && strings.HasPrefix(line, from) {
+			lines[i] = to + line[len(from):]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// findCRLFMatches matches with all line endings normalized to "\n", which
+// also covers old_string values that start or end in the middle of a line.
+func findCRLFMatches(content, oldString, newString string) []editMatch {
+	if !strings.Contains(content, "\r\n") && !strings.Contains(oldString, "\r\n") {
+		return nil
+	}
+
+	// offsets maps each byte of the normalized content back to the original
+	var normalized strings.Builder
+	offsets := make([]int, 0, len(content)+1)
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\r' && i+1 < len(content) && content[i+1] == '\n' {
+			continue
+		}
+		normalized.WriteByte(content[i])
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(content))
+
+	haystack := normalized.String()
+	needle := strings.ReplaceAll(oldString, "\r\n", "\n")
+	if needle == "" {
+		return nil
+	}
+	replacement := strings.ReplaceAll(newString, "\r\n", "\n")
+	if strings.Contains(content, "\r\n") {
+		replacement = strings.ReplaceAll(replacement, "\n", "\r\n")
+	}
+
+	var matches []editMatch
+	for from := 0; ; {
+		index := strings.Index(haystack[from:], needle)
+		if index == -1 {
+			break
+		}
+		index += from
+		end := index + len(needle)
+		matches = append(matches, editMatch{
+			start:       offsets[index],
+			end:         offsets[end-1] + 1,
+			replacement: replacement,
+		})
+		from = index + 1
+	}
+	return matches
+}
+
+// notFoundError reports that oldString was not found, together with the
+// region of the file that shares the most lines with it.
+func notFoundError(content, oldString string) error {
+	const msg = "old_string not found in file. Make sure it matches exactly, including whitespace and line breaks"
+
+	lines := splitContentLines(content)
+	target := splitTargetLines(oldString)
+	size := min(len(target), len(lines))
+
+	bestStart, bestScore := 0, 0
+	for i := 0; i+size <= len(lines); i++ {
+		score := 0
+		for j := 0; j < size; j++ {
+			a, b := strings.TrimSpace(lines[i+j].text), strings.TrimSpace(target[j])
+			if a != "" && a == b {
+				score++
+			}
+		}
+		if score > bestScore {
+			bestStart, bestScore = i, score
+		}
+	}
+	if bestScore == 0 {
+		return errors.New(msg)
+	}
+
+	bestEnd := bestStart + size - 1
+	candidate := content[lines[bestStart].start:lines[bestEnd].end]
+	return fmt.Errorf("%s.\nThe closest match is at lines %d-%d, lines starting with - are in the file and lines starting with + are in old_string:\n%s",
+		msg, bestStart+1, bestEnd+1, GenerateDiff(strings.Join(splitTargetLines(candidate), "\n")+"\n", strings.Join(target, "\n")+"\n"))
+}
+
 func GenerateDiff(oldContent, newContent string) string {
 	dmp := diffmatchpatch.New()
 	fileAdmp, fileBdmp, dmpStrings := dmp.DiffLinesToChars(oldContent, newContent)
@@ -336,6 +582,7 @@ Special cases:
 - To delete content: provide file_path and old_string, leave new_string empty
 
 The tool will replace ONE occurrence of old_string with new_string in the specified file.
+The file keeps its permissions, line endings (LF or CRLF), final newline and UTF-8 byte order mark, and is written atomically.
 
 CRITICAL REQUIREMENTS FOR USING THIS TOOL:
 
@@ -355,7 +602,7 @@ CRITICAL REQUIREMENTS FOR USING THIS TOOL:
 
 WARNING: If you do not follow these requirements:
    - The tool will fail if old_string matches multiple locations
-   - The tool will fail if old_string doesn't match exactly (including whitespace)
+   - The tool will fail if old_string doesn't match (differences only in trailing whitespace, indentation or line endings are tolerated when the match is still unique)
    - You may change the wrong instance if you don't include enough context
 
 When making edits:
@@ -366,6 +613,6 @@ When making edits:
 Remember: when making multiple file edits in a row to the same file, you should prefer to send all edits in a single message with multiple calls to this tool, rather than multiple messages with a single call each.`
 }
 
-func NewEditTool() BaseTool {
-	return &editTool{}
+func NewEditTool(lspManager *lsp.Manager, files filerecord.Service) BaseTool {
+	return &editTool{lspManager: lspManager, files: files}
 }
\ No newline at end of file
@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditTool_Info(t *testing.T) {
+	tool := NewEditTool(nil, nil)
+	info := tool.Info()
+
+	assert.Equal(t, EditToolName, info.Name)
+	assert.NotEmpty(t, info.Description)
+	assert.Contains(t, info.Parameters, "file_path")
+	assert.Contains(t, info.Parameters, "old_string")
+	assert.Contains(t, info.Parameters, "new_string")
+	assert.Contains(t, info.Required, "file_path")
+}
+
+func TestFindEditMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		oldString string
+		newString string
+		expected  string
+		errorText string
+	}{
+		{
+			name:      "exact match",
+			content:   "a\nb\nc\n",
+			oldString: "b",
+			newString: "B",
+			expected:  "a\nB\nc\n",
+		},
+		{
+			name:      "exact match that is ambiguous",
+			content:   "x\nx\n",
+			oldString: "x",
+			newString: "y",
+			errorText: "appears multiple times",
+		},
+		{
+			name:      "trailing whitespace in the file",
+			content:   "func f() {  \n\treturn 1\t\n}\n",
+			oldString: "func f() {\n\treturn 1\n}",
+			newString: "func f() {\n\treturn 2\n}",
+			expected:  "func f() {\n\treturn 2\n}\n",
+		},
+		{
+			name:      "trailing whitespace in old_string",
+			content:   "one\ntwo\nthree\n",
+			oldString: "two   \n",
+			newString: "2\n",
+			expected:  "one\n2\nthree\n",
+		},
+		{
+			name:      "different indentation",
+			content:   "func f() {\n\t\tif x {\n\t\t\ty()\n\t\t}\n}\n",
+			oldString: "if x {\n\ty()\n}",
+			newString: "if x {\n\tz()\n}",
+			expected:  "func f() {\n\t\tif x {\n\t\t\tz()\n\t\t}\n}\n",
+		},
+		{
+			name:      "indentation match that is ambiguous",
+			content:   "\tcall()\n\t\tcall()\n",
+			oldString: "    call()",
+			newString: "other()",
+			errorText: "matches 2 locations in the file when ignoring indentation",
+		},
+		{
+			name:      "file with CRLF line endings",
+			content:   "a\r\nb\r\nc\r\n",
+			oldString: "a\nb",
+			newString: "a\nB\nB2",
+			expected:  "a\r\nB\r\nB2\r\nc\r\n",
+		},
+		{
+			name:      "partial lines with CRLF line endings",
+			content:   "foo bar\r\nbaz qux\r\n",
+			oldString: "bar\nbaz",
+			newString: "BAR\nBAZ",
+			expected:  "foo BAR\r\nBAZ qux\r\n",
+		},
+		{
+			name:      "no match reports the closest region",
+			content:   "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n",
+			oldString: "func main() {\n\tprintln(\"hello\")\n}",
+			newString: "x",
+			errorText: "closest match is at lines 3-5",
+		},
+		{
+			name:      "no match without any similar region",
+			content:   "a\nb\n",
+			oldString: "zzz",
+			newString: "x",
+			errorText: "old_string not found in file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, err := findEditMatch(tt.content, tt.oldString, tt.newString)
+			if tt.errorText != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorText)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tt.content[:match.start]+match.replacement+tt.content[match.end:])
+		})
+	}
+}
+
+func TestEditTool_Run(t *testing.T) {
+	origPermission := permission.Default
+	defer func() {
+		permission.Default = origPermission
+	}()
+	permission.Default = newMockPermissionService(true)
+
+	tempDir := t.TempDir()
+	files := newMockFileRecordService()
+
+	runEdit := func(t *testing.T, params EditParams) ToolResponse {
+		paramsJSON, err := json.Marshal(params)
+		require.NoError(t, err)
+
+		response, err := NewEditTool(nil, files).Run(context.Background(), ToolCall{
+			Name:  EditToolName,
+			Input: string(paramsJSON),
+		})
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("replaces content despite whitespace differences", func(t *testing.T) {
+		filePath := filepath.Join(tempDir, "indent.go")
+		require.NoError(t, os.WriteFile(filePath, []byte("func f() {\n\t\treturn 1\n}\n"), 0o644))
+		recordFileRead(context.Background(), files, filePath)
+
+		response := runEdit(t, EditParams{
+			FilePath:  filePath,
+			OldString: "    return 1",
+			NewString: "    return 2",
+		})
+		assert.False(t, response.IsError, response.Content)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "func f() {\n\t\treturn 2\n}\n", string(content))
+	})
+
+	t.Run("shows the closest candidate when nothing matches", func(t *testing.T) {
+		original := "first line\nsecond line\nthird line\n"
+		filePath := filepath.Join(tempDir, "candidate.txt")
+		require.NoError(t, os.WriteFile(filePath, []byte(original), 0o644))
+		recordFileRead(context.Background(), files, filePath)
+
+		response := runEdit(t, EditParams{
+			FilePath:  filePath,
+			OldString: "second line\nthird lines",
+			NewString: "replacement",
+		})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "closest match is at lines 2-3")
+		assert.Contains(t, response.Content, "- third line")
+		assert.Contains(t, response.Content, "+ third lines")
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content))
+	})
+
+	t.Run("keeps CRLF line endings, the byte order mark and the mode", func(t *testing.T) {
+		filePath := filepath.Join(tempDir, "windows.sh")
+		require.NoError(t, os.WriteFile(filePath, []byte("\xEF\xBB\xBFecho one\r\necho two\r\n"), 0o755))
+		recordFileRead(context.Background(), files, filePath)
+
+		response := runEdit(t, EditParams{
+			FilePath:  filePath,
+			OldString: "echo one\necho two",
+			NewString: "echo one\necho 2",
+		})
+		require.False(t, response.IsError, response.Content)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "\xEF\xBB\xBFecho one\r\necho 2\r\n", string(content))
+		info, err := os.Stat(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+		// the recorded content is the one on disk, the file can be edited again
+		assert.NoError(t, checkFileFresh(context.Background(), files, filePath))
+	})
+
+	t.Run("keeps a missing final newline", func(t *testing.T) {
+		filePath := filepath.Join(tempDir, "no-newline.txt")
+		require.NoError(t, os.WriteFile(filePath, []byte("a\nb"), 0o644))
+		recordFileRead(context.Background(), files, filePath)
+
+		response := runEdit(t, EditParams{
+			FilePath:  filePath,
+			OldString: "b",
+			NewString: "c\n",
+		})
+		require.False(t, response.IsError, response.Content)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "a\nc", string(content))
+	})
+}
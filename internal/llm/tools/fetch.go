@@ -0,0 +1,303 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+)
+
+type fetchTool struct {
+	client *http.Client
+}
+
+const (
+	FetchToolName = "fetch"
+
+	DefaultFetchTimeout = 30  // 30 seconds
+	MaxFetchTimeout     = 120 // 2 minutes
+	MaxFetchSize        = 5 * 1024 * 1024
+
+	fetchFormatMarkdown = "markdown"
+	fetchFormatRaw      = "raw"
+)
+
+type FetchParams struct {
+	URL     string `json:"url"`
+	Format  string `json:"format"`
+	Timeout int    `json:"timeout"`
+}
+
+type FetchPermissionsParams struct {
+	URL     string `json:"url"`
+	Domain  string `json:"domain"`
+	Format  string `json:"format"`
+	Timeout int    `json:"timeout"`
+}
+
+func (f *fetchTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        FetchToolName,
+		Description: fetchDescription(),
+		Parameters: map[string]any{
+			"url": map[string]any{
+				"type":        "string",
+				"description": "The http or https URL to fetch",
+			},
+			"format": map[string]any{
+				"type":        "string",
+				"description": "How to return HTML pages: \"markdown\" (default) converts them, \"raw\" returns the HTML",
+				"enum":        []string{fetchFormatMarkdown, fetchFormatRaw},
+			},
+			"timeout": map[string]any{
+				"type":        "number",
+				"description": "Optional timeout in seconds (max 120)",
+			},
+		},
+		Required: []string{"url"},
+	}
+}
+
+// Run implements Tool.
+func (f *fetchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params FetchParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.URL == "" {
+		return NewTextErrorResponse("url is required"), nil
+	}
+	if params.Format == "" {
+		params.Format = fetchFormatMarkdown
+	}
+	if params.Format != fetchFormatMarkdown && params.Format != fetchFormatRaw {
+		return NewTextErrorResponse(fmt.Sprintf("invalid format %q, must be %q or %q", params.Format, fetchFormatMarkdown, fetchFormatRaw)), nil
+	}
+	if params.Timeout <= 0 {
+		params.Timeout = DefaultFetchTimeout
+	} else if params.Timeout > MaxFetchTimeout {
+		params.Timeout = MaxFetchTimeout
+	}
+
+	u, err := url.Parse(params.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return NewTextErrorResponse("url must be a valid http or https URL"), nil
+	}
+	domain := u.Hostname()
+	if err := checkFetchDomain(domain, config.Get().Fetch); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	if !requestFetchPermission(ctx, params.URL, domain, params) {
+		return NewTextErrorResponse("permission denied"), nil
+	}
+
+	// redirects to other domains are asked for with the same parameters
+	ctx = context.WithValue(ctx, fetchParamsContextKey{}, params)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(params.Timeout)*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params.URL, nil)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("failed to create request: %s", err)), nil
+	}
+	req.Header.Set("User-Agent", "termai/1.0")
+	req.Header.Set("Accept", "text/html, text/markdown, text/plain, application/json;q=0.9, */*;q=0.8")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return NewTextErrorResponse(fmt.Sprintf("request timed out after %d seconds", params.Timeout)), nil
+		}
+		return NewTextErrorResponse(fmt.Sprintf("failed to fetch url: %s", err)), nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return NewTextErrorResponse(fmt.Sprintf("request failed with status %s", resp.Status)), nil
+	}
+	if resp.ContentLength > MaxFetchSize {
+		return NewTextErrorResponse(fmt.Sprintf("response is too large (%d bytes, max %d bytes)", resp.ContentLength, MaxFetchSize)), nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxFetchSize+1))
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return NewTextErrorResponse(fmt.Sprintf("request timed out after %d seconds", params.Timeout)), nil
+		}
+		return NewTextErrorResponse(fmt.Sprintf("failed to read response: %s", err)), nil
+	}
+	if len(body) > MaxFetchSize {
+		return NewTextErrorResponse(fmt.Sprintf("response is too large (more than %d bytes)", MaxFetchSize)), nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" {
+		mediaType = http.DetectContentType(body)
+		mediaType, _, _ = mime.ParseMediaType(mediaType)
+	}
+	if !isTextMediaType(mediaType) {
+		return NewTextErrorResponse(fmt.Sprintf("unsupported content type %s, only text, HTML and JSON can be fetched", mediaType)), nil
+	}
+
+	content := string(body)
+	if params.Format == fetchFormatMarkdown && (mediaType == "text/html" || mediaType == "application/xhtml+xml") {
+		converter := md.NewConverter(u.Host, true, nil)
+		converter.Remove("script", "style", "noscript", "iframe", "svg")
+		markdown, err := converter.ConvertString(content)
+		if err != nil {
+			return NewTextErrorResponse(fmt.Sprintf("failed to convert HTML to markdown: %s", err)), nil
+		}
+		content = markdown
+	}
+
+	if strings.TrimSpace(content) == "" {
+		return NewTextResponse("The page has no content"), nil
+	}
+	return NewTextResponse(spillOutput(ctx, FetchToolName, content)), nil
+}
+
+func isTextMediaType(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		return true
+	case mediaType == "application/xml", strings.HasSuffix(mediaType, "+xml"):
+		return true
+	case mediaType == "application/javascript", mediaType == "application/x-yaml", mediaType == "application/yaml":
+		return true
+	}
+	return false
+}
+
+// domainMatches reports if host is domain or one of its subdomains. A leading
+// "*." in domain is accepted and means the same.
+func domainMatches(host, domain string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "*."))
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func checkFetchDomain(host string, cfg *config.Fetch) error {
+	if cfg == nil {
+		return nil
+	}
+	for _, denied := range cfg.DeniedDomains {
+		if domainMatches(host, denied) {
+			return fmt.Errorf("fetching from %s is denied by the configuration", host)
+		}
+	}
+	if len(cfg.AllowedDomains) == 0 {
+		return nil
+	}
+	for _, allowed := range cfg.AllowedDomains {
+		if domainMatches(host, allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("fetching from %s is not allowed, allowed domains are: %s", host, strings.Join(cfg.AllowedDomains, ", "))
+}
+
+func fetchDescription() string {
+	return fmt.Sprintf(`Fetches content from a URL and returns it as markdown or text. Use this tool instead of curl or wget, which are not allowed in the Bash tool.
+
+WHEN TO USE THIS TOOL:
+- Use when you need to read documentation, API references or other web pages
+- Helpful to download JSON or plain text from an HTTP API
+
+HOW TO USE:
+- Provide the http or https URL to fetch
+- HTML pages are converted to markdown, set format to "raw" to get the HTML instead
+- Text, JSON and XML responses are returned as they are
+- Optionally set a timeout in seconds (default %d, max %d)
+
+FEATURES:
+- Every request asks the user for permission, showing the domain being accessed
+- Scripts and styles are removed from HTML pages before conversion
+
+LIMITATIONS:
+- Responses larger than %d MB are rejected
+- Binary content such as images or archives is not supported
+- Only GET requests without authentication are made
+- Some domains can be denied, or only some allowed, by the configuration
+- A redirect to another domain needs the permission of that domain
+
+TIPS:
+- Prefer fetching specific documentation pages over large index pages
+- Long pages are truncated and stored to be read with the ReadOutput tool, or fetch a more specific URL`, DefaultFetchTimeout, MaxFetchTimeout, MaxFetchSize/(1024*1024))
+}
+
+// fetchParamsContextKey holds the parameters of a fetch in the context of its
+// HTTP request, for the permission requests of redirects.
+type fetchParamsContextKey struct{}
+
+// requestFetchPermission asks for the permission to fetch rawURL. The
+// permission is granted per domain.
+func requestFetchPermission(ctx context.Context, rawURL, domain string, params FetchParams) bool {
+	return permission.Default.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   GetSessionFromContext(ctx),
+			Path:        rawURL,
+			ToolName:    FetchToolName,
+			Action:      domain,
+			Description: fmt.Sprintf("Fetch %s from %s", rawURL, domain),
+			Params: FetchPermissionsParams{
+				URL:     rawURL,
+				Domain:  domain,
+				Format:  params.Format,
+				Timeout: params.Timeout,
+			},
+		},
+	)
+}
+
+// checkFetchRedirect refuses the redirects to domains outside the
+// configuration, and asks for the permission of a domain that was not
+// requested yet, the permission of the first request does not cover it.
+func checkFetchRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 5 {
+		return fmt.Errorf("stopped after 5 redirects")
+	}
+	domain := req.URL.Hostname()
+	if err := checkFetchDomain(domain, config.Get().Fetch); err != nil {
+		return err
+	}
+	for _, previous := range via {
+		if previous.URL.Hostname() == domain {
+			return nil
+		}
+	}
+	params, _ := req.Context().Value(fetchParamsContextKey{}).(FetchParams)
+	if !requestFetchPermission(req.Context(), req.URL.String(), domain, params) {
+		return fmt.Errorf("redirected to %s, permission denied for the domain %s", req.URL, domain)
+	}
+	return nil
+}
+
+func NewFetchTool() BaseTool {
+	return &fetchTool{
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				DialContext: (&net.Dialer{
+					Timeout: 10 * time.Second,
+				}).DialContext,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: MaxFetchTimeout * time.Second,
+			},
+			// redirects must not escape the configured nor the permitted domains
+			CheckRedirect: checkFetchRedirect,
+		},
+	}
+}
@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchTool_Info(t *testing.T) {
+	tool := NewFetchTool()
+	info := tool.Info()
+
+	assert.Equal(t, FetchToolName, info.Name)
+	assert.NotEmpty(t, info.Description)
+	assert.Contains(t, info.Parameters, "url")
+	assert.Contains(t, info.Parameters, "format")
+	assert.Contains(t, info.Parameters, "timeout")
+	assert.Contains(t, info.Required, "url")
+}
+
+func TestFetchTool_Run(t *testing.T) {
+	// loading the configuration sets the working directory, keep it as it was
+	origWd := viper.GetString("wd")
+	origPermission := permission.Default
+	origFetch := config.Get().Fetch
+	defer func() {
+		permission.Default = origPermission
+		config.Get().Fetch = origFetch
+		viper.Set("wd", origWd)
+	}()
+	permission.Default = newMockPermissionService(true)
+	config.Get().Fetch = nil
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><style>body{}</style><script>alert(1)</script></head>
+<body><h1>Title</h1><p>Some <strong>bold</strong> text and a <a href="/docs">link</a>.</p></body></html>`))
+	})
+	mux.HandleFunc("/data.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"key": "value"}`))
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte{0x89, 'P', 'N', 'G'})
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("a", MaxFetchSize+1)))
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	runFetch := func(t *testing.T, params FetchParams) ToolResponse {
+		paramsJSON, err := json.Marshal(params)
+		require.NoError(t, err)
+		response, err := NewFetchTool().Run(context.Background(), ToolCall{
+			Name:  FetchToolName,
+			Input: string(paramsJSON),
+		})
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("converts HTML to markdown", func(t *testing.T) {
+		response := runFetch(t, FetchParams{URL: server.URL + "/page"})
+		assert.False(t, response.IsError, response.Content)
+		assert.Contains(t, response.Content, "# Title")
+		assert.Contains(t, response.Content, "Some **bold** text")
+		assert.Contains(t, response.Content, "[link]("+server.URL+"/docs)")
+		assert.NotContains(t, response.Content, "alert(1)")
+	})
+
+	t.Run("returns raw HTML when requested", func(t *testing.T) {
+		response := runFetch(t, FetchParams{URL: server.URL + "/page", Format: "raw"})
+		assert.False(t, response.IsError)
+		assert.Contains(t, response.Content, "<h1>Title</h1>")
+	})
+
+	t.Run("returns JSON as it is", func(t *testing.T) {
+		response := runFetch(t, FetchParams{URL: server.URL + "/data.json"})
+		assert.False(t, response.IsError)
+		assert.Equal(t, `{"key": "value"}`, response.Content)
+	})
+
+	t.Run("rejects binary content", func(t *testing.T) {
+		response := runFetch(t, FetchParams{URL: server.URL + "/image.png"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "unsupported content type image/png")
+	})
+
+	t.Run("rejects responses over the size limit", func(t *testing.T) {
+		response := runFetch(t, FetchParams{URL: server.URL + "/large"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "too large")
+	})
+
+	t.Run("reports error status codes", func(t *testing.T) {
+		response := runFetch(t, FetchParams{URL: server.URL + "/missing"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "404")
+	})
+
+	t.Run("rejects unsupported schemes", func(t *testing.T) {
+		response := runFetch(t, FetchParams{URL: "file:///etc/passwd"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "http or https")
+	})
+
+	t.Run("handles permission denied", func(t *testing.T) {
+		permission.Default = newMockPermissionService(false)
+		defer func() { permission.Default = newMockPermissionService(true) }()
+
+		response := runFetch(t, FetchParams{URL: server.URL + "/page"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "permission denied")
+	})
+
+	t.Run("asks for the permission of a redirect to another domain", func(t *testing.T) {
+		// the same server under another host name
+		redirect := httptest.NewServer(http.RedirectHandler(strings.Replace(server.URL, "127.0.0.1", "localhost", 1)+"/data.json", http.StatusFound))
+		defer redirect.Close()
+
+		recorder := &recordingPermissionService{Service: &allowDomainsPermissionService{
+			Service: newMockPermissionService(true),
+			allowed: map[string]bool{"127.0.0.1": true},
+		}}
+		permission.Default = recorder
+		response := runFetch(t, FetchParams{URL: redirect.URL})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "redirected to http://localhost:")
+		require.Len(t, recorder.requests, 2)
+		assert.Equal(t, "localhost", recorder.requests[1].Action)
+
+		permission.Default = newMockPermissionService(true)
+		response = runFetch(t, FetchParams{URL: redirect.URL})
+		assert.False(t, response.IsError, response.Content)
+		assert.Equal(t, `{"key": "value"}`, response.Content)
+	})
+
+	t.Run("respects the domain configuration", func(t *testing.T) {
+		defer func() { config.Get().Fetch = nil }()
+
+		config.Get().Fetch = &config.Fetch{DeniedDomains: []string{"127.0.0.1"}}
+		response := runFetch(t, FetchParams{URL: server.URL + "/page"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "denied by the configuration")
+
+		config.Get().Fetch = &config.Fetch{AllowedDomains: []string{"example.com"}}
+		response = runFetch(t, FetchParams{URL: server.URL + "/page"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "not allowed")
+
+		config.Get().Fetch = &config.Fetch{AllowedDomains: []string{"127.0.0.1"}}
+		response = runFetch(t, FetchParams{URL: server.URL + "/page"})
+		assert.False(t, response.IsError, response.Content)
+	})
+}
+
+func TestDomainMatches(t *testing.T) {
+	tests := []struct {
+		host     string
+		domain   string
+		expected bool
+	}{
+		{"example.com", "example.com", true},
+		{"docs.example.com", "example.com", true},
+		{"docs.example.com", "*.example.com", true},
+		{"EXAMPLE.com", "example.COM", true},
+		{"notexample.com", "example.com", false},
+		{"example.com.evil.org", "example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host+" "+tt.domain, func(t *testing.T) {
+			assert.Equal(t, tt.expected, domainMatches(tt.host, tt.domain))
+		})
+	}
+}
+
+// allowDomainsPermissionService only allows the fetches of some domains.
+type allowDomainsPermissionService struct {
+	permission.Service
+	allowed map[string]bool
+}
+
+func (a *allowDomainsPermissionService) Request(opts permission.CreatePermissionRequest) bool {
+	return a.allowed[opts.Action]
+}
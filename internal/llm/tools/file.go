@@ -1,53 +1,49 @@
 package tools
 
 import (
-	"sync"
+	"context"
+	"errors"
+	"fmt"
+	"os"
 	"time"
-)
-
-// File record to track when files were read/written
-type fileRecord struct {
-	path      string
-	readTime  time.Time
-	writeTime time.Time
-}
 
-var (
-	fileRecords     = make(map[string]fileRecord)
-	fileRecordMutex sync.RWMutex
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
 )
 
-func recordFileRead(path string) {
-	fileRecordMutex.Lock()
-	defer fileRecordMutex.Unlock()
-
-	record, exists := fileRecords[path]
-	if !exists {
-		record = fileRecord{path: path}
+// recordFileRead records the current content of a file as seen by the session
+// of ctx. Failing to record only means the file has to be read again before
+// editing it, so errors are ignored.
+func recordFileRead(ctx context.Context, files filerecord.Service, path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
 	}
-	record.readTime = time.Now()
-	fileRecords[path] = record
+	_, _ = files.RecordRead(GetSessionFromContext(ctx), path, filerecord.Hash(content))
 }
 
-func getLastReadTime(path string) time.Time {
-	fileRecordMutex.RLock()
-	defer fileRecordMutex.RUnlock()
-
-	record, exists := fileRecords[path]
-	if !exists {
-		return time.Time{}
-	}
-	return record.readTime
+// recordFileWrite records the content the session of ctx wrote to a file.
+func recordFileWrite(ctx context.Context, files filerecord.Service, path string, content []byte) {
+	_, _ = files.RecordWrite(GetSessionFromContext(ctx), path, filerecord.Hash(content))
 }
 
-func recordFileWrite(path string) {
-	fileRecordMutex.Lock()
-	defer fileRecordMutex.Unlock()
+// checkFileFresh enforces that the session of ctx read a file before changing
+// it, and that its content has not changed since.
+func checkFileFresh(ctx context.Context, files filerecord.Service, path string) error {
+	record, err := files.Get(GetSessionFromContext(ctx), path)
+	if errors.Is(err, filerecord.ErrNotRecorded) {
+		return fmt.Errorf("you must read the file before editing it. Use the View tool first")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check when the file was read: %w", err)
+	}
 
-	record, exists := fileRecords[path]
-	if !exists {
-		record = fileRecord{path: path}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
 	}
-	record.writeTime = time.Now()
-	fileRecords[path] = record
-}
\ No newline at end of file
+	if filerecord.Hash(content) != record.Hash {
+		return fmt.Errorf("file %s has been modified since it was last read (last read: %s). Read it again before editing it",
+			path, time.Unix(record.ReadAt, 0).Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockFileRecordService struct {
+	mu      sync.Mutex
+	records map[string]filerecord.Record
+}
+
+func (m *mockFileRecordService) Get(sessionID, path string) (filerecord.Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.records[sessionID+"\x00"+path]
+	if !ok {
+		return filerecord.Record{}, filerecord.ErrNotRecorded
+	}
+	return record, nil
+}
+
+func (m *mockFileRecordService) ListByPath(path string) ([]filerecord.Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var records []filerecord.Record
+	for _, record := range m.records {
+		if record.Path == path {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (m *mockFileRecordService) RecordRead(sessionID, path, hash string) (filerecord.Record, error) {
+	return m.record(sessionID, path, hash, false)
+}
+
+func (m *mockFileRecordService) RecordWrite(sessionID, path, hash string) (filerecord.Record, error) {
+	return m.record(sessionID, path, hash, true)
+}
+
+func (m *mockFileRecordService) record(sessionID, path, hash string, write bool) (filerecord.Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := sessionID + "\x00" + path
+	record := m.records[key]
+	record.SessionID = sessionID
+	record.Path = path
+	record.Hash = hash
+	record.ReadAt = time.Now().Unix()
+	if write {
+		record.WrittenAt = record.ReadAt
+	}
+	m.records[key] = record
+	return record, nil
+}
+
+func (m *mockFileRecordService) Forget(sessionID, path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, record := range m.records {
+		if record.SessionID == sessionID && (record.Path == path || strings.HasPrefix(record.Path, path+string(filepath.Separator))) {
+			delete(m.records, key)
+		}
+	}
+	return nil
+}
+
+func newMockFileRecordService() *mockFileRecordService {
+	return &mockFileRecordService{records: make(map[string]filerecord.Record)}
+}
+
+func TestCheckFileFresh(t *testing.T) {
+	dir := t.TempDir()
+	files := newMockFileRecordService()
+	session := context.WithValue(context.Background(), SessionIDContextKey, "session")
+	other := context.WithValue(context.Background(), SessionIDContextKey, "other")
+
+	filePath := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(filePath, []byte("package main\n"), 0o644))
+
+	t.Run("requires a read", func(t *testing.T) {
+		err := checkFileFresh(session, files, filePath)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "you must read the file")
+	})
+
+	t.Run("accepts a file read by the session", func(t *testing.T) {
+		recordFileRead(session, files, filePath)
+		assert.NoError(t, checkFileFresh(session, files, filePath))
+	})
+
+	t.Run("does not share reads between sessions", func(t *testing.T) {
+		err := checkFileFresh(other, files, filePath)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "you must read the file")
+	})
+
+	t.Run("ignores a modification time change", func(t *testing.T) {
+		later := time.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(filePath, later, later))
+		assert.NoError(t, checkFileFresh(session, files, filePath))
+	})
+
+	t.Run("detects a content change", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filePath, []byte("package other\n"), 0o644))
+		err := checkFileFresh(session, files, filePath)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has been modified since it was last read")
+	})
+
+	t.Run("accepts the content the session wrote", func(t *testing.T) {
+		content := []byte("package main\n\nfunc main() {}\n")
+		require.NoError(t, os.WriteFile(filePath, content, 0o644))
+		recordFileWrite(session, files, filePath, content)
+		assert.NoError(t, checkFileFresh(session, files, filePath))
+	})
+}
@@ -0,0 +1,281 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
+)
+
+// maxPermissionFiles is the number of files below a directory listed in a
+// permission request
+const maxPermissionFiles = 20
+
+// FileOperationPermissionsParams describes a move, copy, delete or mkdir in
+// the permission dialog. Files lists the first files affected below a
+// directory, out of TotalFiles.
+type FileOperationPermissionsParams struct {
+	Operation   string   `json:"operation"`
+	Source      string   `json:"source"`
+	Destination string   `json:"destination,omitempty"`
+	Files       []string `json:"files,omitempty"`
+	TotalFiles  int      `json:"total_files"`
+}
+
+// dataDirectory returns the absolute data directory, a relative one being
+// relative to the working directory.
+func dataDirectory() string {
+	dir := config.Get().Data.Directory
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(config.WorkingDirectory(), dir)
+	}
+	return filepath.Clean(dir)
+}
+
+// trashDirectory returns the directory holding the deleted files.
+func trashDirectory() string {
+	return filepath.Join(dataDirectory(), "trash")
+}
+
+// resolveWorkspacePath returns the absolute path of a path given to a file
+// operation, relative paths being relative to the working directory. Paths
+// leading outside of the working directory, symbolic links included, the
+// working directory itself, the .git directory and the data directory are
+// refused. allowTrash allows the content of the trash, to restore it.
+func resolveWorkspacePath(path string, allowTrash bool) (string, error) {
+	wd := config.WorkingDirectory()
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(wd, path)
+	}
+	path = filepath.Clean(path)
+
+	// the last element is not followed, a link is moved or deleted itself
+	real := filepath.Join(resolveExisting(filepath.Dir(path)), filepath.Base(path))
+
+	// the data directory may be outside the working directory when it is
+	// absolute, the trash is checked first so it can still be restored
+	data := resolveExisting(dataDirectory())
+	if within(real, data) {
+		if !allowTrash || !within(real, filepath.Join(data, "trash")) || real == filepath.Join(data, "trash") {
+			return "", fmt.Errorf("the data directory of termai cannot be changed: %s", path)
+		}
+		if err := checkFileAccess(path); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+
+	rel, err := filepath.Rel(resolveExisting(wd), real)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside the working directory %s", path, wd)
+	}
+	if rel == "." {
+		return "", fmt.Errorf("the working directory itself cannot be changed")
+	}
+	if strings.Split(rel, string(filepath.Separator))[0] == ".git" {
+		return "", fmt.Errorf("the .git directory cannot be changed, use the %s tool", GitToolName)
+	}
+
+	if err := checkFileAccess(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// resolveExisting evaluates the symbolic links of the longest existing part
+// of path.
+func resolveExisting(path string) string {
+	missing := ""
+	for {
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			return filepath.Join(real, missing)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(path, missing)
+		}
+		missing = filepath.Join(filepath.Base(path), missing)
+		path = parent
+	}
+}
+
+// within reports whether path is dir or below it.
+func within(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// listTree returns the first files below a directory, relative to it, and
+// the total number of files.
+func listTree(dir string) ([]string, int, error) {
+	var files []string
+	total := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		total++
+		if len(files) < maxPermissionFiles {
+			rel, _ := filepath.Rel(dir, path)
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, total, err
+}
+
+// operationParams describes an operation on source for the permission
+// request, with the files below it when it is a directory.
+func operationParams(operation, source, destination string) (FileOperationPermissionsParams, error) {
+	params := FileOperationPermissionsParams{
+		Operation:   operation,
+		Source:      source,
+		Destination: destination,
+		TotalFiles:  1,
+	}
+	info, err := os.Lstat(source)
+	if err != nil {
+		return params, err
+	}
+	if info.IsDir() {
+		params.Files, params.TotalFiles, err = listTree(source)
+	}
+	return params, err
+}
+
+// describeFiles returns "file" or "directory with N files" for a permission
+// request.
+func describeFiles(params FileOperationPermissionsParams) string {
+	if params.Files == nil && params.TotalFiles == 1 {
+		return "file"
+	}
+	return fmt.Sprintf("directory with %d file%s", params.TotalFiles, pluralize(params.TotalFiles))
+}
+
+// copyPath copies a file, a symbolic link or a directory tree, keeping the
+// permissions of the files.
+func copyPath(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			return fmt.Errorf("cannot copy %s, it is not a regular file", path)
+		}
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// movePath renames src to dst, copying then removing it when they are on
+// different devices.
+func movePath(src, dst string) error {
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyPath(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// moveToTrash moves a file or a directory to a new entry of the trash, under
+// its path relative to the working directory, or its absolute path when it is
+// outside, and returns where it is now.
+func moveToTrash(path string) (string, error) {
+	suffix := make([]byte, 2)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	entry := filepath.Join(trashDirectory(), time.Now().Format("20060102-150405")+"-"+hex.EncodeToString(suffix))
+	rel, err := filepath.Rel(config.WorkingDirectory(), path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		rel = strings.TrimPrefix(path, filepath.VolumeName(path))
+	}
+	trashed := filepath.Join(entry, rel)
+	if err := os.MkdirAll(filepath.Dir(trashed), 0o755); err != nil {
+		return "", err
+	}
+	if err := movePath(path, trashed); err != nil {
+		return "", err
+	}
+	return trashed, nil
+}
+
+// moveFileRecord carries what the session of ctx knew of a moved or copied
+// file, or of the files below a moved or copied directory, to their new path
+// so they do not have to be read again. move forgets the old paths.
+func moveFileRecord(ctx context.Context, files filerecord.Service, src, dst string, move bool) {
+	sessionID := GetSessionFromContext(ctx)
+	_ = filepath.WalkDir(dst, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dst, path)
+		if err != nil {
+			return nil
+		}
+		record, err := files.Get(sessionID, filepath.Join(src, rel))
+		if err != nil {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err == nil && filerecord.Hash(content) == record.Hash {
+			_, _ = files.RecordRead(sessionID, path, record.Hash)
+		}
+		return nil
+	})
+	if move {
+		_ = files.Forget(sessionID, src)
+	}
+}
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupWorkspace makes a temporary directory the working directory, with the
+// data directory inside it, and allows every permission request.
+func setupWorkspace(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	origWd := viper.GetString("wd")
+	origDirectory := config.Get().Data.Directory
+	origPermission := permission.Default
+	t.Cleanup(func() {
+		viper.Set("wd", origWd)
+		config.Get().Data.Directory = origDirectory
+		permission.Default = origPermission
+	})
+	viper.Set("wd", dir)
+	config.Get().Data.Directory = ".termai"
+	permission.Default = newMockPermissionService(true)
+	return dir
+}
+
+func writeWorkspaceFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func runFileTool(t *testing.T, tool BaseTool, ctx context.Context, params any) ToolResponse {
+	t.Helper()
+	input, err := json.Marshal(params)
+	require.NoError(t, err)
+	response, err := tool.Run(ctx, ToolCall{Name: tool.Info().Name, Input: string(input)})
+	require.NoError(t, err)
+	return response
+}
+
+func TestResolveWorkspacePath(t *testing.T) {
+	dir := setupWorkspace(t)
+	outside := t.TempDir()
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "file"), filepath.Join(dir, "link")))
+
+	tests := []struct {
+		name       string
+		path       string
+		allowTrash bool
+		expected   string
+		err        string
+	}{
+		{name: "relative path", path: "src/main.go", expected: filepath.Join(dir, "src/main.go")},
+		{name: "absolute path", path: filepath.Join(dir, "a.txt"), expected: filepath.Join(dir, "a.txt")},
+		{name: "link itself", path: "link", expected: filepath.Join(dir, "link")},
+		{name: "parent directory", path: "../other", err: "outside the working directory"},
+		{name: "absolute outside", path: outside, err: "outside the working directory"},
+		{name: "through a link", path: "escape/file", err: "outside the working directory"},
+		{name: "working directory", path: ".", err: "working directory itself"},
+		{name: "git directory", path: ".git/config", err: ".git directory"},
+		{name: "data directory", path: ".termai/termai.db", err: "data directory"},
+		{name: "trash", path: ".termai/trash/x/a.txt", err: "data directory"},
+		{name: "trash allowed", path: ".termai/trash/x/a.txt", allowTrash: true, expected: filepath.Join(dir, ".termai/trash/x/a.txt")},
+		{name: "trash itself", path: ".termai/trash", allowTrash: true, err: "data directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := resolveWorkspacePath(tt.path, tt.allowTrash)
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, path)
+		})
+	}
+}
+
+func TestCopyPath(t *testing.T) {
+	dir := t.TempDir()
+	writeWorkspaceFile(t, dir, "src/a.txt", "a")
+	script := writeWorkspaceFile(t, dir, "src/sub/run.sh", "#!/bin/sh\n")
+	require.NoError(t, os.Chmod(script, 0o755))
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(dir, "src/link")))
+
+	require.NoError(t, copyPath(filepath.Join(dir, "src"), filepath.Join(dir, "dst")))
+
+	content, err := os.ReadFile(filepath.Join(dir, "dst/a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(content))
+	info, err := os.Stat(filepath.Join(dir, "dst/sub/run.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+	link, err := os.Readlink(filepath.Join(dir, "dst/link"))
+	require.NoError(t, err)
+	assert.Equal(t, "a.txt", link)
+}
+
+func TestMoveToTrash(t *testing.T) {
+	dir := setupWorkspace(t)
+	inside := writeWorkspaceFile(t, dir, "pkg/a.txt", "a")
+	outside := writeWorkspaceFile(t, t.TempDir(), "b.txt", "b")
+
+	trashed, err := moveToTrash(inside)
+	require.NoError(t, err)
+	entry := filepath.Dir(filepath.Dir(trashed))
+	assert.Equal(t, filepath.Join(dir, ".termai", "trash"), filepath.Dir(entry))
+	assert.Equal(t, filepath.Join(entry, "pkg", "a.txt"), trashed)
+
+	// a path outside the working directory keeps its absolute path in the
+	// trash
+	trashed, err = moveToTrash(outside)
+	require.NoError(t, err)
+	assert.True(t, within(trashed, filepath.Join(dir, ".termai", "trash")))
+	assert.True(t, strings.HasSuffix(trashed, outside))
+	assert.NoFileExists(t, outside)
+}
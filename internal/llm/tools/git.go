@@ -0,0 +1,518 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+)
+
+type gitTool struct{}
+
+const (
+	GitToolName = "git"
+
+	defaultGitLogLimit = 20
+	maxGitLogLimit     = 100
+	gitTimeout         = 30 * time.Second
+)
+
+type GitParams struct {
+	Command   string   `json:"command"`
+	Paths     []string `json:"paths"`
+	Ref       string   `json:"ref"`
+	Staged    bool     `json:"staged"`
+	Stat      bool     `json:"stat"`
+	Limit     int      `json:"limit"`
+	File      string   `json:"file"`
+	StartLine int      `json:"start_line"`
+	EndLine   int      `json:"end_line"`
+}
+
+func (g *gitTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        GitToolName,
+		Description: gitDescription(),
+		Parameters: map[string]any{
+			"command": map[string]any{
+				"type":        "string",
+				"enum":        []string{"status", "diff", "log", "show", "blame"},
+				"description": "The git command to run",
+			},
+			"paths": map[string]any{
+				"type":        "array",
+				"description": "Limit status, diff, log and show to these files or directories",
+				"items": map[string]any{
+					"type": "string",
+				},
+			},
+			"ref": map[string]any{
+				"type":        "string",
+				"description": "diff: a commit or range to compare to (e.g. \"main\", \"HEAD~3..HEAD\"), log: where to start (default HEAD), show: the commit to show (default HEAD), blame: the revision to blame",
+			},
+			"staged": map[string]any{
+				"type":        "boolean",
+				"description": "diff: show the staged changes instead of the unstaged ones",
+			},
+			"stat": map[string]any{
+				"type":        "boolean",
+				"description": "diff and show: only show the changed files with the number of changed lines",
+			},
+			"limit": map[string]any{
+				"type":        "number",
+				"description": "log: the number of commits to show (default 20, max 100)",
+			},
+			"file": map[string]any{
+				"type":        "string",
+				"description": "blame: the file to blame",
+			},
+			"start_line": map[string]any{
+				"type":        "number",
+				"description": "blame: the first line to blame (1-based)",
+			},
+			"end_line": map[string]any{
+				"type":        "number",
+				"description": "blame: the last line to blame",
+			},
+		},
+		Required: []string{"command"},
+	}
+}
+
+// Run implements Tool. Every command is read only so it runs without asking
+// for permission.
+func (g *gitTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params GitParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if err := validateGitParams(params); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	var output string
+	var err error
+	switch params.Command {
+	case "status":
+		output, err = gitStatus(ctx, params.Paths)
+	case "diff":
+		output, err = gitDiff(ctx, params)
+	case "log":
+		output, err = gitLog(ctx, params)
+	case "show":
+		output, err = gitShow(ctx, params)
+	case "blame":
+		output, err = gitBlame(ctx, params)
+	case "":
+		return NewTextErrorResponse("command is required"), nil
+	default:
+		return NewTextErrorResponse(fmt.Sprintf("unknown command %q, use status, diff, log, show or blame", params.Command)), nil
+	}
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	return NewTextResponse(spillOutput(ctx, GitToolName, output)), nil
+}
+
+// validateGitParams rejects values git would read as options, like
+// --output which writes a file.
+func validateGitParams(params GitParams) error {
+	if strings.HasPrefix(params.Ref, "-") {
+		return fmt.Errorf("invalid ref %q", params.Ref)
+	}
+	if params.Limit < 0 || params.StartLine < 0 || params.EndLine < 0 {
+		return errors.New("limit, start_line and end_line must not be negative")
+	}
+	if params.EndLine > 0 && params.EndLine < params.StartLine {
+		return errors.New("end_line must not be before start_line")
+	}
+	return nil
+}
+
+// runGit runs a read only git command in the working directory. Optional
+// locks are disabled so status does not refresh the index.
+func runGit(ctx context.Context, args ...string) (string, error) {
+	command := args[0]
+	args = append([]string{"--no-pager", "-c", "core.fsmonitor=false", "-c", "color.ui=false"}, args...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = config.WorkingDirectory()
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0", "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s failed: %s", command, msg)
+		}
+		return "", fmt.Errorf("git %s failed: %w", command, err)
+	}
+	return stdout.String(), nil
+}
+
+func gitPathArgs(paths []string) []string {
+	if len(paths) == 0 {
+		return nil
+	}
+	return append([]string{"--"}, paths...)
+}
+
+// GitStatusSummary is the state of the repository shown in the environment
+// information of the prompt.
+type GitStatusSummary struct {
+	Branch string
+	// Changes is the number of changed, staged or untracked files
+	Changes int
+}
+
+// GetGitStatusSummary returns the branch and the number of changed files of
+// the repository in the working directory.
+func GetGitStatusSummary(ctx context.Context) (GitStatusSummary, error) {
+	status, err := readGitStatus(ctx, nil)
+	if err != nil {
+		return GitStatusSummary{}, err
+	}
+	return GitStatusSummary{
+		Branch:  status.branch,
+		Changes: len(status.staged) + len(status.unstaged) + len(status.untracked) + len(status.conflicts),
+	}, nil
+}
+
+type gitStatusEntry struct {
+	code byte
+	path string
+	// from is the old path of renamed and copied files
+	from string
+}
+
+type gitStatusResult struct {
+	branch   string
+	upstream string
+	ahead    string
+	behind   string
+
+	staged    []gitStatusEntry
+	unstaged  []gitStatusEntry
+	untracked []string
+	conflicts []string
+}
+
+func readGitStatus(ctx context.Context, paths []string) (gitStatusResult, error) {
+	args := append([]string{"status", "--porcelain=v1", "--branch", "-z", "--untracked-files=all"}, gitPathArgs(paths)...)
+	out, err := runGit(ctx, args...)
+	if err != nil {
+		return gitStatusResult{}, err
+	}
+	return parseGitStatus(out), nil
+}
+
+// parseGitStatus parses the output of git status --porcelain=v1 --branch -z.
+func parseGitStatus(out string) gitStatusResult {
+	var result gitStatusResult
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if strings.HasPrefix(entry, "## ") {
+			result.parseBranch(entry[3:])
+			continue
+		}
+		if len(entry) < 4 {
+			continue
+		}
+		x, y, path := entry[0], entry[1], entry[3:]
+		from := ""
+		if x == 'R' || x == 'C' || y == 'R' || y == 'C' {
+			// the old path is the next entry
+			if i+1 < len(entries) {
+				from = entries[i+1]
+				i++
+			}
+		}
+
+		switch {
+		case x == '?':
+			result.untracked = append(result.untracked, path)
+		case x == '!':
+		case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+			result.conflicts = append(result.conflicts, path)
+		default:
+			if x != ' ' {
+				result.staged = append(result.staged, gitStatusEntry{code: x, path: path, from: from})
+			}
+			if y != ' ' {
+				result.unstaged = append(result.unstaged, gitStatusEntry{code: y, path: path, from: from})
+			}
+		}
+	}
+	return result
+}
+
+// parseBranch parses the branch header, like "main...origin/main [ahead 1]"
+// or "No commits yet on main".
+func (r *gitStatusResult) parseBranch(header string) {
+	header = strings.TrimPrefix(header, "No commits yet on ")
+	header = strings.TrimPrefix(header, "Initial commit on ")
+	if i := strings.Index(header, " ["); i >= 0 {
+		for _, part := range strings.Split(strings.Trim(header[i+2:], "]"), ", ") {
+			if n, ok := strings.CutPrefix(part, "ahead "); ok {
+				r.ahead = n
+			} else if n, ok := strings.CutPrefix(part, "behind "); ok {
+				r.behind = n
+			}
+		}
+		header = header[:i]
+	}
+	r.branch, r.upstream, _ = strings.Cut(header, "...")
+	if r.branch == "HEAD (no branch)" {
+		r.branch = "detached HEAD"
+	}
+}
+
+var gitStatusNames = map[byte]string{
+	'M': "modified",
+	'A': "added",
+	'D': "deleted",
+	'R': "renamed",
+	'C': "copied",
+	'T': "type changed",
+}
+
+func gitStatus(ctx context.Context, paths []string) (string, error) {
+	status, err := readGitStatus(ctx, paths)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Branch: " + status.branch)
+	if status.upstream != "" {
+		fmt.Fprintf(&sb, " (tracking %s", status.upstream)
+		if status.ahead != "" {
+			fmt.Fprintf(&sb, ", ahead %s", status.ahead)
+		}
+		if status.behind != "" {
+			fmt.Fprintf(&sb, ", behind %s", status.behind)
+		}
+		sb.WriteString(")")
+	}
+	sb.WriteString("\n")
+
+	writeEntries := func(title string, entries []gitStatusEntry) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "\n%s (%d):\n", title, len(entries))
+		for _, e := range entries {
+			name := gitStatusNames[e.code]
+			if name == "" {
+				name = string(e.code)
+			}
+			if e.from != "" {
+				fmt.Fprintf(&sb, "  %s: %s -> %s\n", name, e.from, e.path)
+			} else {
+				fmt.Fprintf(&sb, "  %s: %s\n", name, e.path)
+			}
+		}
+	}
+	writePaths := func(title string, paths []string) {
+		if len(paths) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "\n%s (%d):\n", title, len(paths))
+		for _, path := range paths {
+			fmt.Fprintf(&sb, "  %s\n", path)
+		}
+	}
+	writePaths("Conflicts", status.conflicts)
+	writeEntries("Staged", status.staged)
+	writeEntries("Not staged", status.unstaged)
+	writePaths("Untracked", status.untracked)
+
+	if len(status.conflicts)+len(status.staged)+len(status.unstaged)+len(status.untracked) == 0 {
+		sb.WriteString("\nWorking tree clean\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+func gitDiff(ctx context.Context, params GitParams) (string, error) {
+	args := []string{"diff", "--no-ext-diff", "--no-textconv"}
+	if params.Staged {
+		args = append(args, "--cached")
+	}
+	if params.Stat {
+		args = append(args, "--stat")
+	}
+	if params.Ref != "" {
+		args = append(args, params.Ref)
+	}
+	args = append(args, gitPathArgs(params.Paths)...)
+	out, err := runGit(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(out) == "" {
+		return "No changes", nil
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func gitLog(ctx context.Context, params GitParams) (string, error) {
+	limit := params.Limit
+	if limit == 0 {
+		limit = defaultGitLogLimit
+	}
+	limit = min(limit, maxGitLogLimit)
+
+	// one commit per line: short hash, date, author and subject
+	args := []string{"log", "--no-ext-diff", fmt.Sprintf("--max-count=%d", limit), "--date=short", "--format=%h %ad %an: %s"}
+	if params.Ref != "" {
+		args = append(args, params.Ref)
+	}
+	args = append(args, gitPathArgs(params.Paths)...)
+	out, err := runGit(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	out = strings.TrimSuffix(out, "\n")
+	if out == "" {
+		return "No commits", nil
+	}
+	if count := strings.Count(out, "\n") + 1; count == limit {
+		out += fmt.Sprintf("\n\n(Showing the last %d commits, use limit to see more.)", limit)
+	}
+	return out, nil
+}
+
+func gitShow(ctx context.Context, params GitParams) (string, error) {
+	ref := params.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	args := []string{"show", "--no-ext-diff", "--no-textconv", "--date=iso", "--format=commit %H%nAuthor: %an <%ae>%nDate: %ad%n%n%B"}
+	if params.Stat {
+		args = append(args, "--stat")
+	}
+	args = append(args, ref)
+	args = append(args, gitPathArgs(params.Paths)...)
+	out, err := runGit(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+type gitBlameCommit struct {
+	author string
+	date   string
+}
+
+func gitBlame(ctx context.Context, params GitParams) (string, error) {
+	if params.File == "" {
+		return "", errors.New("file is required for blame")
+	}
+	file := params.File
+	if filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(config.WorkingDirectory(), file); err == nil {
+			file = rel
+		}
+	}
+
+	args := []string{"blame", "--porcelain", "--no-textconv"}
+	if params.StartLine > 0 || params.EndLine > 0 {
+		start := max(params.StartLine, 1)
+		end := ""
+		if params.EndLine > 0 {
+			end = strconv.Itoa(params.EndLine)
+		}
+		args = append(args, fmt.Sprintf("-L%d,%s", start, end))
+	}
+	if params.Ref != "" {
+		args = append(args, params.Ref)
+	}
+	args = append(args, "--", file)
+	out, err := runGit(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	return formatGitBlame(out), nil
+}
+
+// formatGitBlame turns git blame --porcelain output into one line per source
+// line: short hash, author, date, line number and text.
+func formatGitBlame(out string) string {
+	commits := make(map[string]*gitBlameCommit)
+	var sb strings.Builder
+	var hash string
+	var lineNumber string
+	for _, line := range strings.Split(out, "\n") {
+		if text, ok := strings.CutPrefix(line, "\t"); ok {
+			c := commits[hash]
+			short := hash
+			if len(short) > 8 {
+				short = short[:8]
+			}
+			if strings.Trim(hash, "0") == "" {
+				short = "uncommitted"
+			}
+			fmt.Fprintf(&sb, "%s (%s %s) %s: %s\n", short, c.author, c.date, lineNumber, text)
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && (len(fields[0]) == 40 || len(fields[0]) == 64) {
+			hash, lineNumber = fields[0], fields[2]
+			if commits[hash] == nil {
+				commits[hash] = &gitBlameCommit{}
+			}
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			commits[hash].author = value
+		case "author-time":
+			if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+				commits[hash].date = time.Unix(sec, 0).UTC().Format("2006-01-02")
+			}
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func gitDescription() string {
+	return `Runs read only git commands in the working directory and returns concise results. It never changes the repository, so it runs without asking for permission.
+
+WHEN TO USE THIS TOOL:
+- Use to see what changed in the working tree before reviewing or committing
+- Use to read the history of files, the content of a commit or who last changed some lines
+- Prefer it to running git with the Bash tool for these read only commands
+
+HOW TO USE:
+- status: the branch, its upstream and the staged, not staged, untracked and conflicting files, optionally limited to paths
+- diff: the unstaged changes, or the staged ones with staged, or the changes since ref (a commit or a range like "main..HEAD"), optionally limited to paths, stat only lists the changed files
+- log: the last commits, one per line with short hash, date, author and subject, limit sets how many (default 20), ref where to start and paths filters the commits touching them
+- show: a commit (ref, default HEAD) with its message and diff, stat only lists the changed files
+- blame: the last commit of each line of file, optionally from start_line to end_line
+
+LIMITATIONS:
+- Only status, diff, log, show and blame are available, use the Bash tool to commit, checkout or change branches
+- Long outputs are truncated and stored to be read with the ReadOutput tool, use paths, stat or line ranges to narrow them
+
+TIPS:
+- Start with a diff using stat to see which files changed, then diff these paths
+- Use blame with a line range, then show the commit to understand why some code was written`
+}
+
+func NewGitTool() BaseTool {
+	return &gitTool{}
+}
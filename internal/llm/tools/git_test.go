@@ -0,0 +1,236 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupGitRepo creates a repository with two commits, then stages, changes
+// and adds files without committing them.
+func setupGitRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE=2024-03-01T10:00:00Z", "GIT_COMMITTER_DATE=2024-03-01T10:00:00Z")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	git("init", "-q", "-b", "main")
+	git("config", "user.name", "Ada")
+	git("config", "user.email", "ada@example.com")
+	write("main.go", "package main\n\nfunc main() {\n}\n")
+	write("old.txt", "old\n")
+	write("README.md", "# demo\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "Initial commit")
+	write("main.go", "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n")
+	git("commit", "-q", "-am", "Print a greeting")
+
+	write("README.md", "# demo\n\nUpdated.\n")
+	git("mv", "old.txt", "new.txt")
+	write("staged.go", "package main\n")
+	git("add", "staged.go")
+	write("main.go", "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n")
+	write("untracked.txt", "new\n")
+
+	origWd := viper.GetString("wd")
+	t.Cleanup(func() {
+		viper.Set("wd", origWd)
+	})
+	viper.Set("wd", dir)
+	return dir
+}
+
+func TestGitTool(t *testing.T) {
+	dir := setupGitRepo(t)
+
+	// the tool is read only and must never ask for permission
+	origPermission := permission.Default
+	defer func() {
+		permission.Default = origPermission
+	}()
+	recorder := &recordingPermissionService{Service: newMockPermissionService(false)}
+	permission.Default = recorder
+
+	tool := NewGitTool()
+	run := func(t *testing.T, params GitParams) ToolResponse {
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		response, err := tool.Run(context.Background(), ToolCall{Input: string(input)})
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("status", func(t *testing.T) {
+		response := run(t, GitParams{Command: "status"})
+		require.False(t, response.IsError, response.Content)
+		assert.Equal(t, `Branch: main
+
+Staged (2):
+  renamed: old.txt -> new.txt
+  added: staged.go
+
+Not staged (2):
+  modified: README.md
+  modified: main.go
+
+Untracked (1):
+  untracked.txt`, response.Content)
+	})
+
+	t.Run("status with paths", func(t *testing.T) {
+		response := run(t, GitParams{Command: "status", Paths: []string{"README.md"}})
+		require.False(t, response.IsError, response.Content)
+		assert.Equal(t, "Branch: main\n\nNot staged (1):\n  modified: README.md", response.Content)
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		response := run(t, GitParams{Command: "diff", Paths: []string{"main.go"}})
+		require.False(t, response.IsError, response.Content)
+		assert.Contains(t, response.Content, "-\tprintln(\"hi\")\n+\tprintln(\"hello\")")
+		assert.NotContains(t, response.Content, "README.md")
+	})
+
+	t.Run("diff staged stat", func(t *testing.T) {
+		response := run(t, GitParams{Command: "diff", Staged: true, Stat: true})
+		require.False(t, response.IsError, response.Content)
+		assert.Contains(t, response.Content, "staged.go")
+		assert.Contains(t, response.Content, "old.txt => new.txt")
+		assert.NotContains(t, response.Content, "main.go")
+	})
+
+	t.Run("diff without changes", func(t *testing.T) {
+		response := run(t, GitParams{Command: "diff", Paths: []string{"untracked.txt"}})
+		assert.Equal(t, "No changes", response.Content)
+	})
+
+	t.Run("log", func(t *testing.T) {
+		response := run(t, GitParams{Command: "log"})
+		require.False(t, response.IsError, response.Content)
+		lines := strings.Split(response.Content, "\n")
+		require.Len(t, lines, 2)
+		assert.Regexp(t, `^[0-9a-f]{7,} 2024-03-01 Ada: Print a greeting$`, lines[0])
+		assert.Regexp(t, `^[0-9a-f]{7,} 2024-03-01 Ada: Initial commit$`, lines[1])
+	})
+
+	t.Run("log with limit and paths", func(t *testing.T) {
+		response := run(t, GitParams{Command: "log", Limit: 1})
+		assert.Contains(t, response.Content, "Print a greeting")
+		assert.Contains(t, response.Content, "Showing the last 1 commits")
+
+		response = run(t, GitParams{Command: "log", Paths: []string{"README.md"}})
+		assert.Contains(t, response.Content, "Initial commit")
+		assert.NotContains(t, response.Content, "Print a greeting")
+	})
+
+	t.Run("show", func(t *testing.T) {
+		response := run(t, GitParams{Command: "show"})
+		require.False(t, response.IsError, response.Content)
+		assert.Contains(t, response.Content, "Author: Ada <ada@example.com>")
+		assert.Contains(t, response.Content, "Print a greeting")
+		assert.Contains(t, response.Content, "+\tprintln(\"hi\")")
+
+		response = run(t, GitParams{Command: "show", Ref: "HEAD~1", Stat: true})
+		require.False(t, response.IsError, response.Content)
+		assert.Contains(t, response.Content, "Initial commit")
+		assert.Contains(t, response.Content, "3 files changed")
+	})
+
+	t.Run("blame", func(t *testing.T) {
+		response := run(t, GitParams{Command: "blame", File: filepath.Join(dir, "main.go"), StartLine: 3, EndLine: 4})
+		require.False(t, response.IsError, response.Content)
+		lines := strings.Split(response.Content, "\n")
+		require.Len(t, lines, 2)
+		assert.Regexp(t, `^[0-9a-f]{8} \(Ada 2024-03-01\) 3: func main\(\) \{$`, lines[0])
+		assert.Regexp(t, `^uncommitted \(Not Committed Yet .*\) 4: \tprintln\("hello"\)$`, lines[1])
+
+		response = run(t, GitParams{Command: "blame", File: "main.go", Ref: "HEAD", StartLine: 4, EndLine: 4})
+		require.False(t, response.IsError, response.Content)
+		assert.Regexp(t, `^[0-9a-f]{8} \(Ada 2024-03-01\) 4: \tprintln\("hi"\)$`, response.Content)
+	})
+
+	t.Run("blame does not run textconv drivers", func(t *testing.T) {
+		marker := filepath.Join(t.TempDir(), "textconv-ran")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "info", "attributes"), []byte("*.go diff=marker\n"), 0o644))
+		defer os.Remove(filepath.Join(dir, ".git", "info", "attributes"))
+		cmd := exec.Command("git", "config", "diff.marker.textconv", "touch "+marker+"; cat")
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+
+		response := run(t, GitParams{Command: "blame", File: "main.go", StartLine: 1, EndLine: 1})
+		require.False(t, response.IsError, response.Content)
+		assert.NoFileExists(t, marker)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		tests := []struct {
+			params GitParams
+			error  string
+		}{
+			{params: GitParams{}, error: "command is required"},
+			{params: GitParams{Command: "commit"}, error: "unknown command"},
+			{params: GitParams{Command: "diff", Ref: "--output=/tmp/x"}, error: "invalid ref"},
+			{params: GitParams{Command: "blame"}, error: "file is required"},
+			{params: GitParams{Command: "blame", File: "main.go", StartLine: 4, EndLine: 2}, error: "end_line must not be before start_line"},
+			{params: GitParams{Command: "show", Ref: "nope"}, error: "git show failed"},
+		}
+		for _, tt := range tests {
+			response := run(t, tt.params)
+			assert.True(t, response.IsError)
+			assert.Contains(t, response.Content, tt.error)
+		}
+	})
+
+	t.Run("paths are not read as options", func(t *testing.T) {
+		response := run(t, GitParams{Command: "diff", Paths: []string{"--output=" + filepath.Join(dir, "out")}})
+		assert.Equal(t, "No changes", response.Content)
+		assert.NoFileExists(t, filepath.Join(dir, "out"))
+	})
+
+	assert.Empty(t, recorder.requests)
+}
+
+func TestGetGitStatusSummary(t *testing.T) {
+	setupGitRepo(t)
+
+	summary, err := GetGitStatusSummary(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, GitStatusSummary{Branch: "main", Changes: 5}, summary)
+}
+
+func TestParseGitStatus(t *testing.T) {
+	status := parseGitStatus("## feature...origin/feature [ahead 2, behind 1]\x00UU conflict.go\x00 D gone.go\x00MM both.go\x00")
+	assert.Equal(t, "feature", status.branch)
+	assert.Equal(t, "origin/feature", status.upstream)
+	assert.Equal(t, "2", status.ahead)
+	assert.Equal(t, "1", status.behind)
+	assert.Equal(t, []string{"conflict.go"}, status.conflicts)
+	assert.Equal(t, []gitStatusEntry{{code: 'M', path: "both.go"}}, status.staged)
+	assert.Equal(t, []gitStatusEntry{{code: 'D', path: "gone.go"}, {code: 'M', path: "both.go"}}, status.unstaged)
+
+	status = parseGitStatus("## No commits yet on main\x00")
+	assert.Equal(t, "main", status.branch)
+	assert.Empty(t, status.upstream)
+}
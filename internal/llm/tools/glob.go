@@ -104,6 +104,7 @@ func globFiles(pattern, searchPath string, limit int) ([]string, bool, error) {
 
 	// Collect matching files
 	var matches []fileInfo
+	ignore := newIgnoreMatcher(searchPath)
 
 	// Use doublestar to walk the filesystem and find matches
 	err := doublestar.GlobWalk(fsys, relPattern, func(path string, d fs.DirEntry) error {
@@ -111,7 +112,7 @@ func globFiles(pattern, searchPath string, limit int) ([]string, bool, error) {
 		if d.IsDir() {
 			return nil
 		}
-		if skipHidden(path) {
+		if skipHidden(path) || ignore.Ignored("/"+path, false) {
 			return nil
 		}
 
@@ -194,6 +195,7 @@ LIMITATIONS:
 - Results are limited to 100 files (newest first)
 - Does not search file contents (use Grep tool for that)
 - Hidden files (starting with '.') are skipped
+- Files ignored by .gitignore files, the global git excludes or .termaiignore are skipped
 
 TIPS:
 - For the most useful results, combine with the Grep tool: first find files with Glob, then search their contents with Grep
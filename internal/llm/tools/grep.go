@@ -1,17 +1,24 @@
 package tools
 
 import (
-	"bufio"
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/imnulhaqueruman/opencode-poc/internal/config"
 )
@@ -20,17 +27,59 @@ type grepTool struct{}
 
 const (
 	GrepToolName = "grep"
+
+	GrepOutputFiles   = "files_with_matches"
+	GrepOutputContent = "content"
+	GrepOutputCount   = "count"
+
+	defaultGrepLimit = 100
+	// maxGrepLineLength is where lines are cut in the content output, minified
+	// files would fill it otherwise
+	maxGrepLineLength = 500
+	// maxGrepFiles stops searches matching too many files, sorting them
+	// all by modification time is not worth it
+	maxGrepFiles = 1000
+	// maxGrepFileSize is the size of the largest file searched
+	maxGrepFileSize = 10 * 1024 * 1024
+	// binaryCheckSize is how much of a file is checked for NUL bytes to skip
+	// binary files
+	binaryCheckSize = 8000
 )
 
 type GrepParams struct {
-	Pattern string `json:"pattern"`
-	Path    string `json:"path"`
-	Include string `json:"include"`
+	Pattern    string `json:"pattern"`
+	Path       string `json:"path"`
+	Include    string `json:"include"`
+	OutputMode string `json:"output_mode"`
+	Before     int    `json:"before"`
+	After      int    `json:"after"`
+	Context    int    `json:"context"`
+	IgnoreCase bool   `json:"ignore_case"`
+	Multiline  bool   `json:"multiline"`
+	Offset     int    `json:"offset"`
+	Limit      int    `json:"limit"`
+}
+
+// grepOptions are the search options shared by ripgrep and the fallback.
+type grepOptions struct {
+	pattern    string
+	include    string
+	before     int
+	after      int
+	ignoreCase bool
+	multiline  bool
+	// maxFiles stops the search once that many files matched, 0 means
+	// no limit
+	maxFiles int
 }
 
 type grepMatch struct {
 	path    string
 	modTime time.Time
+	// lines are the numbers of the matching lines, in order
+	lines []int
+	// text holds the matching lines and their context lines by line number
+	text map[int]string
 }
 
 func (g *grepTool) Info() ToolInfo {
@@ -50,6 +99,39 @@ func (g *grepTool) Info() ToolInfo {
 				"type":        "string",
 				"description": "File pattern to include in the search (e.g. \"*.js\", \"*.{ts,tsx}\")",
 			},
+			"output_mode": map[string]any{
+				"type":        "string",
+				"enum":        []string{GrepOutputFiles, GrepOutputContent, GrepOutputCount},
+				"description": "\"files_with_matches\" lists the matching files (default), \"content\" shows the matching lines with their line numbers, \"count\" shows the number of matching lines per file",
+			},
+			"before": map[string]any{
+				"type":        "number",
+				"description": "Number of lines to show before each match, content mode only",
+			},
+			"after": map[string]any{
+				"type":        "number",
+				"description": "Number of lines to show after each match, content mode only",
+			},
+			"context": map[string]any{
+				"type":        "number",
+				"description": "Number of lines to show before and after each match, content mode only",
+			},
+			"ignore_case": map[string]any{
+				"type":        "boolean",
+				"description": "Match without case sensitivity",
+			},
+			"multiline": map[string]any{
+				"type":        "boolean",
+				"description": "Let the pattern match across lines, '.' also matches newlines",
+			},
+			"offset": map[string]any{
+				"type":        "number",
+				"description": "Number of results to skip, files in files_with_matches and count modes, matching lines in content mode",
+			},
+			"limit": map[string]any{
+				"type":        "number",
+				"description": "Maximum number of results to return (default 100)",
+			},
 		},
 		Required: []string{"pattern"},
 	}
@@ -66,30 +148,61 @@ func (g *grepTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		return NewTextErrorResponse("pattern is required"), nil
 	}
 
+	switch params.OutputMode {
+	case "":
+		params.OutputMode = GrepOutputFiles
+	case GrepOutputFiles, GrepOutputContent, GrepOutputCount:
+	default:
+		return NewTextErrorResponse(fmt.Sprintf("invalid output_mode %q, use %s, %s or %s", params.OutputMode, GrepOutputFiles, GrepOutputContent, GrepOutputCount)), nil
+	}
+	if params.Before < 0 || params.After < 0 || params.Context < 0 || params.Offset < 0 || params.Limit < 0 {
+		return NewTextErrorResponse("before, after, context, offset and limit must not be negative"), nil
+	}
+	if params.Before == 0 {
+		params.Before = params.Context
+	}
+	if params.After == 0 {
+		params.After = params.Context
+	}
+	if params.Limit == 0 {
+		params.Limit = defaultGrepLimit
+	}
+
 	// If path is empty, use current working directory
 	searchPath := params.Path
 	if searchPath == "" {
 		searchPath = config.WorkingDirectory()
 	}
 
-	matches, truncated, err := searchFiles(params.Pattern, searchPath, params.Include, 100)
+	opts := grepOptions{
+		pattern:    params.Pattern,
+		include:    params.Include,
+		ignoreCase: params.IgnoreCase,
+		multiline:  params.Multiline,
+		maxFiles:   maxGrepFiles,
+	}
+	if params.OutputMode == GrepOutputContent {
+		// context lines are only shown in content mode
+		opts.before = params.Before
+		opts.after = params.After
+	}
+	matches, truncated, err := searchFiles(ctx, searchPath, opts)
 	if err != nil {
 		return NewTextErrorResponse(fmt.Sprintf("error searching files: %s", err)), nil
 	}
 
 	// Format the output for the assistant
 	var output string
-	if len(matches) == 0 {
-		output = "No files found"
-	} else {
-		output = fmt.Sprintf("Found %d file%s\n%s",
-			len(matches),
-			pluralize(len(matches)),
-			strings.Join(matches, "\n"))
-
-		if truncated {
-			output += "\n\n(Results are truncated. Consider using a more specific path or pattern.)"
-		}
+	switch params.OutputMode {
+	case GrepOutputContent:
+		output = formatGrepContent(matches, opts, params.Offset, params.Limit)
+	case GrepOutputCount:
+		output = formatGrepCount(matches, params.Offset, params.Limit)
+	default:
+		output = formatGrepFiles(matches, params.Offset, params.Limit)
+	}
+	if truncated {
+		output += fmt.Sprintf("\n\n(The search stopped after %d matching files, the results above are incomplete and not the most recent files. Use a more specific path, include or pattern.)", maxGrepFiles)
 	}
 
 	return NewTextResponse(output), nil
@@ -102,12 +215,162 @@ func pluralize(count int) string {
 	return "s"
 }
 
-func searchFiles(pattern, rootPath, include string, limit int) ([]string, bool, error) {
+// grepPage returns the range of the results shown for offset and limit.
+func grepPage(total, offset, limit int) (int, int) {
+	start := min(offset, total)
+	return start, min(start+limit, total)
+}
+
+// grepPageNote tells how to get the next results when some are not shown.
+func grepPageNote(kind string, start, end, total int) string {
+	if start == 0 && end == total {
+		return ""
+	}
+	if end == total {
+		return fmt.Sprintf("\n\n(Showing %s %d-%d of %d.)", kind, start+1, end, total)
+	}
+	return fmt.Sprintf("\n\n(Results are truncated, showing %s %d-%d of %d. Use offset %d to see more, or a more specific path or pattern.)", kind, start+1, end, total, end)
+}
+
+func formatGrepFiles(matches []grepMatch, offset, limit int) string {
+	if len(matches) == 0 {
+		return "No files found"
+	}
+	start, end := grepPage(len(matches), offset, limit)
+	if start == end {
+		return fmt.Sprintf("Found %d file%s, none left after offset %d", len(matches), pluralize(len(matches)), offset)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Found %d file%s", len(matches), pluralize(len(matches)))
+	for _, m := range matches[start:end] {
+		sb.WriteString("\n")
+		sb.WriteString(m.path)
+	}
+	sb.WriteString(grepPageNote("files", start, end, len(matches)))
+	return sb.String()
+}
+
+func formatGrepCount(matches []grepMatch, offset, limit int) string {
+	if len(matches) == 0 {
+		return "No matches found"
+	}
+	total := 0
+	for _, m := range matches {
+		total += len(m.lines)
+	}
+	header := fmt.Sprintf("Found %d match%s in %d file%s", total, pluralizeES(total), len(matches), pluralize(len(matches)))
+	start, end := grepPage(len(matches), offset, limit)
+	if start == end {
+		return fmt.Sprintf("%s, none left after offset %d", header, offset)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(header)
+	for _, m := range matches[start:end] {
+		fmt.Fprintf(&sb, "\n%s:%d", m.path, len(m.lines))
+	}
+	sb.WriteString(grepPageNote("files", start, end, len(matches)))
+	return sb.String()
+}
+
+// formatGrepContent writes the matching lines like grep -n with the file
+// name, "path:12:text" for matches and "path-13-text" for context lines.
+// Groups of lines that are not adjacent are separated by "--" when context
+// is shown.
+func formatGrepContent(matches []grepMatch, opts grepOptions, offset, limit int) string {
+	if len(matches) == 0 {
+		return "No matches found"
+	}
+	total := 0
+	for _, m := range matches {
+		total += len(m.lines)
+	}
+	header := fmt.Sprintf("Found %d match%s in %d file%s", total, pluralizeES(total), len(matches), pluralize(len(matches)))
+	start, end := grepPage(total, offset, limit)
+	if start == end {
+		return fmt.Sprintf("%s, none left after offset %d", header, offset)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(header)
+	withContext := opts.before > 0 || opts.after > 0
+	first := true
+	index := 0
+	for _, m := range matches {
+		// the matches of this file within the page
+		var kept []int
+		for _, line := range m.lines {
+			if index >= start && index < end {
+				kept = append(kept, line)
+			}
+			index++
+		}
+		if len(kept) == 0 {
+			continue
+		}
+
+		matching := make(map[int]bool, len(m.lines))
+		for _, line := range m.lines {
+			matching[line] = true
+		}
+		var shown []int
+		last := 0
+		for _, line := range kept {
+			for n := max(line-opts.before, last+1); n <= line+opts.after; n++ {
+				if _, ok := m.text[n]; ok {
+					shown = append(shown, n)
+					last = n
+				}
+			}
+		}
+
+		for i, n := range shown {
+			if withContext && !first && (i == 0 || n != shown[i-1]+1) {
+				sb.WriteString("\n--")
+			}
+			first = false
+			sep := "-"
+			if matching[n] {
+				sep = ":"
+			}
+			fmt.Fprintf(&sb, "\n%s%s%d%s%s", m.path, sep, n, sep, truncateGrepLine(m.text[n]))
+		}
+	}
+	sb.WriteString(grepPageNote("matches", start, end, total))
+	return sb.String()
+}
+
+func pluralizeES(count int) string {
+	if count == 1 {
+		return ""
+	}
+	return "es"
+}
+
+func truncateGrepLine(line string) string {
+	if len(line) <= maxGrepLineLength {
+		return line
+	}
+	cut := maxGrepLineLength
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + "..."
+}
+
+// searchFiles returns the files matching the options, the most recently
+// modified first. truncated is set when the search stopped at
+// opts.maxFiles matching files.
+func searchFiles(ctx context.Context, rootPath string, opts grepOptions) ([]grepMatch, bool, error) {
 	// First try using ripgrep if available for better performance
-	matches, err := searchWithRipgrep(pattern, rootPath, include)
+	matches, truncated, err := searchWithRipgrep(ctx, rootPath, opts)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
 		// Fall back to manual regex search if ripgrep is not available
-		matches, err = searchFilesWithRegex(pattern, rootPath, include)
+		matches, truncated, err = searchFilesWithRegex(ctx, rootPath, opts)
 		if err != nil {
 			return nil, false, err
 		}
@@ -115,146 +378,355 @@ func searchFiles(pattern, rootPath, include string, limit int) ([]string, bool,
 
 	// Sort files by modification time (newest first)
 	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].modTime.After(matches[j].modTime)
+		if !matches[i].modTime.Equal(matches[j].modTime) {
+			return matches[i].modTime.After(matches[j].modTime)
+		}
+		return matches[i].path < matches[j].path
 	})
 
-	// Check if we need to truncate the results
-	truncated := len(matches) > limit
-	if truncated {
-		matches = matches[:limit]
-	}
+	return matches, truncated, nil
+}
+
+// rgText is a string in the ripgrep JSON output, text that is not valid UTF-8
+// is base64 encoded in bytes instead.
+type rgText struct {
+	Text  *string `json:"text"`
+	Bytes string  `json:"bytes"`
+}
 
-	// Extract just the paths
-	results := make([]string, len(matches))
-	for i, m := range matches {
-		results[i] = m.path
+func (t rgText) String() string {
+	if t.Text != nil {
+		return *t.Text
 	}
+	b, _ := base64.StdEncoding.DecodeString(t.Bytes)
+	return string(b)
+}
 
-	return results, truncated, nil
+type rgEvent struct {
+	Type string `json:"type"`
+	Data struct {
+		Path       rgText `json:"path"`
+		Lines      rgText `json:"lines"`
+		LineNumber int    `json:"line_number"`
+	} `json:"data"`
 }
 
-func searchWithRipgrep(pattern, path, include string) ([]grepMatch, error) {
+func searchWithRipgrep(ctx context.Context, path string, opts grepOptions) ([]grepMatch, bool, error) {
 	_, err := exec.LookPath("rg")
 	if err != nil {
-		return nil, fmt.Errorf("ripgrep not found: %w", err)
+		return nil, false, fmt.Errorf("ripgrep not found: %w", err)
 	}
 
-	args := []string{"-l", pattern}
-	if include != "" {
-		args = append(args, "--glob", include)
+	args := []string{"--json", "--max-filesize", fmt.Sprint(maxGrepFileSize)}
+	if opts.ignoreCase {
+		args = append(args, "--ignore-case")
+	}
+	if opts.multiline {
+		args = append(args, "--multiline", "--multiline-dotall")
 	}
-	args = append(args, path)
+	if opts.before > 0 {
+		args = append(args, "--before-context", fmt.Sprint(opts.before))
+	}
+	if opts.after > 0 {
+		args = append(args, "--after-context", fmt.Sprint(opts.after))
+	}
+	if opts.include != "" {
+		args = append(args, "--glob", opts.include)
+	}
+	args = append(args, "--regexp", opts.pattern, "--", path)
 
-	cmd := exec.Command("rg", args...)
-	output, err := cmd.Output()
+	cmd := exec.CommandContext(ctx, "rg", args...)
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-			// Exit code 1 means no matches, which isn't an error for our purposes
-			return []grepMatch{}, nil
-		}
-		return nil, err
+		return nil, false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, false, err
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	matches := make([]grepMatch, 0, len(lines))
-
-	for _, line := range lines {
-		if line == "" {
+	var matches []grepMatch
+	byPath := make(map[string]int)
+	matchedFiles := 0
+	truncated := false
+	ignore := newIgnoreMatcher(path)
+	decoder := json.NewDecoder(stdout)
+	var decodeErr error
+	for !truncated {
+		var event rgEvent
+		if err := decoder.Decode(&event); err != nil {
+			if !errors.Is(err, io.EOF) {
+				decodeErr = fmt.Errorf("invalid ripgrep output: %w", err)
+			}
+			break
+		}
+		if event.Type != "match" && event.Type != "context" {
 			continue
 		}
 
-		fileInfo, err := os.Stat(line)
-		if err != nil {
-			continue // Skip files we can't access
+		filePath := filepath.Clean(event.Data.Path.String())
+		i, ok := byPath[filePath]
+		if !ok {
+			// ripgrep does not know .termaiignore
+			if ignore.Ignored(filePath, false) {
+				continue
+			}
+			fileInfo, err := os.Stat(filePath)
+			if err != nil {
+				continue // Skip files we can't access
+			}
+			i = len(matches)
+			byPath[filePath] = i
+			matches = append(matches, grepMatch{
+				path:    filePath,
+				modTime: fileInfo.ModTime(),
+				text:    make(map[int]string),
+			})
+		}
+		m := &matches[i]
+		if event.Type == "match" && len(m.lines) == 0 {
+			if opts.maxFiles > 0 && matchedFiles == opts.maxFiles {
+				// stop ripgrep, there are more matching files than needed
+				truncated = true
+				cmd.Process.Kill()
+				break
+			}
+			matchedFiles++
 		}
 
-		matches = append(matches, grepMatch{
-			path:    line,
-			modTime: fileInfo.ModTime(),
-		})
+		// a multiline match holds several lines
+		text := strings.TrimSuffix(event.Data.Lines.String(), "\n")
+		for j, line := range strings.Split(text, "\n") {
+			n := event.Data.LineNumber + j
+			m.text[n] = strings.TrimSuffix(line, "\r")
+			if event.Type == "match" && (len(m.lines) == 0 || m.lines[len(m.lines)-1] < n) {
+				m.lines = append(m.lines, n)
+			}
+		}
+	}
+	if decodeErr != nil {
+		cmd.Process.Kill()
+	}
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return nil, false, ctx.Err()
+	}
+	if decodeErr != nil {
+		return nil, false, decodeErr
+	}
+	if err != nil && !truncated {
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+			return nil, false, err
+		}
+		// Exit code 1 means no matches, which isn't an error for our purposes
 	}
 
-	return matches, nil
+	// drop the files only holding context lines of a match that was not
+	// kept after the search was stopped
+	result := []grepMatch{}
+	for _, m := range matches {
+		if len(m.lines) > 0 {
+			result = append(result, m)
+		}
+	}
+	return result, truncated, nil
 }
 
-func searchFilesWithRegex(pattern, rootPath, include string) ([]grepMatch, error) {
-	matches := []grepMatch{}
-
+// searchFilesWithRegex searches the files without ripgrep. The tree is walked
+// in one goroutine, which also applies the ignore rules, while a pool of
+// workers reads and matches the files.
+func searchFilesWithRegex(ctx context.Context, rootPath string, opts grepOptions) ([]grepMatch, bool, error) {
+	flags := ""
+	if opts.ignoreCase {
+		flags += "i"
+	}
+	if opts.multiline {
+		flags += "ms"
+	}
+	pattern := opts.pattern
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
 	regex, err := regexp.Compile(pattern)
 	if err != nil {
-		return nil, fmt.Errorf("invalid regex pattern: %w", err)
+		return nil, false, fmt.Errorf("invalid regex pattern: %w", err)
+	}
+	// prefilter rejects a file with a single pass over its content, a line
+	// matching the pattern is also a match of the whole content when ^ and
+	// $ match at line boundaries
+	prefilter := regex
+	if !opts.multiline {
+		prefilter = regexp.MustCompile("(?m" + flags + ")" + opts.pattern)
 	}
 
 	var includePattern *regexp.Regexp
-	if include != "" {
-		regexPattern := globToRegex(include)
-		includePattern, err = regexp.Compile(regexPattern)
+	if opts.include != "" {
+		includePattern, err = regexp.Compile("^" + globToRegex(opts.include) + "$")
 		if err != nil {
-			return nil, fmt.Errorf("invalid include pattern: %w", err)
+			return nil, false, fmt.Errorf("invalid include pattern: %w", err)
 		}
 	}
 
-	err = filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+	// searchCtx is also cancelled once enough files matched
+	searchCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	type candidate struct {
+		path    string
+		modTime time.Time
+	}
+	candidates := make(chan candidate, 256)
+	var (
+		mu        sync.Mutex
+		matches   = []grepMatch{}
+		truncated bool
+		wg        sync.WaitGroup
+	)
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range candidates {
+				if searchCtx.Err() != nil {
+					continue // drain the channel
+				}
+				lines, text, ok := grepFile(c.path, regex, prefilter, opts)
+				if !ok {
+					continue
+				}
+
+				mu.Lock()
+				if opts.maxFiles > 0 && len(matches) >= opts.maxFiles {
+					truncated = true
+					stop()
+				} else {
+					matches = append(matches, grepMatch{
+						path:    c.path,
+						modTime: c.modTime,
+						lines:   lines,
+						text:    text,
+					})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	ignore := newIgnoreMatcher(rootPath)
+	err = filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
+		if searchCtx.Err() != nil {
+			return filepath.SkipAll
+		}
 		if err != nil {
 			return nil // Skip errors
 		}
 
-		if info.IsDir() {
-			return nil // Skip directories
-		}
-
-		// Skip hidden files
-		if skipHidden(path) {
+		// Skip hidden and ignored files and directories, like ripgrep
+		if path != rootPath && (skipHidden(path) || ignore.match(path, d.IsDir())) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
 			return nil
 		}
-
-		// Check include pattern if provided
-		if includePattern != nil && !includePattern.MatchString(path) {
+		if d.IsDir() {
 			return nil
 		}
 
-		// Check file contents for the pattern
-		match, err := fileContainsPattern(path, regex)
-		if err != nil {
-			return nil // Skip files we can't read
-		}
-
-		if match {
-			matches = append(matches, grepMatch{
-				path:    path,
-				modTime: info.ModTime(),
-			})
-
-			// Check if we've hit the limit (collect double for sorting)
-			if len(matches) >= 200 {
-				return filepath.SkipAll
+		// Check include pattern if provided, against the name like ripgrep
+		// does for globs without a slash
+		if includePattern != nil {
+			name := filepath.Base(path)
+			if strings.Contains(opts.include, "/") {
+				name, _ = filepath.Rel(rootPath, path)
+			}
+			if !includePattern.MatchString(name) {
+				return nil
 			}
 		}
 
+		info, err := d.Info()
+		if err != nil || info.Size() > maxGrepFileSize {
+			return nil // Skip files we can't access and large files
+		}
+		select {
+		case candidates <- candidate{path: path, modTime: info.ModTime()}:
+		case <-searchCtx.Done():
+			return filepath.SkipAll
+		}
 		return nil
 	})
+	close(candidates)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, false, ctx.Err()
+	}
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-
-	return matches, nil
+	return matches, truncated, nil
 }
 
-func fileContainsPattern(filePath string, pattern *regexp.Regexp) (bool, error) {
-	file, err := os.Open(filePath)
+// grepFile returns the matching lines of a file, ok is false when nothing
+// matched or the file could not be read or is binary.
+func grepFile(path string, regex, prefilter *regexp.Regexp, opts grepOptions) ([]int, map[int]string, bool) {
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return false, err
+		return nil, nil, false // Skip files we can't read
+	}
+	if bytes.IndexByte(content[:min(len(content), binaryCheckSize)], 0) >= 0 {
+		return nil, nil, false // Skip binary files
+	}
+	if !prefilter.Match(content) {
+		return nil, nil, false
+	}
+	lines, text := matchFileContent(string(content), regex, opts)
+	return lines, text, len(lines) > 0
+}
+
+// matchFileContent returns the numbers of the lines matching the pattern and
+// the text of these lines and of their context lines.
+func matchFileContent(content string, regex *regexp.Regexp, opts grepOptions) ([]int, map[int]string) {
+	lines := strings.Split(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		if pattern.MatchString(scanner.Text()) {
-			return true, nil
+	var matched []int
+	if opts.multiline {
+		// a match marks every line it spans
+		starts := make([]int, len(lines))
+		offset := 0
+		for i, line := range lines {
+			starts[i] = offset
+			offset += len(line) + 1
+		}
+		lineAt := func(offset int) int {
+			return sort.Search(len(starts), func(i int) bool { return starts[i] > offset }) - 1
+		}
+		last := -1
+		for _, loc := range regex.FindAllStringIndex(content, -1) {
+			first := lineAt(loc[0])
+			end := lineAt(max(loc[0], loc[1]-1))
+			for i := max(first, last+1); i <= end && i < len(lines); i++ {
+				matched = append(matched, i+1)
+				last = i
+			}
+		}
+	} else {
+		for i, line := range lines {
+			if regex.MatchString(line) {
+				matched = append(matched, i+1)
+			}
 		}
 	}
 
-	return false, scanner.Err()
+	text := make(map[int]string)
+	for _, n := range matched {
+		for c := max(1, n-opts.before); c <= min(len(lines), n+opts.after); c++ {
+			text[c] = strings.TrimSuffix(lines[c-1], "\r")
+		}
+	}
+	return matched, text
 }
 
 func globToRegex(glob string) string {
@@ -272,17 +744,25 @@ func globToRegex(glob string) string {
 }
 
 func grepDescription() string {
-	return `Fast content search tool that finds files containing specific text or patterns, returning matching file paths sorted by modification time (newest first).
+	return `Fast content search tool that finds files containing specific text or patterns. It returns the matching file paths sorted by modification time (newest first), the matching lines with their line numbers, or the number of matches per file.
 
 WHEN TO USE THIS TOOL:
 - Use when you need to find files containing specific text or patterns
 - Great for searching code bases for function names, variable declarations, or error messages
 - Useful for finding all files that use a particular API or pattern
+- Use the content output mode to see the matching lines directly instead of viewing every file
 
 HOW TO USE:
 - Provide a regex pattern to search for within file contents
 - Optionally specify a starting directory (defaults to current working directory)
 - Optionally provide an include pattern to filter which files to search
+- Choose the output with output_mode:
+  - "files_with_matches" (default) lists the matching files
+  - "content" shows the matching lines as "path:line:text"
+  - "count" shows the number of matching lines per file as "path:count"
+- In content mode, before, after and context show the lines around each match as "path-line-text", separated groups of lines are divided by "--"
+- Set ignore_case to match without case sensitivity
+- Set multiline to let the pattern span several lines, '.' then matches newlines too
 - Results are sorted with most recently modified files first
 
 REGEX PATTERN SYNTAX:
@@ -290,6 +770,7 @@ REGEX PATTERN SYNTAX:
 - 'function' searches for the literal text "function"
 - 'log\..*Error' finds text starting with "log." and ending with "Error"
 - 'import\s+.*\s+from' finds import statements in JavaScript/TypeScript
+- 'struct \{[^}]*Name' with multiline finds structs with a Name field
 
 COMMON INCLUDE PATTERN EXAMPLES:
 - '*.js' - Only search JavaScript files
@@ -297,15 +778,19 @@ COMMON INCLUDE PATTERN EXAMPLES:
 - '*.go' - Only search Go files
 
 LIMITATIONS:
-- Results are limited to 100 files (newest first)
+- Results are limited to 100 files, or 100 matching lines in content mode, use limit and offset to page through more
+- Long lines are cut in the content output
 - Performance depends on the number of files being searched
-- Very large binary files may be skipped
+- Binary files and files larger than 10MB are skipped
+- The search stops after 1000 matching files, the results are then incomplete
 - Hidden files (starting with '.') are skipped
+- Files ignored by .gitignore files, the global git excludes or .termaiignore are skipped
 
 TIPS:
 - For faster, more targeted searches, first use Glob to find relevant files, then use Grep
+- Use the content mode with a few lines of context to understand matches without viewing the whole files
 - When doing iterative exploration that may require multiple rounds of searching, consider using the Agent tool instead
-- Always check if results are truncated and refine your search pattern if needed`
+- Always check if results are truncated and refine your search pattern or use offset if needed`
 }
 
 func NewGrepTool() BaseTool {
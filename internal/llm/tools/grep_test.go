@@ -0,0 +1,458 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const grepTestGo = `package a
+
+func Hello() string {
+	return "hello"
+}
+
+func World() string {
+	return "world"
+}
+`
+
+// setupGrepDir creates files to search, a.go is the most recently modified,
+// then b.txt, then sub/c.go.
+func setupGrepDir(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.go":         grepTestGo,
+		"b.txt":        "Hello there\nnothing\nhello again\n",
+		"sub/c.go":     "package sub\n\n// HELLO shouting\n",
+		".hidden/d.go": "hello hidden\n",
+		".e.txt":       "hello hidden file\n",
+		"bin.dat":      "hello\x00binary",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	now := time.Now()
+	for i, name := range []string{"a.go", "b.txt", "sub/c.go"} {
+		modTime := now.Add(-time.Duration(i) * time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, name), modTime, modTime))
+	}
+	return dir
+}
+
+func runGrep(t *testing.T, params GrepParams) ToolResponse {
+	paramsJSON, err := json.Marshal(params)
+	require.NoError(t, err)
+
+	response, err := NewGrepTool().Run(context.Background(), ToolCall{
+		Name:  GrepToolName,
+		Input: string(paramsJSON),
+	})
+	require.NoError(t, err)
+	return response
+}
+
+func TestGrepTool_Info(t *testing.T) {
+	tool := NewGrepTool()
+	info := tool.Info()
+
+	assert.Equal(t, GrepToolName, info.Name)
+	assert.NotEmpty(t, info.Description)
+	for _, param := range []string{"pattern", "path", "include", "output_mode", "before", "after", "context", "ignore_case", "multiline", "offset", "limit"} {
+		assert.Contains(t, info.Parameters, param)
+	}
+	assert.Contains(t, info.Required, "pattern")
+}
+
+func TestGrepTool_Run(t *testing.T) {
+	dir := setupGrepDir(t)
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.txt")
+	c := filepath.Join(dir, "sub", "c.go")
+
+	tests := []struct {
+		name     string
+		params   GrepParams
+		expected string
+	}{
+		{
+			name:     "files",
+			params:   GrepParams{Pattern: "hello"},
+			expected: fmt.Sprintf("Found 2 files\n%s\n%s", a, b),
+		},
+		{
+			name:     "files ignoring case",
+			params:   GrepParams{Pattern: "hello", IgnoreCase: true},
+			expected: fmt.Sprintf("Found 3 files\n%s\n%s\n%s", a, b, c),
+		},
+		{
+			name:     "files with include",
+			params:   GrepParams{Pattern: "hello", IgnoreCase: true, Include: "*.go"},
+			expected: fmt.Sprintf("Found 2 files\n%s\n%s", a, c),
+		},
+		{
+			name:     "no files",
+			params:   GrepParams{Pattern: "missing"},
+			expected: "No files found",
+		},
+		{
+			name:     "content",
+			params:   GrepParams{Pattern: "func", OutputMode: GrepOutputContent},
+			expected: fmt.Sprintf("Found 2 matches in 1 file\n%[1]s:3:func Hello() string {\n%[1]s:7:func World() string {", a),
+		},
+		{
+			name:     "content in several files",
+			params:   GrepParams{Pattern: "hello", OutputMode: GrepOutputContent},
+			expected: fmt.Sprintf("Found 2 matches in 2 files\n%s:4:\treturn \"hello\"\n%s:3:hello again", a, b),
+		},
+		{
+			name:   "content with context",
+			params: GrepParams{Pattern: "return", OutputMode: GrepOutputContent, Context: 1},
+			expected: fmt.Sprintf("Found 2 matches in 1 file\n"+
+				"%[1]s-3-func Hello() string {\n%[1]s:4:\treturn \"hello\"\n%[1]s-5-}\n--\n"+
+				"%[1]s-7-func World() string {\n%[1]s:8:\treturn \"world\"\n%[1]s-9-}", a),
+		},
+		{
+			name:   "content with overlapping context",
+			params: GrepParams{Pattern: "return", OutputMode: GrepOutputContent, After: 3},
+			expected: fmt.Sprintf("Found 2 matches in 1 file\n"+
+				"%[1]s:4:\treturn \"hello\"\n%[1]s-5-}\n%[1]s-6-\n%[1]s-7-func World() string {\n"+
+				"%[1]s:8:\treturn \"world\"\n%[1]s-9-}", a),
+		},
+		{
+			name:   "content with context in several files",
+			params: GrepParams{Pattern: "^hello|^func World", OutputMode: GrepOutputContent, Before: 1},
+			expected: fmt.Sprintf("Found 2 matches in 2 files\n"+
+				"%[1]s-6-\n%[1]s:7:func World() string {\n--\n"+
+				"%[2]s-2-nothing\n%[2]s:3:hello again", a, b),
+		},
+		{
+			name:     "context is ignored outside content mode",
+			params:   GrepParams{Pattern: "func", Context: 2},
+			expected: fmt.Sprintf("Found 1 file\n%s", a),
+		},
+		{
+			name:     "no matches",
+			params:   GrepParams{Pattern: "missing", OutputMode: GrepOutputContent},
+			expected: "No matches found",
+		},
+		{
+			name:     "count",
+			params:   GrepParams{Pattern: "hello", IgnoreCase: true, OutputMode: GrepOutputCount},
+			expected: fmt.Sprintf("Found 5 matches in 3 files\n%s:2\n%s:2\n%s:1", a, b, c),
+		},
+		{
+			name:     "multiline",
+			params:   GrepParams{Pattern: `Hello\(\) string \{\n\treturn`, Multiline: true, OutputMode: GrepOutputContent},
+			expected: fmt.Sprintf("Found 2 matches in 1 file\n%[1]s:3:func Hello() string {\n%[1]s:4:\treturn \"hello\"", a),
+		},
+		{
+			name:     "multiline dot matches newlines",
+			params:   GrepParams{Pattern: `Hello.*"hello"`, Multiline: true, OutputMode: GrepOutputCount},
+			expected: fmt.Sprintf("Found 2 matches in 1 file\n%s:2", a),
+		},
+		{
+			name:     "without multiline patterns match single lines",
+			params:   GrepParams{Pattern: `Hello.*"hello"`},
+			expected: "No files found",
+		},
+		{
+			name:   "limit",
+			params: GrepParams{Pattern: "return", OutputMode: GrepOutputContent, Limit: 1},
+			expected: fmt.Sprintf("Found 2 matches in 1 file\n%s:4:\treturn \"hello\"\n\n"+
+				"(Results are truncated, showing matches 1-1 of 2. Use offset 1 to see more, or a more specific path or pattern.)", a),
+		},
+		{
+			name:     "offset",
+			params:   GrepParams{Pattern: "return", OutputMode: GrepOutputContent, Offset: 1},
+			expected: fmt.Sprintf("Found 2 matches in 1 file\n%s:8:\treturn \"world\"\n\n(Showing matches 2-2 of 2.)", a),
+		},
+		{
+			name:   "offset and limit with files",
+			params: GrepParams{Pattern: "hello", IgnoreCase: true, Offset: 1, Limit: 1},
+			expected: fmt.Sprintf("Found 3 files\n%s\n\n"+
+				"(Results are truncated, showing files 2-2 of 3. Use offset 2 to see more, or a more specific path or pattern.)", b),
+		},
+		{
+			name:     "offset past the results",
+			params:   GrepParams{Pattern: "hello", Offset: 5},
+			expected: "Found 2 files, none left after offset 5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params.Path = dir
+			response := runGrep(t, tt.params)
+			assert.False(t, response.IsError, response.Content)
+			assert.Equal(t, tt.expected, response.Content)
+		})
+	}
+
+	t.Run("cuts long lines", func(t *testing.T) {
+		longDir := t.TempDir()
+		line := "needle" + strings.Repeat("x", 2*maxGrepLineLength)
+		require.NoError(t, os.WriteFile(filepath.Join(longDir, "long.js"), []byte(line), 0644))
+
+		response := runGrep(t, GrepParams{Pattern: "needle", Path: longDir, OutputMode: GrepOutputContent})
+		assert.Contains(t, response.Content, line[:maxGrepLineLength]+"...")
+		assert.NotContains(t, response.Content, line[:maxGrepLineLength+1])
+	})
+
+	t.Run("rejects invalid parameters", func(t *testing.T) {
+		response := runGrep(t, GrepParams{Pattern: "hello", Path: dir, OutputMode: "lines"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "invalid output_mode")
+
+		response = runGrep(t, GrepParams{Pattern: "hello", Path: dir, Context: -1})
+		assert.True(t, response.IsError)
+
+		response = runGrep(t, GrepParams{Path: dir})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "pattern is required")
+	})
+}
+
+// TestGrep_RipgrepFallbackParity checks ripgrep and the Go fallback find the
+// same lines.
+func TestGrep_RipgrepFallbackParity(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("ripgrep is not installed")
+	}
+	dir := setupGrepDir(t)
+
+	tests := []grepOptions{
+		{pattern: "hello"},
+		{pattern: "hello", ignoreCase: true},
+		{pattern: "hello", ignoreCase: true, include: "*.go"},
+		{pattern: "return", before: 1, after: 2},
+		{pattern: `Hello.*"hello"`, multiline: true},
+		{pattern: `\}\n\nfunc`, multiline: true, before: 1, after: 1},
+		{pattern: "missing"},
+	}
+	for _, opts := range tests {
+		t.Run(fmt.Sprintf("%+v", opts), func(t *testing.T) {
+			rg, _, err := searchWithRipgrep(context.Background(), dir, opts)
+			require.NoError(t, err)
+			fallback, _, err := searchFilesWithRegex(context.Background(), dir, opts)
+			require.NoError(t, err)
+
+			sortGrepMatches(rg)
+			sortGrepMatches(fallback)
+			assert.Equal(t, fallback, rg)
+		})
+	}
+}
+
+func sortGrepMatches(matches []grepMatch) {
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].path < matches[j].path
+	})
+}
+
+// TestSearchWithRipgrep_Output checks the ripgrep JSON output is read the same
+// way as the fallback results, with a fake rg printing the output of
+// "rg --json --multiline --context 1".
+func TestSearchWithRipgrep_Output(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	require.NoError(t, os.WriteFile(file, []byte(grepTestGo), 0644))
+
+	events := []string{
+		`{"type":"begin","data":{"path":{"text":"%[1]s"}}}`,
+		`{"type":"context","data":{"path":{"text":"%[1]s"},"lines":{"text":"\n"},"line_number":2,"absolute_offset":10,"submatches":[]}}`,
+		`{"type":"match","data":{"path":{"text":"%[1]s"},"lines":{"text":"func Hello() string {\n\treturn \"hello\"\n"},"line_number":3,"absolute_offset":11,"submatches":[{"match":{"text":"Hello() string {\n\treturn"},"start":5,"end":26}]}}`,
+		`{"type":"context","data":{"path":{"text":"%[1]s"},"lines":{"bytes":"fQo="},"line_number":5,"absolute_offset":49,"submatches":[]}}`,
+		`{"type":"end","data":{"path":{"text":"%[1]s"},"binary_offset":null,"stats":{}}}`,
+		`{"data":{"elapsed_total":{"human":"0.01s","nanos":1,"secs":0},"stats":{}},"type":"summary"}`,
+	}
+	output := fmt.Sprintf(strings.Join(events, "\n"), file)
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "output.json"), []byte(output+"\n"), 0644))
+	script := fmt.Sprintf("#!/bin/sh\ncat %q\n", filepath.Join(binDir, "output.json"))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "rg"), []byte(script), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	opts := grepOptions{pattern: `Hello\(\) string \{\n\treturn`, multiline: true, before: 1, after: 1}
+	rg, _, err := searchWithRipgrep(context.Background(), dir, opts)
+	require.NoError(t, err)
+	fallback, _, err := searchFilesWithRegex(context.Background(), dir, opts)
+	require.NoError(t, err)
+
+	require.Len(t, rg, 1)
+	assert.Equal(t, []int{3, 4}, rg[0].lines)
+	assert.Equal(t, map[int]string{2: "", 3: "func Hello() string {", 4: "\treturn \"hello\"", 5: "}"}, rg[0].text)
+	assert.Equal(t, fallback, rg)
+}
+
+// searchFilesSequential is the search without ripgrep as it was before the
+// worker pool, files are scanned line by line one after the other. It is the
+// reference for the parallel search results and benchmark.
+func searchFilesSequential(rootPath string, opts grepOptions) ([]grepMatch, error) {
+	regex, err := regexp.Compile(opts.pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches := []grepMatch{}
+	ignore := newIgnoreMatcher(rootPath)
+	err = filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path != rootPath && (skipHidden(path) || ignore.match(path, d.IsDir())) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return nil
+		}
+		defer file.Close()
+		found := false
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if regex.MatchString(scanner.Text()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		lines, text := matchFileContent(string(content), regex, opts)
+		matches = append(matches, grepMatch{path: path, modTime: info.ModTime(), lines: lines, text: text})
+		return nil
+	})
+	return matches, err
+}
+
+// setupGrepTree creates a tree of Go like files, one file out of ten
+// contains "needle".
+func setupGrepTree(tb testing.TB, dirs, filesPerDir int) string {
+	root := tb.TempDir()
+	var body strings.Builder
+	for i := range 200 {
+		fmt.Fprintf(&body, "func function%d(value int) int {\n\treturn value * %d // some comment\n}\n", i, i)
+	}
+	for d := range dirs {
+		dir := filepath.Join(root, fmt.Sprintf("pkg%d", d))
+		require.NoError(tb, os.MkdirAll(dir, 0755))
+		for f := range filesPerDir {
+			content := body.String()
+			if (d*filesPerDir+f)%10 == 0 {
+				content += "// needle\n"
+			}
+			require.NoError(tb, os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d.go", f)), []byte(content), 0644))
+		}
+	}
+	return root
+}
+
+func TestSearchFilesWithRegex(t *testing.T) {
+	root := setupGrepTree(t, 10, 20)
+
+	t.Run("finds the same files as a sequential search", func(t *testing.T) {
+		for _, opts := range []grepOptions{
+			{pattern: "needle"},
+			{pattern: `return value \* 1[0-9]\b`, before: 1, after: 1},
+			{pattern: `^// needle$`},
+			{pattern: "missing"},
+		} {
+			expected, err := searchFilesSequential(root, opts)
+			require.NoError(t, err)
+			matches, truncated, err := searchFilesWithRegex(context.Background(), root, opts)
+			require.NoError(t, err)
+			assert.False(t, truncated)
+
+			sortGrepMatches(expected)
+			sortGrepMatches(matches)
+			assert.Equal(t, expected, matches)
+		}
+	})
+
+	t.Run("stops at the file limit", func(t *testing.T) {
+		matches, truncated, err := searchFilesWithRegex(context.Background(), root, grepOptions{pattern: "needle", maxFiles: 5})
+		require.NoError(t, err)
+		assert.True(t, truncated)
+		assert.Len(t, matches, 5)
+
+		matches, truncated, err = searchFilesWithRegex(context.Background(), root, grepOptions{pattern: "needle", maxFiles: 20})
+		require.NoError(t, err)
+		assert.False(t, truncated)
+		assert.Len(t, matches, 20)
+	})
+
+	t.Run("stops when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, _, err := searchFilesWithRegex(ctx, root, grepOptions{pattern: "needle"})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		response, err := NewGrepTool().Run(ctx, ToolCall{Input: fmt.Sprintf(`{"pattern": "needle", "path": %q}`, root)})
+		require.NoError(t, err)
+		assert.True(t, response.IsError)
+	})
+
+	t.Run("skips large files", func(t *testing.T) {
+		dir := t.TempDir()
+		large := bytes.Repeat([]byte("needle\n"), maxGrepFileSize/7+1)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "large.txt"), large, 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "small.txt"), []byte("needle\n"), 0644))
+
+		matches, _, err := searchFilesWithRegex(context.Background(), dir, grepOptions{pattern: "needle"})
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		assert.Equal(t, filepath.Join(dir, "small.txt"), matches[0].path)
+	})
+}
+
+func BenchmarkGrepFallback(b *testing.B) {
+	root := setupGrepTree(b, 50, 40)
+	opts := grepOptions{pattern: "needle"}
+
+	b.Run("sequential", func(b *testing.B) {
+		for range b.N {
+			if _, err := searchFilesSequential(root, opts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for range b.N {
+			if _, _, err := searchFilesWithRegex(context.Background(), root, opts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
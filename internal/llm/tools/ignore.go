@@ -0,0 +1,279 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+)
+
+// ignoreFileNames are the files holding ignore patterns, read in every
+// directory. Patterns of .termaiignore come last and win over .gitignore.
+var ignoreFileNames = []string{".gitignore", ".termaiignore"}
+
+// sensitiveFilePatterns match the names of files that usually hold secrets.
+// The file tools deny access to them when they are ignored, a .env file in
+// .gitignore is private while a committed .env.example is not.
+var sensitiveFilePatterns = []string{
+	".env", ".env.*", "*.pem", "*.key", "*.p12", "*.pfx", "*.jks", "*.keystore",
+	"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519",
+	".netrc", ".npmrc", ".pypirc", "credentials", "credentials.*", "*.tfstate", "*.tfvars",
+}
+
+type ignoreRule struct {
+	// pattern is a doublestar pattern matched against the path relative to
+	// the directory declaring the rule
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreMatcher tells which paths the file tools skip. It follows the
+// gitignore rules: the global git excludes file, .git/info/exclude and the
+// .gitignore files from the repository root down to the path, then the
+// .termaiignore files. Directories outside a repository use the rules from
+// the working directory, or from the base directory given to the matcher.
+// Rules are loaded once per directory, a matcher is meant to be used for a
+// single tool call.
+type ignoreMatcher struct {
+	base string
+
+	mu     sync.Mutex
+	rules  map[string][]ignoreRule
+	roots  map[string]string
+	global []ignoreRule
+}
+
+func newIgnoreMatcher(base string) *ignoreMatcher {
+	if base != "" {
+		base, _ = filepath.Abs(base)
+	}
+	return &ignoreMatcher{
+		base:   base,
+		rules:  make(map[string][]ignoreRule),
+		roots:  make(map[string]string),
+		global: readIgnoreFile(globalExcludesFile()),
+	}
+}
+
+// globalExcludesFile returns the path of the global git excludes file.
+func globalExcludesFile() string {
+	if out, err := exec.Command("git", "config", "--get", "core.excludesFile").Output(); err == nil {
+		path := strings.TrimSpace(string(out))
+		if strings.HasPrefix(path, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, path[2:])
+			}
+		}
+		return path
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "git", "ignore")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "git", "ignore")
+}
+
+func readIgnoreFile(path string) []ignoreRule {
+	if path == "" {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return parseIgnorePatterns(string(content))
+}
+
+// parseIgnorePatterns parses patterns in the gitignore format.
+func parseIgnorePatterns(content string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		// trailing spaces are ignored unless escaped
+		for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+			line = line[:len(line)-1]
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		// a pattern with a slash, other than at the end, is relative to the
+		// directory of the ignore file, others match at any depth
+		if strings.Contains(line, "/") {
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+		// braces are not special in gitignore patterns
+		line = strings.NewReplacer("{", `\{`, "}", `\}`).Replace(line)
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// root returns the directory the rules for dir start at.
+func (m *ignoreMatcher) root(dir string) string {
+	if root, ok := m.roots[dir]; ok {
+		return root
+	}
+	root := ""
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Lstat(filepath.Join(d, ".git")); err == nil {
+			root = d
+			break
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	if root == "" {
+		root = dir
+		for _, candidate := range []string{config.WorkingDirectory(), m.base} {
+			if candidate != "" && isWithin(candidate, dir) {
+				root = candidate
+				break
+			}
+		}
+	}
+	m.roots[dir] = root
+	return root
+}
+
+// dirRules returns the rules declared in dir, root is the directory the
+// global rules apply to.
+func (m *ignoreMatcher) dirRules(dir, root string) []ignoreRule {
+	if rules, ok := m.rules[dir]; ok {
+		return rules
+	}
+	var rules []ignoreRule
+	if dir == root {
+		rules = append(rules, m.global...)
+		rules = append(rules, readIgnoreFile(filepath.Join(dir, ".git", "info", "exclude"))...)
+	}
+	for _, name := range ignoreFileNames {
+		rules = append(rules, readIgnoreFile(filepath.Join(dir, name))...)
+	}
+	m.rules[dir] = rules
+	return rules
+}
+
+// match reports if path is ignored by the rules, without looking at its
+// parent directories. Walks use it on every entry and skip ignored
+// directories.
+func (m *ignoreMatcher) match(path string, isDir bool) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	root := m.root(parent)
+	var dirs []string
+	for d := parent; ; d = filepath.Dir(d) {
+		dirs = append(dirs, d)
+		if d == root || filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	ignored := false
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rules := m.dirRules(dirs[i], root)
+		if len(rules) == 0 {
+			continue
+		}
+		rel, err := filepath.Rel(dirs[i], path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, rule := range rules {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if ok, _ := doublestar.Match(rule.pattern, rel); ok {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// Ignored reports if path or one of its parent directories below the base
+// directory is ignored.
+func (m *ignoreMatcher) Ignored(path string, isDir bool) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	if m.match(path, isDir) {
+		return true
+	}
+	for d := filepath.Dir(path); d != filepath.Dir(d); d = filepath.Dir(d) {
+		if d == m.base || (m.base != "" && !isWithin(m.base, d)) {
+			break
+		}
+		if m.match(d, true) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func isSensitiveFile(path string) bool {
+	base := filepath.Base(path)
+	for _, pattern := range sensitiveFilePatterns {
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFileAccess denies view, edit and write access to ignored files that
+// look like they hold secrets.
+func checkFileAccess(path string) error {
+	if !isSensitiveFile(path) {
+		return nil
+	}
+	if newIgnoreMatcher(config.WorkingDirectory()).Ignored(path, false) {
+		return fmt.Errorf("access to %s is denied: the file is ignored and may contain secrets", path)
+	}
+	return nil
+}
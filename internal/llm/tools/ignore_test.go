@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupIgnoreRepo creates a repository with nested ignore files. The global
+// git config is replaced so the excludes file of the machine is not used.
+func setupIgnoreRepo(t *testing.T) string {
+	home := t.TempDir()
+	globalExcludes := filepath.Join(home, "global-ignore")
+	require.NoError(t, os.WriteFile(globalExcludes, []byte("*.swp\n"), 0644))
+	gitConfig := filepath.Join(home, "gitconfig")
+	require.NoError(t, os.WriteFile(gitConfig, []byte("[core]\n\texcludesFile = "+globalExcludes+"\n"), 0644))
+	t.Setenv("GIT_CONFIG_GLOBAL", gitConfig)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	dir := t.TempDir()
+	files := map[string]string{
+		".git/info/exclude":       "local-only.txt\n",
+		".gitignore":              "# build outputs\nnode_modules/\n/dist\n*.log\n!keep.log\n.env\nbuild\n",
+		".termaiignore":           "fixtures/large/\nsecret-notes.md\n",
+		"app/.gitignore":          "generated/\n*.tmp\n!important.tmp\n",
+		"main.go":                 "package main\n",
+		"debug.log":               "log\n",
+		"keep.log":                "log\n",
+		"local-only.txt":          "local\n",
+		"notes.swp":               "swap\n",
+		"secret-notes.md":         "notes\n",
+		".env":                    "TOKEN=secret\n",
+		".env.example":            "TOKEN=\n",
+		"node_modules/x/index.js": "module.exports = {}\n",
+		"dist/bundle.js":          "bundle\n",
+		"app/dist/keep.js":        "not at the root\n",
+		"app/main.go":             "package app\n",
+		"app/cache.tmp":           "tmp\n",
+		"app/important.tmp":       "tmp\n",
+		"app/generated/code.go":   "package generated\n",
+		"app/build":               "a file named build\n",
+		"fixtures/large/a.json":   "{}\n",
+		"fixtures/small/a.json":   "{}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestParseIgnorePatterns(t *testing.T) {
+	rules := parseIgnorePatterns("# comment\n\n*.log  \n!keep.log\n/dist\nbuild/\na/**/b\n\\#file\n\\!bang\n{x}\n")
+	assert.Equal(t, []ignoreRule{
+		{pattern: "**/*.log"},
+		{pattern: "**/keep.log", negate: true},
+		{pattern: "dist"},
+		{pattern: "**/build", dirOnly: true},
+		{pattern: "a/**/b"},
+		{pattern: "**/#file"},
+		{pattern: "**/!bang"},
+		{pattern: `**/\{x\}`},
+	}, rules)
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	dir := setupIgnoreRepo(t)
+	matcher := newIgnoreMatcher(dir)
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{path: "main.go"},
+		{path: "debug.log", ignored: true},
+		{path: "keep.log"},
+		{path: "local-only.txt", ignored: true},
+		{path: "notes.swp", ignored: true},
+		{path: "secret-notes.md", ignored: true},
+		{path: ".env", ignored: true},
+		{path: ".env.example"},
+		{path: "node_modules", isDir: true, ignored: true},
+		{path: "node_modules/x/index.js", ignored: true},
+		{path: "dist", isDir: true, ignored: true},
+		{path: "dist/bundle.js", ignored: true},
+		{path: "app/dist", isDir: true},
+		{path: "app/dist/keep.js"},
+		{path: "app/main.go"},
+		{path: "app/cache.tmp", ignored: true},
+		{path: "app/important.tmp"},
+		{path: "cache.tmp"},
+		{path: "app/generated", isDir: true, ignored: true},
+		{path: "app/generated/code.go", ignored: true},
+		{path: "app/build", ignored: true},
+		{path: "fixtures/large", isDir: true, ignored: true},
+		{path: "fixtures/large/a.json", ignored: true},
+		{path: "fixtures/small/a.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.ignored, matcher.Ignored(filepath.Join(dir, tt.path), tt.isDir))
+		})
+	}
+
+	t.Run("directory only patterns do not match files", func(t *testing.T) {
+		assert.False(t, matcher.match(filepath.Join(dir, "node_modules"), false))
+	})
+
+	t.Run("parents above the base are not checked", func(t *testing.T) {
+		inside := newIgnoreMatcher(filepath.Join(dir, "node_modules"))
+		assert.False(t, inside.Ignored(filepath.Join(dir, "node_modules", "x", "index.js"), false))
+	})
+}
+
+func TestIgnoredFilesInTools(t *testing.T) {
+	dir := setupIgnoreRepo(t)
+
+	run := func(t *testing.T, tool BaseTool, params any) ToolResponse {
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		response, err := tool.Run(context.Background(), ToolCall{Input: string(input)})
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("ls", func(t *testing.T) {
+		response := run(t, NewLsTool(), LSParams{Path: dir})
+		assert.Contains(t, response.Content, "main.go")
+		assert.Contains(t, response.Content, "keep.log")
+		assert.Contains(t, response.Content, "important.tmp")
+		for _, ignored := range []string{"debug.log", "node_modules", "bundle.js", "generated", "cache.tmp", "secret-notes.md", "large"} {
+			assert.NotContains(t, response.Content, ignored)
+		}
+	})
+
+	t.Run("glob", func(t *testing.T) {
+		response := run(t, NewGlobTool(), GlobParams{Pattern: "**/*.js", Path: dir})
+		assert.Equal(t, filepath.Join(dir, "app", "dist", "keep.js"), response.Content)
+	})
+
+	t.Run("grep", func(t *testing.T) {
+		response := run(t, NewGrepTool(), GrepParams{Pattern: "package|module|bundle", Path: dir})
+		lines := strings.Split(response.Content, "\n")
+		assert.Equal(t, "Found 2 files", lines[0])
+		assert.ElementsMatch(t, []string{filepath.Join(dir, "main.go"), filepath.Join(dir, "app", "main.go")}, lines[1:])
+
+		fallback, _, err := searchFilesWithRegex(context.Background(), dir, grepOptions{pattern: "{}"})
+		require.NoError(t, err)
+		require.Len(t, fallback, 1)
+		assert.Equal(t, filepath.Join(dir, "fixtures", "small", "a.json"), fallback[0].path)
+	})
+
+	t.Run("view denies ignored secrets", func(t *testing.T) {
+		response := run(t, NewViewTool(nil, newMockFileRecordService()), ViewParams{FilePath: filepath.Join(dir, ".env")})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "access to")
+
+		response = run(t, NewViewTool(nil, newMockFileRecordService()), ViewParams{FilePath: filepath.Join(dir, ".env.example")})
+		assert.False(t, response.IsError, response.Content)
+
+		// ignored files that are not secrets can still be read
+		response = run(t, NewViewTool(nil, newMockFileRecordService()), ViewParams{FilePath: filepath.Join(dir, "debug.log")})
+		assert.False(t, response.IsError, response.Content)
+	})
+
+	t.Run("write and edit deny ignored secrets", func(t *testing.T) {
+		response := run(t, NewWriteTool(nil, newMockFileRecordService()), WriteParams{FilePath: filepath.Join(dir, ".env"), Content: "TOKEN=other\n"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "access to")
+
+		response = run(t, NewEditTool(nil, newMockFileRecordService()), EditParams{FilePath: filepath.Join(dir, ".env"), OldString: "secret", NewString: "other"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "access to")
+
+		content, err := os.ReadFile(filepath.Join(dir, ".env"))
+		require.NoError(t, err)
+		assert.Equal(t, "TOKEN=secret\n", string(content))
+	})
+}
+
+func TestIsSensitiveFile(t *testing.T) {
+	for _, path := range []string{".env", "/a/.env.local", "server.key", "cert.pem", "id_rsa", "id_ed25519", ".npmrc", "terraform.tfstate"} {
+		assert.True(t, isSensitiveFile(path), path)
+	}
+	for _, path := range []string{"main.go", "env.go", ".envrc.md", "keys.go", "README.md", "id_rsa.pub"} {
+		assert.False(t, isSensitiveFile(path), path)
+	}
+}
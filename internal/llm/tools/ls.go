@@ -92,13 +92,14 @@ func (l *lsTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
 func listDirectory(initialPath string, ignorePatterns []string, limit int) ([]string, bool, error) {
 	var results []string
 	truncated := false
+	ignore := newIgnoreMatcher(initialPath)
 
 	err := filepath.Walk(initialPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // Skip files we don't have permission to access
 		}
 
-		if shouldSkip(path, ignorePatterns) {
+		if shouldSkip(path, ignorePatterns) || (path != initialPath && ignore.match(path, info.IsDir())) {
 			if info.IsDir() {
 				return filepath.SkipDir
 			}
@@ -301,6 +302,7 @@ FEATURES:
 - Displays a hierarchical view of files and directories
 - Automatically skips hidden files/directories (starting with '.')
 - Skips common system directories like __pycache__
+- Skips files and directories ignored by .gitignore files, the global git excludes or .termaiignore
 - Can filter out files matching specific patterns
 
 LIMITATIONS:
@@ -0,0 +1,14 @@
+package tools
+
+import (
+	"os"
+	"testing"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools/shell"
+)
+
+func TestMain(m *testing.M) {
+	// the sandbox tests start sandboxed shells through the test binary
+	shell.RunSandboxHelper()
+	os.Exit(m.Run())
+}
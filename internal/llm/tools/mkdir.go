@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+)
+
+type mkdirTool struct{}
+
+const (
+	MkdirToolName = "mkdir"
+)
+
+type MkdirParams struct {
+	Path string `json:"path"`
+}
+
+func (m *mkdirTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        MkdirToolName,
+		Description: mkdirDescription(),
+		Parameters: map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "The path of the directory to create",
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+// Run implements Tool.
+func (m *mkdirTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params MkdirParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.Path == "" {
+		return NewTextErrorResponse("path is required"), nil
+	}
+
+	path, err := resolveWorkspacePath(params.Path, false)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if info, err := os.Stat(path); err == nil {
+		if !info.IsDir() {
+			return NewTextErrorResponse(fmt.Sprintf("Path exists and is not a directory: %s", path)), nil
+		}
+		return NewTextResponse(fmt.Sprintf("Directory already exists: %s", path)), nil
+	}
+
+	p := permission.Default.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   GetSessionFromContext(ctx),
+			Path:        path,
+			ToolName:    MkdirToolName,
+			Action:      "create",
+			Description: fmt.Sprintf("Create directory %s", path),
+			Params: FileOperationPermissionsParams{
+				Operation: "mkdir",
+				Source:    path,
+			},
+		},
+	)
+	if !p {
+		return NewTextErrorResponse(fmt.Sprintf("Permission denied to create directory %s", path)), nil
+	}
+
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to create directory: %s", err)), nil
+	}
+	return NewTextResponse(fmt.Sprintf("Directory created: %s", path)), nil
+}
+
+func mkdirDescription() string {
+	return `Directory creation tool that creates a directory inside the working directory.
+
+WHEN TO USE THIS TOOL:
+- Use when you need an empty directory, like a new package or a directory for generated files
+- Not needed before writing a file, the Write tool creates parent directories
+
+HOW TO USE:
+- Provide the path of the directory to create
+
+FEATURES:
+- Creates the missing parent directories too
+- Succeeds without changes when the directory already exists
+
+LIMITATIONS:
+- The path must be inside the working directory
+- Cannot create directories in the .git directory nor in the data directory
+
+TIPS:
+- Use the LS tool to check the layout of the project before creating directories
+- Use this tool instead of mkdir in the Bash tool`
+}
+
+func NewMkdirTool() BaseTool {
+	return &mkdirTool{}
+}
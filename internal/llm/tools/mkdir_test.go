@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMkdirTool_Info(t *testing.T) {
+	info := NewMkdirTool().Info()
+
+	assert.Equal(t, MkdirToolName, info.Name)
+	assert.NotEmpty(t, info.Description)
+	assert.Contains(t, info.Parameters, "path")
+	assert.Equal(t, []string{"path"}, info.Required)
+}
+
+func TestMkdirTool_Run(t *testing.T) {
+	dir := setupWorkspace(t)
+	tool := NewMkdirTool()
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "session")
+
+	t.Run("creates nested directories", func(t *testing.T) {
+		response := runFileTool(t, tool, ctx, MkdirParams{Path: "internal/store/sql"})
+		require.False(t, response.IsError, response.Content)
+		assert.DirExists(t, filepath.Join(dir, "internal/store/sql"))
+	})
+
+	t.Run("accepts an existing directory", func(t *testing.T) {
+		response := runFileTool(t, tool, ctx, MkdirParams{Path: "internal/store"})
+		require.False(t, response.IsError, response.Content)
+		assert.Contains(t, response.Content, "already exists")
+	})
+
+	t.Run("refuses an existing file", func(t *testing.T) {
+		writeWorkspaceFile(t, dir, "README.md", "# readme\n")
+		response := runFileTool(t, tool, ctx, MkdirParams{Path: "README.md"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "not a directory")
+	})
+
+	t.Run("refuses paths outside the working directory", func(t *testing.T) {
+		response := runFileTool(t, tool, ctx, MkdirParams{Path: "../outside"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "outside the working directory")
+	})
+}
@@ -0,0 +1,181 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+)
+
+type moveTool struct {
+	files filerecord.Service
+}
+
+const (
+	MoveToolName = "move"
+)
+
+type MoveParams struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Overwrite   bool   `json:"overwrite"`
+}
+
+func (m *moveTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        MoveToolName,
+		Description: moveDescription(),
+		Parameters: map[string]any{
+			"source": map[string]any{
+				"type":        "string",
+				"description": "The path of the file or directory to move",
+			},
+			"destination": map[string]any{
+				"type":        "string",
+				"description": "The new path of the file or directory, not the directory to move it into",
+			},
+			"overwrite": map[string]any{
+				"type":        "boolean",
+				"description": "Replace an existing destination file, which is moved to the trash (default false)",
+			},
+		},
+		Required: []string{"source", "destination"},
+	}
+}
+
+// Run implements Tool.
+func (m *moveTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params MoveParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.Source == "" {
+		return NewTextErrorResponse("source is required"), nil
+	}
+	if params.Destination == "" {
+		return NewTextErrorResponse("destination is required"), nil
+	}
+
+	// the trash is allowed as source to restore deleted files
+	source, err := resolveWorkspacePath(params.Source, true)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	destination, err := resolveWorkspacePath(params.Destination, false)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if source == destination {
+		return NewTextErrorResponse("source and destination are the same path"), nil
+	}
+	if within(destination, source) {
+		return NewTextErrorResponse(fmt.Sprintf("cannot move %s into itself", source)), nil
+	}
+
+	permissionParams, err := operationParams("move", source, destination)
+	if os.IsNotExist(err) {
+		return NewTextErrorResponse(fmt.Sprintf("Source not found: %s", source)), nil
+	} else if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to access source: %s", err)), nil
+	}
+	if err := checkDestination(ctx, m.files, destination, params.Overwrite); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	p := permission.Default.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   GetSessionFromContext(ctx),
+			Path:        source,
+			ToolName:    MoveToolName,
+			Action:      "move",
+			Description: fmt.Sprintf("Move %s %s to %s", describeFiles(permissionParams), source, destination),
+			Params:      permissionParams,
+		},
+	)
+	if !p {
+		return NewTextErrorResponse(fmt.Sprintf("Permission denied to move %s", source)), nil
+	}
+
+	trashed, err := replaceDestination(destination)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to move the existing destination to the trash: %s", err)), nil
+	}
+	if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to create parent directories: %s", err)), nil
+	}
+	if err := movePath(source, destination); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to move %s: %s", source, err)), nil
+	}
+	moveFileRecord(ctx, m.files, source, destination, true)
+
+	output := fmt.Sprintf("Moved %s to %s", source, destination)
+	if trashed != "" {
+		output += fmt.Sprintf("\nThe previous %s was moved to the trash: %s", destination, trashed)
+	}
+	return NewTextResponse(output), nil
+}
+
+// checkDestination refuses an existing destination, unless overwrite is set
+// and it is a file the session of ctx has read since its last change.
+func checkDestination(ctx context.Context, files filerecord.Service, destination string, overwrite bool) error {
+	info, err := os.Lstat(destination)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to access destination: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("destination %s is an existing directory, give the full new path", destination)
+	}
+	if !overwrite {
+		return fmt.Errorf("destination %s already exists, set overwrite to replace it", destination)
+	}
+	return checkFileFresh(ctx, files, destination)
+}
+
+// replaceDestination moves an existing destination to the trash and returns
+// where it is now, or an empty string when there was none.
+func replaceDestination(destination string) (string, error) {
+	if _, err := os.Lstat(destination); os.IsNotExist(err) {
+		return "", nil
+	}
+	return moveToTrash(destination)
+}
+
+func moveDescription() string {
+	return `File moving tool that moves or renames a file or a directory inside the working directory.
+
+WHEN TO USE THIS TOOL:
+- Use when you need to rename a file or a directory
+- Helpful for reorganizing files, like moving a file to another package
+- Restores a file deleted with the Delete tool by moving it back from the trash
+
+HOW TO USE:
+- Provide the path of the file or directory to move as source
+- Provide its new path as destination, parent directories are created if needed
+- Set overwrite to replace an existing destination file
+
+FEATURES:
+- Moves files and whole directories
+- A replaced destination file is moved to the trash, so it can be restored
+- Files you have already read do not need to be read again at their new path
+
+LIMITATIONS:
+- Both paths must be inside the working directory
+- The destination is the new path, a file is not moved into an existing directory with the same name
+- Cannot move the .git directory, use the Git tool for version control
+- You must read a destination file before overwriting it
+
+TIPS:
+- Use the LS tool to verify the destination before moving files
+- Use the Grep tool to update the imports and references to moved files
+- Use this tool instead of mv in the Bash tool, so the move is tracked`
+}
+
+func NewMoveTool(files filerecord.Service) BaseTool {
+	return &moveTool{files: files}
+}
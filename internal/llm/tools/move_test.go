@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveTool_Info(t *testing.T) {
+	info := NewMoveTool(nil).Info()
+
+	assert.Equal(t, MoveToolName, info.Name)
+	assert.NotEmpty(t, info.Description)
+	assert.Contains(t, info.Parameters, "source")
+	assert.Contains(t, info.Parameters, "destination")
+	assert.Contains(t, info.Parameters, "overwrite")
+	assert.Equal(t, []string{"source", "destination"}, info.Required)
+}
+
+func TestMoveTool_Run(t *testing.T) {
+	dir := setupWorkspace(t)
+	files := newMockFileRecordService()
+	tool := NewMoveTool(files)
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "session")
+
+	t.Run("moves a file and its record", func(t *testing.T) {
+		source := writeWorkspaceFile(t, dir, "old.go", "package old\n")
+		recordFileRead(ctx, files, source)
+
+		response := runFileTool(t, tool, ctx, MoveParams{Source: "old.go", Destination: "pkg/new.go"})
+		require.False(t, response.IsError, response.Content)
+		assert.Contains(t, response.Content, "Moved")
+
+		assert.NoFileExists(t, source)
+		destination := filepath.Join(dir, "pkg/new.go")
+		assert.FileExists(t, destination)
+		assert.NoError(t, checkFileFresh(ctx, files, destination))
+		_, err := files.Get("session", source)
+		assert.Error(t, err)
+	})
+
+	t.Run("moves a directory and the records below it", func(t *testing.T) {
+		read := writeWorkspaceFile(t, dir, "from/a.txt", "a")
+		nested := writeWorkspaceFile(t, dir, "from/b/c.txt", "c")
+		writeWorkspaceFile(t, dir, "from/unread.txt", "u")
+		recordFileRead(ctx, files, read)
+		recordFileRead(ctx, files, nested)
+
+		response := runFileTool(t, tool, ctx, MoveParams{Source: "from", Destination: "to"})
+		require.False(t, response.IsError, response.Content)
+		assert.NoDirExists(t, filepath.Join(dir, "from"))
+		assert.FileExists(t, filepath.Join(dir, "to/b/c.txt"))
+
+		assert.NoError(t, checkFileFresh(ctx, files, filepath.Join(dir, "to/a.txt")))
+		assert.NoError(t, checkFileFresh(ctx, files, filepath.Join(dir, "to/b/c.txt")))
+		assert.Error(t, checkFileFresh(ctx, files, filepath.Join(dir, "to/unread.txt")))
+		_, err := files.Get("session", nested)
+		assert.Error(t, err)
+	})
+
+	t.Run("refuses an existing destination", func(t *testing.T) {
+		writeWorkspaceFile(t, dir, "x.txt", "x")
+		writeWorkspaceFile(t, dir, "y.txt", "y")
+
+		response := runFileTool(t, tool, ctx, MoveParams{Source: "x.txt", Destination: "y.txt"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "set overwrite")
+
+		response = runFileTool(t, tool, ctx, MoveParams{Source: "x.txt", Destination: "y.txt", Overwrite: true})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "you must read the file")
+	})
+
+	t.Run("overwrites a read destination to the trash", func(t *testing.T) {
+		recordFileRead(ctx, files, filepath.Join(dir, "y.txt"))
+
+		response := runFileTool(t, tool, ctx, MoveParams{Source: "x.txt", Destination: "y.txt", Overwrite: true})
+		require.False(t, response.IsError, response.Content)
+		assert.Contains(t, response.Content, "moved to the trash")
+
+		content, err := os.ReadFile(filepath.Join(dir, "y.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "x", string(content))
+		trashed, err := filepath.Glob(filepath.Join(dir, ".termai/trash/*/y.txt"))
+		require.NoError(t, err)
+		assert.Len(t, trashed, 1)
+	})
+
+	t.Run("refuses to move into itself", func(t *testing.T) {
+		response := runFileTool(t, tool, ctx, MoveParams{Source: "to", Destination: "to/inner"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "into itself")
+	})
+
+	t.Run("refuses paths outside the working directory", func(t *testing.T) {
+		response := runFileTool(t, tool, ctx, MoveParams{Source: "y.txt", Destination: "../y.txt"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "outside the working directory")
+	})
+
+	t.Run("respects a denied permission", func(t *testing.T) {
+		permission.Default = newMockPermissionService(false)
+		defer func() { permission.Default = newMockPermissionService(true) }()
+
+		response := runFileTool(t, tool, ctx, MoveParams{Source: "y.txt", Destination: "z.txt"})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "Permission denied")
+		assert.FileExists(t, filepath.Join(dir, "y.txt"))
+	})
+}
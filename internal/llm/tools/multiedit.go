@@ -0,0 +1,247 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
+	"github.com/imnulhaqueruman/opencode-poc/internal/lsp"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+)
+
+type multiEditTool struct {
+	lspManager *lsp.Manager
+	files      filerecord.Service
+}
+
+const (
+	MultiEditToolName = "multiedit"
+)
+
+type MultiEditOperation struct {
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all"`
+}
+
+type MultiEditParams struct {
+	FilePath string               `json:"file_path"`
+	Edits    []MultiEditOperation `json:"edits"`
+}
+
+type MultiEditPermissionsParams struct {
+	FilePath string `json:"file_path"`
+	Edits    int    `json:"edits"`
+	Diff     string `json:"diff"`
+}
+
+func (m *multiEditTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        MultiEditToolName,
+		Description: multiEditDescription(),
+		Parameters: map[string]any{
+			"file_path": map[string]any{
+				"type":        "string",
+				"description": "The absolute path to the file to modify",
+			},
+			"edits": map[string]any{
+				"type":        "array",
+				"description": "Edits to apply in order, each one operates on the result of the previous one",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"old_string": map[string]any{
+							"type":        "string",
+							"description": "The text to replace",
+						},
+						"new_string": map[string]any{
+							"type":        "string",
+							"description": "The text to replace it with",
+						},
+						"replace_all": map[string]any{
+							"type":        "boolean",
+							"description": "Replace all occurrences of old_string (default false)",
+						},
+					},
+					"required": []string{"old_string", "new_string"},
+				},
+			},
+		},
+		Required: []string{"file_path", "edits"},
+	}
+}
+
+// Run implements Tool.
+func (m *multiEditTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params MultiEditParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("invalid parameters"), nil
+	}
+
+	if params.FilePath == "" {
+		return NewTextErrorResponse("file_path is required"), nil
+	}
+	if len(params.Edits) == 0 {
+		return NewTextErrorResponse("at least one edit is required"), nil
+	}
+
+	if !filepath.IsAbs(params.FilePath) {
+		params.FilePath = filepath.Join(config.WorkingDirectory(), params.FilePath)
+	}
+	if err := checkFileAccess(params.FilePath); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	oldContent := ""
+	format := textFormat{mode: defaultFileMode}
+	isNewFile := false
+	fileInfo, err := os.Stat(params.FilePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return NewTextErrorResponse(fmt.Sprintf("failed to access file: %s", err)), nil
+		}
+		// A missing file can only be created by a first edit with an empty old_string
+		if params.Edits[0].OldString != "" {
+			return NewTextErrorResponse(fmt.Sprintf("file not found: %s", params.FilePath)), nil
+		}
+		isNewFile = true
+	} else {
+		if fileInfo.IsDir() {
+			return NewTextErrorResponse(fmt.Sprintf("path is a directory, not a file: %s", params.FilePath)), nil
+		}
+
+		if err := checkFileFresh(ctx, m.files, params.FilePath); err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+
+		oldContent, format, err = readFileText(params.FilePath)
+		if err != nil {
+			return NewTextErrorResponse(fmt.Sprintf("failed to read file: %s", err)), nil
+		}
+	}
+
+	newContent, err := applyEdits(oldContent, params.Edits, isNewFile)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("no changes made: %s", err)), nil
+	}
+	if !isNewFile && newContent == oldContent {
+		return NewTextErrorResponse("no changes made: the edits do not change the file"), nil
+	}
+
+	action := "replace"
+	description := fmt.Sprintf("Apply %d edits to file %s", len(params.Edits), params.FilePath)
+	if isNewFile {
+		action = "create"
+		description = fmt.Sprintf("Create file %s", params.FilePath)
+	}
+	p := permission.Default.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   GetSessionFromContext(ctx),
+			Path:        filepath.Dir(params.FilePath),
+			ToolName:    MultiEditToolName,
+			Action:      action,
+			Description: description,
+			Params: MultiEditPermissionsParams{
+				FilePath: params.FilePath,
+				Edits:    len(params.Edits),
+				Diff:     GenerateDiff(oldContent, newContent),
+			},
+		},
+	)
+	if !p {
+		return NewTextErrorResponse("permission denied"), nil
+	}
+
+	if isNewFile {
+		if err = os.MkdirAll(filepath.Dir(params.FilePath), 0o755); err != nil {
+			return NewTextErrorResponse(fmt.Sprintf("failed to create parent directories: %s", err)), nil
+		}
+	}
+	written, err := writeFileText(params.FilePath, newContent, format)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("failed to write file: %s", err)), nil
+	}
+
+	recordFileWrite(ctx, m.files, params.FilePath, written)
+
+	return appendDiagnostics(ctx, m.lspManager, NewTextResponse(fmt.Sprintf("Applied %d edits to file: %s", len(params.Edits), params.FilePath)), params.FilePath), nil
+}
+
+// applyEdits applies the edits in order to content. Nothing is written here,
+// so an edit that does not apply leaves the file untouched.
+func applyEdits(content string, edits []MultiEditOperation, isNewFile bool) (string, error) {
+	for i, edit := range edits {
+		if edit.OldString == "" {
+			if i == 0 && isNewFile {
+				content = edit.NewString
+				continue
+			}
+			return "", fmt.Errorf("edit %d: old_string is required", i+1)
+		}
+		if edit.OldString == edit.NewString {
+			return "", fmt.Errorf("edit %d: old_string and new_string are the same", i+1)
+		}
+
+		count := strings.Count(content, edit.OldString)
+		switch {
+		case count == 0:
+			return "", fmt.Errorf("edit %d: old_string not found in file. Make sure it matches exactly, including whitespace and line breaks, and accounts for the previous edits", i+1)
+		case count > 1 && !edit.ReplaceAll:
+			return "", fmt.Errorf("edit %d: old_string appears %d times in the file. Provide more context to ensure a unique match, or set replace_all", i+1, count)
+		}
+
+		if edit.ReplaceAll {
+			content = strings.ReplaceAll(content, edit.OldString, edit.NewString)
+		} else {
+			content = strings.Replace(content, edit.OldString, edit.NewString, 1)
+		}
+	}
+	return content, nil
+}
+
+func multiEditDescription() string {
+	return `Makes several edits to a single file in one operation. Prefer this tool over the Edit tool when you need to make multiple changes to the same file, such as renaming a symbol or updating several related places.
+
+Before using this tool:
+
+1. Use the View tool to understand the file's contents and context
+
+2. Verify the directory path is correct (only applicable when creating new files)
+
+To make multiple edits, provide the following:
+1. file_path: The absolute path to the file to modify
+2. edits: An array of edits to perform, where each edit contains:
+   - old_string: The text to replace (must match the file contents exactly, including all whitespace and indentation)
+   - new_string: The edited text to replace the old_string
+   - replace_all: Replace all occurrences of old_string instead of requiring a unique match (optional, defaults to false)
+
+IMPORTANT:
+- All edits are applied in sequence, in the order they are provided
+- Each edit operates on the result of the previous edit, so plan your edits carefully
+- The edits are atomic: either all of them succeed or none of them are applied
+- Without replace_all, each old_string must uniquely identify a single location in the file
+- A single permission request is made with the combined diff of all edits
+- The file keeps its permissions, line endings (LF or CRLF), final newline and UTF-8 byte order mark, and is written atomically
+
+Special cases:
+- To create a new file: use a path that does not exist and an empty old_string in the first edit, its new_string becomes the file content
+
+WARNING:
+- The tool will fail if any old_string is not found, or matches multiple locations without replace_all
+- The tool will fail if old_string and new_string are the same
+- Since edits are applied in order, make sure an earlier edit does not change the text a later edit is trying to find
+
+When making edits:
+- Ensure the edits result in idiomatic, correct code
+- Do not leave the code in a broken state
+- Always use absolute file paths (starting with /)`
+}
+
+func NewMultiEditTool(lspManager *lsp.Manager, files filerecord.Service) BaseTool {
+	return &multiEditTool{lspManager: lspManager, files: files}
+}
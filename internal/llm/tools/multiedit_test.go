@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiEditTool_Info(t *testing.T) {
+	tool := NewMultiEditTool(nil, nil)
+	info := tool.Info()
+
+	assert.Equal(t, MultiEditToolName, info.Name)
+	assert.NotEmpty(t, info.Description)
+	assert.Contains(t, info.Parameters, "file_path")
+	assert.Contains(t, info.Parameters, "edits")
+	assert.Contains(t, info.Required, "file_path")
+	assert.Contains(t, info.Required, "edits")
+}
+
+func TestMultiEditTool_Run(t *testing.T) {
+	origPermission := permission.Default
+	defer func() {
+		permission.Default = origPermission
+	}()
+
+	tempDir := t.TempDir()
+	files := newMockFileRecordService()
+
+	runMultiEdit := func(t *testing.T, params MultiEditParams) ToolResponse {
+		paramsJSON, err := json.Marshal(params)
+		require.NoError(t, err)
+
+		response, err := NewMultiEditTool(nil, files).Run(context.Background(), ToolCall{
+			Name:  MultiEditToolName,
+			Input: string(paramsJSON),
+		})
+		require.NoError(t, err)
+		return response
+	}
+
+	writeAndRead := func(t *testing.T, name, content string) string {
+		filePath := filepath.Join(tempDir, name)
+		require.NoError(t, os.WriteFile(filePath, []byte(content), 0o644))
+		recordFileRead(context.Background(), files, filePath)
+		return filePath
+	}
+
+	t.Run("applies edits in order", func(t *testing.T) {
+		permission.Default = newMockPermissionService(true)
+		filePath := writeAndRead(t, "ordered.go", "func foo() {\n\tbar()\n}\n")
+
+		response := runMultiEdit(t, MultiEditParams{
+			FilePath: filePath,
+			Edits: []MultiEditOperation{
+				{OldString: "func foo()", NewString: "func baz()"},
+				{OldString: "func baz() {\n\tbar()", NewString: "func baz() {\n\tqux()"},
+			},
+		})
+		assert.False(t, response.IsError, response.Content)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "func baz() {\n\tqux()\n}\n", string(content))
+	})
+
+	t.Run("replaces all occurrences when requested", func(t *testing.T) {
+		permission.Default = newMockPermissionService(true)
+		filePath := writeAndRead(t, "rename.go", "a := old\nb := old + old\n")
+
+		response := runMultiEdit(t, MultiEditParams{
+			FilePath: filePath,
+			Edits: []MultiEditOperation{
+				{OldString: "old", NewString: "renamed", ReplaceAll: true},
+			},
+		})
+		assert.False(t, response.IsError, response.Content)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "a := renamed\nb := renamed + renamed\n", string(content))
+	})
+
+	t.Run("does not write anything when one edit fails", func(t *testing.T) {
+		permission.Default = newMockPermissionService(true)
+		original := "first line\nsecond line\n"
+		filePath := writeAndRead(t, "atomic.txt", original)
+
+		response := runMultiEdit(t, MultiEditParams{
+			FilePath: filePath,
+			Edits: []MultiEditOperation{
+				{OldString: "first", NewString: "1st"},
+				{OldString: "missing", NewString: "present"},
+			},
+		})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "edit 2")
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content))
+	})
+
+	t.Run("rejects ambiguous matches without replace_all", func(t *testing.T) {
+		permission.Default = newMockPermissionService(true)
+		filePath := writeAndRead(t, "ambiguous.txt", "x\nx\n")
+
+		response := runMultiEdit(t, MultiEditParams{
+			FilePath: filePath,
+			Edits:    []MultiEditOperation{{OldString: "x", NewString: "y"}},
+		})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "appears 2 times")
+	})
+
+	t.Run("creates a new file", func(t *testing.T) {
+		permission.Default = newMockPermissionService(true)
+		filePath := filepath.Join(tempDir, "nested", "new.txt")
+
+		response := runMultiEdit(t, MultiEditParams{
+			FilePath: filePath,
+			Edits: []MultiEditOperation{
+				{OldString: "", NewString: "hello world\n"},
+				{OldString: "world", NewString: "there"},
+			},
+		})
+		assert.False(t, response.IsError, response.Content)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "hello there\n", string(content))
+	})
+
+	t.Run("requires the file to be read first", func(t *testing.T) {
+		permission.Default = newMockPermissionService(true)
+		filePath := filepath.Join(tempDir, "unread.txt")
+		require.NoError(t, os.WriteFile(filePath, []byte("content"), 0o644))
+
+		response := runMultiEdit(t, MultiEditParams{
+			FilePath: filePath,
+			Edits:    []MultiEditOperation{{OldString: "content", NewString: "changed"}},
+		})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "you must read the file")
+	})
+
+	t.Run("handles permission denied", func(t *testing.T) {
+		permission.Default = newMockPermissionService(false)
+		original := "keep me"
+		filePath := writeAndRead(t, "denied.txt", original)
+
+		response := runMultiEdit(t, MultiEditParams{
+			FilePath: filePath,
+			Edits:    []MultiEditOperation{{OldString: "keep", NewString: "drop"}},
+		})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "permission denied")
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content))
+	})
+}
@@ -0,0 +1,431 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/lsp"
+)
+
+// Shared helpers of the definition, references and symbols tools.
+
+const (
+	maxNavigationResults = 100
+	maxSnippetLength     = 200
+
+	// navigationTimeout bounds the language server requests of a tool call
+	navigationTimeout = 10 * time.Second
+)
+
+// codeLocation is a 1-based position in a file.
+type codeLocation struct {
+	path   string
+	line   int
+	column int
+}
+
+// codeSymbol is a declaration, with its nested declarations for types.
+type codeSymbol struct {
+	name      string
+	kind      string
+	container string
+	path      string
+	line      int
+	column    int
+	endLine   int
+	children  []codeSymbol
+}
+
+// navigationRoot resolves the directory results are limited to.
+func navigationRoot(path string) string {
+	wd := config.WorkingDirectory()
+	if path == "" {
+		return wd
+	}
+	if !filepath.IsAbs(path) {
+		return filepath.Join(wd, path)
+	}
+	return path
+}
+
+// isNavigablePath reports if path is inside root and not excluded by the
+// ignore rules of the ls and glob tools, nor by the ignore files.
+func isNavigablePath(ignore *ignoreMatcher, root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	if rel == "." {
+		return true
+	}
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if shouldSkip(part, nil) {
+			return false
+		}
+	}
+	return !ignore.Ignored(path, false)
+}
+
+func fromLSPLocations(locations []lsp.Location) []codeLocation {
+	result := make([]codeLocation, 0, len(locations))
+	for _, l := range locations {
+		result = append(result, codeLocation{
+			path:   lsp.URIToPath(l.URI),
+			line:   l.Range.Start.Line + 1,
+			column: l.Range.Start.Character + 1,
+		})
+	}
+	return result
+}
+
+// splitSymbol splits "Type.Method" or "pkg.Name" into its qualifier and name.
+func splitSymbol(symbol string) (string, string) {
+	if idx := strings.LastIndex(symbol, "."); idx >= 0 {
+		return symbol[:idx], symbol[idx+1:]
+	}
+	return "", symbol
+}
+
+// symbolColumn finds the 1-based column of name as a whole word in line.
+func symbolColumn(line, name string) int {
+	re := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+	if loc := re.FindStringIndex(line); loc != nil {
+		return loc[0] + 1
+	}
+	return 0
+}
+
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// formatLocations lists unique locations inside root as "path:line:column:
+// snippet", sorted by path and line.
+func formatLocations(root string, locations []codeLocation) string {
+	seen := make(map[codeLocation]bool)
+	ignore := newIgnoreMatcher(root)
+	var filtered []codeLocation
+	for _, l := range locations {
+		if seen[l] || !isNavigablePath(ignore, root, l.path) {
+			continue
+		}
+		seen[l] = true
+		filtered = append(filtered, l)
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		if filtered[i].path != filtered[j].path {
+			return filtered[i].path < filtered[j].path
+		}
+		if filtered[i].line != filtered[j].line {
+			return filtered[i].line < filtered[j].line
+		}
+		return filtered[i].column < filtered[j].column
+	})
+
+	truncated := len(filtered) > maxNavigationResults
+	if truncated {
+		filtered = filtered[:maxNavigationResults]
+	}
+
+	var sb strings.Builder
+	files := make(map[string][]string)
+	for _, l := range filtered {
+		lines, ok := files[l.path]
+		if !ok {
+			lines, _ = readLines(l.path)
+			files[l.path] = lines
+		}
+		snippet := ""
+		if l.line > 0 && l.line <= len(lines) {
+			snippet = strings.TrimSpace(lines[l.line-1])
+			if len(snippet) > maxSnippetLength {
+				snippet = snippet[:maxSnippetLength] + "..."
+			}
+		}
+		fmt.Fprintf(&sb, "%s:%d:%d: %s\n", l.path, l.line, l.column, snippet)
+	}
+	if truncated {
+		fmt.Fprintf(&sb, "\n(Results are truncated, only the first %d are shown. Use a more specific symbol or path.)\n", maxNavigationResults)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// lspDefinitions looks symbol up in the workspace symbols of all language
+// servers. Methods and fields can be qualified with their type, as "Type.Name".
+func lspDefinitions(ctx context.Context, lspManager *lsp.Manager, symbol string) []codeLocation {
+	qualifier, name := splitSymbol(symbol)
+	var locations []codeLocation
+	for _, c := range lspManager.Clients(ctx, "") {
+		symbols, err := c.WorkspaceSymbols(ctx, name)
+		if err != nil {
+			continue
+		}
+		for _, s := range symbols {
+			// servers differ in how they qualify names, so accept both forms
+			matches := s.Name == symbol || strings.HasSuffix(s.Name, "."+symbol)
+			if !matches && s.Name == name {
+				matches = qualifier == "" || strings.HasSuffix(s.ContainerName, qualifier)
+			}
+			if matches {
+				locations = append(locations, fromLSPLocations([]lsp.Location{s.Location})...)
+			}
+		}
+	}
+	return locations
+}
+
+// goFiles calls fn for every parsable Go file under root, which can also be
+// a single file.
+func goFiles(root string, fn func(path string, fset *token.FileSet, file *ast.File)) error {
+	ignore := newIgnoreMatcher(root)
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path != root && (shouldSkip(path, nil) || ignore.match(path, d.IsDir())) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil && file == nil {
+			return nil
+		}
+		fn(path, fset, file)
+		return nil
+	})
+}
+
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+func newGoSymbol(fset *token.FileSet, path, name, kind, container string, node ast.Node, ident *ast.Ident) codeSymbol {
+	pos := fset.Position(ident.Pos())
+	return codeSymbol{
+		name:      name,
+		kind:      kind,
+		container: container,
+		path:      path,
+		line:      pos.Line,
+		column:    pos.Column,
+		endLine:   fset.Position(node.End()).Line,
+	}
+}
+
+// goSymbols returns the top level declarations of a Go file, with the fields
+// and methods of struct and interface types as children.
+func goSymbols(path string, fset *token.FileSet, file *ast.File) []codeSymbol {
+	var symbols []codeSymbol
+	walkGoDecls(file, goDeclVisitor{
+		function: func(d *ast.FuncDecl) {
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				container := receiverTypeName(d.Recv.List[0].Type)
+				symbols = append(symbols, newGoSymbol(fset, path, d.Name.Name, "method", container, d, d.Name))
+			} else {
+				symbols = append(symbols, newGoSymbol(fset, path, d.Name.Name, "function", "", d, d.Name))
+			}
+		},
+		typeSpec: func(d *ast.GenDecl, s *ast.TypeSpec) {
+			symbols = append(symbols, goTypeSymbol(fset, path, d, s))
+		},
+		values: func(d *ast.GenDecl) {
+			kind := "variable"
+			if d.Tok == token.CONST {
+				kind = "constant"
+			}
+			for _, spec := range d.Specs {
+				s := spec.(*ast.ValueSpec)
+				for _, name := range s.Names {
+					if name.Name != "_" {
+						symbols = append(symbols, newGoSymbol(fset, path, name.Name, kind, "", s, name))
+					}
+				}
+			}
+		},
+	})
+	return symbols
+}
+
+// goDeclVisitor receives the top level declarations of a Go file.
+type goDeclVisitor struct {
+	function func(decl *ast.FuncDecl)
+	typeSpec func(decl *ast.GenDecl, spec *ast.TypeSpec)
+	// values receives a const or var declaration, with all its specs
+	values func(decl *ast.GenDecl)
+}
+
+// walkGoDecls calls v for the top level declarations of file, in order.
+// Imports are left out.
+func walkGoDecls(file *ast.File, v goDeclVisitor) {
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			v.function(d)
+		case *ast.GenDecl:
+			switch d.Tok {
+			case token.TYPE:
+				for _, spec := range d.Specs {
+					v.typeSpec(d, spec.(*ast.TypeSpec))
+				}
+			case token.CONST, token.VAR:
+				v.values(d)
+			}
+		}
+	}
+}
+
+func goTypeSymbol(fset *token.FileSet, path string, decl *ast.GenDecl, spec *ast.TypeSpec) codeSymbol {
+	var node ast.Node = spec
+	if len(decl.Specs) == 1 {
+		node = decl
+	}
+	kind := "type"
+	var members *ast.FieldList
+	memberKind := ""
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		kind, members, memberKind = "struct", t.Fields, "field"
+	case *ast.InterfaceType:
+		kind, members, memberKind = "interface", t.Methods, "method"
+	}
+
+	symbol := newGoSymbol(fset, path, spec.Name.Name, kind, "", node, spec.Name)
+	if members != nil {
+		for _, field := range members.List {
+			for _, name := range field.Names {
+				symbol.children = append(symbol.children, newGoSymbol(fset, path, name.Name, memberKind, spec.Name.Name, field, name))
+			}
+		}
+	}
+	return symbol
+}
+
+// goDefinitions finds the declarations of symbol in the Go files under root.
+// Without type information, declarations are matched by name only.
+func goDefinitions(root, symbol string) []codeLocation {
+	qualifier, name := splitSymbol(symbol)
+	var locations []codeLocation
+	var match func(pkg string, symbols []codeSymbol)
+	match = func(pkg string, symbols []codeSymbol) {
+		for _, s := range symbols {
+			if s.name == name && (qualifier == "" || qualifier == s.container || qualifier == pkg) {
+				locations = append(locations, codeLocation{path: s.path, line: s.line, column: s.column})
+			}
+			match(pkg, s.children)
+		}
+	}
+	_ = goFiles(root, func(path string, fset *token.FileSet, file *ast.File) {
+		match(file.Name.Name, goSymbols(path, fset, file))
+	})
+	return locations
+}
+
+// goReferences finds the identifiers named like symbol in the Go files under
+// root. A qualified symbol only matches selectors, as in "x.Name".
+func goReferences(root, symbol string) []codeLocation {
+	qualifier, name := splitSymbol(symbol)
+	var locations []codeLocation
+	_ = goFiles(root, func(path string, fset *token.FileSet, file *ast.File) {
+		add := func(ident *ast.Ident) {
+			pos := fset.Position(ident.Pos())
+			locations = append(locations, codeLocation{path: path, line: pos.Line, column: pos.Column})
+		}
+		if qualifier != "" {
+			for _, s := range goSymbols(path, fset, file) {
+				for _, c := range append([]codeSymbol{s}, s.children...) {
+					if c.name == name && (c.container == qualifier || file.Name.Name == qualifier) {
+						locations = append(locations, codeLocation{path: c.path, line: c.line, column: c.column})
+					}
+				}
+			}
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				if qualifier != "" && n.Sel.Name == name {
+					add(n.Sel)
+				}
+			case *ast.Ident:
+				if qualifier == "" && n.Name == name {
+					add(n)
+				}
+			}
+			return true
+		})
+	})
+	return locations
+}
+
+// lspPositionLookup resolves the symbol on a line of a file to a position and
+// passes it to lookup for every language server handling the file.
+func lspPositionLookup(
+	ctx context.Context,
+	lspManager *lsp.Manager,
+	filePath string,
+	line int,
+	symbol string,
+	lookup func(c *lsp.Client, pos lsp.Position) ([]lsp.Location, error),
+) ([]codeLocation, error) {
+	lines, err := readLines(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if line < 1 || line > len(lines) {
+		return nil, fmt.Errorf("line %d is out of range, the file has %d lines", line, len(lines))
+	}
+	_, name := splitSymbol(symbol)
+	column := symbolColumn(lines[line-1], name)
+	if column == 0 {
+		return nil, fmt.Errorf("symbol %s not found on line %d of %s", name, line, filePath)
+	}
+
+	var locations []codeLocation
+	for _, c := range lspManager.Clients(ctx, filePath) {
+		found, err := lookup(c, lsp.Position{Line: line - 1, Character: column - 1})
+		if err != nil {
+			continue
+		}
+		locations = append(locations, fromLSPLocations(found)...)
+	}
+	return locations, nil
+}
@@ -0,0 +1,135 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const navigationTestSource = `package shapes
+
+type Shape interface {
+	Area() float64
+}
+
+type Square struct {
+	Side float64
+}
+
+func (s *Square) Area() float64 {
+	return s.Side * s.Side
+}
+
+func NewSquare(side float64) *Square {
+	return &Square{Side: side}
+}
+`
+
+const navigationTestUsage = `package shapes
+
+func total(shapes []Shape) float64 {
+	sum := 0.0
+	for _, s := range shapes {
+		sum += s.Area()
+	}
+	return sum + NewSquare(1).Area()
+}
+`
+
+func setupNavigationProject(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shapes.go"), []byte(navigationTestSource), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "total.go"), []byte(navigationTestUsage), 0o644))
+
+	// ignored directories must not show up in results
+	vendor := filepath.Join(dir, "vendor", "other")
+	require.NoError(t, os.MkdirAll(vendor, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(vendor, "other.go"), []byte("package other\n\nfunc NewSquare() {}\n"), 0o644))
+
+	// so must the files excluded by the ignore files
+	generated := filepath.Join(dir, "generated")
+	require.NoError(t, os.MkdirAll(generated, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(generated, "gen.go"), []byte("package generated\n\nfunc NewSquare() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".termaiignore"), []byte("generated/\n"), 0o644))
+
+	oldWd := viper.GetString("wd")
+	viper.Set("wd", dir)
+	t.Cleanup(func() {
+		viper.Set("wd", oldWd)
+	})
+	return dir
+}
+
+func runNavigationTool(t *testing.T, tool BaseTool, params any) ToolResponse {
+	paramsJSON, err := json.Marshal(params)
+	require.NoError(t, err)
+	response, err := tool.Run(context.Background(), ToolCall{Input: string(paramsJSON)})
+	require.NoError(t, err)
+	return response
+}
+
+func TestDefinitionTool_Run(t *testing.T) {
+	dir := setupNavigationProject(t)
+	tool := NewDefinitionTool(nil)
+
+	t.Run("finds a function", func(t *testing.T) {
+		response := runNavigationTool(t, tool, DefinitionParams{Symbol: "NewSquare"})
+		assert.False(t, response.IsError)
+		assert.Equal(t, filepath.Join(dir, "shapes.go")+":15:6: func NewSquare(side float64) *Square {", response.Content)
+	})
+
+	t.Run("finds a qualified method", func(t *testing.T) {
+		response := runNavigationTool(t, tool, DefinitionParams{Symbol: "Square.Area"})
+		assert.Equal(t, filepath.Join(dir, "shapes.go")+":11:18: func (s *Square) Area() float64 {", response.Content)
+	})
+
+	t.Run("finds a struct field", func(t *testing.T) {
+		response := runNavigationTool(t, tool, DefinitionParams{Symbol: "Square.Side"})
+		assert.Equal(t, filepath.Join(dir, "shapes.go")+":8:2: Side float64", response.Content)
+	})
+
+	t.Run("reports missing symbols", func(t *testing.T) {
+		response := runNavigationTool(t, tool, DefinitionParams{Symbol: "Circle"})
+		assert.Equal(t, "No definition found for Circle", response.Content)
+	})
+}
+
+func TestReferencesTool_Run(t *testing.T) {
+	dir := setupNavigationProject(t)
+	tool := NewReferencesTool(nil)
+
+	response := runNavigationTool(t, tool, ReferencesParams{Symbol: "NewSquare"})
+	assert.False(t, response.IsError)
+	assert.Equal(t,
+		filepath.Join(dir, "shapes.go")+":15:6: func NewSquare(side float64) *Square {\n"+
+			filepath.Join(dir, "total.go")+":8:15: return sum + NewSquare(1).Area()",
+		response.Content)
+
+	response = runNavigationTool(t, tool, ReferencesParams{Symbol: "Square.Area"})
+	assert.Contains(t, response.Content, filepath.Join(dir, "shapes.go")+":11:18:")
+	assert.Contains(t, response.Content, filepath.Join(dir, "total.go")+":6:12:")
+	assert.Contains(t, response.Content, filepath.Join(dir, "total.go")+":8:28:")
+}
+
+func TestSymbolsTool_Run(t *testing.T) {
+	dir := setupNavigationProject(t)
+	tool := NewSymbolsTool(nil)
+
+	response := runNavigationTool(t, tool, SymbolsParams{FilePath: "shapes.go"})
+	assert.False(t, response.IsError)
+	assert.Equal(t, `interface Shape (lines 3-5)
+  method Area (line 4)
+struct Square (lines 7-9)
+  field Side (line 8)
+method Square.Area (lines 11-13)
+function NewSquare (lines 15-17)`, response.Content)
+
+	response = runNavigationTool(t, tool, SymbolsParams{FilePath: filepath.Join(dir, "vendor", "other", "other.go")})
+	assert.True(t, response.IsError)
+}
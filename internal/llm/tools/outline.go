@@ -0,0 +1,717 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+)
+
+type outlineTool struct{}
+
+const (
+	OutlineToolName = "outline"
+
+	maxOutlineFileSize        = 5 * 1024 * 1024
+	maxOutlineSignatureLength = 200
+)
+
+type OutlineParams struct {
+	FilePath string `json:"file_path"`
+}
+
+// outlineItem is a declaration of a file. Depth is its nesting level, like a
+// method in a class.
+type outlineItem struct {
+	signature string
+	line      int
+	endLine   int
+	depth     int
+}
+
+// outlineParser returns the declarations of a file in the order they appear.
+// A parser can return the declarations it found along with an error when the
+// file is not valid.
+type outlineParser func(content []byte) ([]outlineItem, error)
+
+// outlineParsers maps file extensions to the parser of their language. Go
+// files are parsed with go/ast, other languages with line based parsers that
+// only need to recognize declarations, so supporting a language is a matter
+// of adding an entry here.
+var outlineParsers = map[string]outlineParser{
+	".go":       goOutline,
+	".py":       pythonOutline,
+	".js":       braceOutline(jsOutlineRules, "\"'`"),
+	".jsx":      braceOutline(jsOutlineRules, "\"'`"),
+	".mjs":      braceOutline(jsOutlineRules, "\"'`"),
+	".cjs":      braceOutline(jsOutlineRules, "\"'`"),
+	".ts":       braceOutline(jsOutlineRules, "\"'`"),
+	".tsx":      braceOutline(jsOutlineRules, "\"'`"),
+	".mts":      braceOutline(jsOutlineRules, "\"'`"),
+	".cts":      braceOutline(jsOutlineRules, "\"'`"),
+	".rs":       braceOutline(rustOutlineRules, `"`),
+	".java":     braceOutline(javaOutlineRules, `"`),
+	".md":       markdownOutline,
+	".markdown": markdownOutline,
+}
+
+func (o *outlineTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        OutlineToolName,
+		Description: outlineDescription(),
+		Parameters: map[string]any{
+			"file_path": map[string]any{
+				"type":        "string",
+				"description": "The path to the file to outline",
+			},
+		},
+		Required: []string{"file_path"},
+	}
+}
+
+// Run implements Tool.
+func (o *outlineTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params OutlineParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.FilePath == "" {
+		return NewTextErrorResponse("file_path is required"), nil
+	}
+	filePath := params.FilePath
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(config.WorkingDirectory(), filePath)
+	}
+	if err := checkFileAccess(filePath); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewTextErrorResponse(fmt.Sprintf("file not found: %s", filePath)), nil
+		}
+		return NewTextErrorResponse(fmt.Sprintf("failed to access file: %s", err)), nil
+	}
+	if info.IsDir() {
+		return NewTextErrorResponse(fmt.Sprintf("path is a directory, not a file: %s", filePath)), nil
+	}
+	if info.Size() > maxOutlineFileSize {
+		return NewTextErrorResponse(fmt.Sprintf("file is too large (%d bytes), maximum size is %d bytes", info.Size(), maxOutlineFileSize)), nil
+	}
+
+	parse, ok := outlineParsers[strings.ToLower(filepath.Ext(filePath))]
+	if !ok {
+		return NewTextErrorResponse(fmt.Sprintf("outlines are not supported for %s, supported extensions are %s. Use the Symbols tool instead", filepath.Base(filePath), strings.Join(outlineExtensions(), ", "))), nil
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("failed to read file: %s", err)), nil
+	}
+
+	items, err := parse(content)
+	if err != nil && len(items) == 0 {
+		return NewTextErrorResponse(fmt.Sprintf("failed to parse file: %s", err)), nil
+	}
+	lineCount := len(splitOutlineLines(content))
+	if len(items) == 0 {
+		return NewTextResponse(fmt.Sprintf("No declarations found in %s (%d line%s)", filePath, lineCount, pluralize(lineCount))), nil
+	}
+
+	output := formatOutline(filePath, lineCount, items)
+	if err != nil {
+		output += fmt.Sprintf("\n\n(The file has syntax errors, the outline may be incomplete: %s)", err)
+	}
+	return NewTextResponse(spillOutput(ctx, OutlineToolName, output)), nil
+}
+
+func outlineExtensions() []string {
+	extensions := make([]string, 0, len(outlineParsers))
+	for ext := range outlineParsers {
+		extensions = append(extensions, ext)
+	}
+	slices.Sort(extensions)
+	return extensions
+}
+
+func formatOutline(path string, lineCount int, items []outlineItem) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s (%d line%s)\n\n", path, lineCount, pluralize(lineCount))
+	for _, item := range items {
+		lines := fmt.Sprintf("line %d", item.line)
+		if item.endLine > item.line {
+			lines = fmt.Sprintf("lines %d-%d", item.line, item.endLine)
+		}
+		fmt.Fprintf(&sb, "%s%s (%s)\n", strings.Repeat("  ", item.depth), item.signature, lines)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func splitOutlineLines(content []byte) []string {
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+var (
+	signatureSpaces  = regexp.MustCompile(`\s+`)
+	signatureOpening = regexp.MustCompile(`([(\[]) `)
+	signatureClosing = regexp.MustCompile(`,? ([)\]])`)
+)
+
+// compactSignature puts a declaration on a single line.
+func compactSignature(signature string) string {
+	signature = signatureSpaces.ReplaceAllString(strings.TrimSpace(signature), " ")
+	signature = signatureOpening.ReplaceAllString(signature, "$1")
+	signature = signatureClosing.ReplaceAllString(signature, "$1")
+	if len(signature) > maxOutlineSignatureLength {
+		signature = signature[:maxOutlineSignatureLength] + "..."
+	}
+	return signature
+}
+
+// goOutline lists the functions, methods, types, constants and variables of
+// a Go file, with their signatures.
+func goOutline(content []byte) ([]outlineItem, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if file == nil {
+		return nil, err
+	}
+
+	var items []outlineItem
+	add := func(node ast.Node, signature string) {
+		items = append(items, outlineItem{
+			signature: signature,
+			line:      fset.Position(node.Pos()).Line,
+			endLine:   fset.Position(node.End()).Line,
+		})
+	}
+	walkGoDecls(file, goDeclVisitor{
+		function: func(d *ast.FuncDecl) {
+			fn := *d
+			fn.Doc, fn.Body = nil, nil
+			add(d, goSignature(fset, &fn))
+		},
+		typeSpec: func(d *ast.GenDecl, s *ast.TypeSpec) {
+			var node ast.Node = s
+			if len(d.Specs) == 1 {
+				node = d
+			}
+			add(node, "type "+goTypeSignature(fset, s))
+		},
+		values: func(d *ast.GenDecl) {
+			var names []string
+			for _, spec := range d.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					names = append(names, name.Name)
+				}
+			}
+			add(d, compactSignature(d.Tok.String()+" "+strings.Join(names, ", ")))
+		},
+	})
+	return items, err
+}
+
+// goTypeSignature prints a type declaration without the fields of structs and
+// the methods of interfaces.
+func goTypeSignature(fset *token.FileSet, spec *ast.TypeSpec) string {
+	s := *spec
+	s.Doc, s.Comment = nil, nil
+	switch spec.Type.(type) {
+	case *ast.StructType:
+		s.Type = ast.NewIdent("struct")
+	case *ast.InterfaceType:
+		s.Type = ast.NewIdent("interface")
+	}
+	return goSignature(fset, &s)
+}
+
+func goSignature(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return compactSignature(buf.String())
+}
+
+var pythonDeclaration = regexp.MustCompile(`^(\s*)(?:async\s+def|def|class)\s+\w+`)
+
+// pythonOutline lists the classes and functions of a Python file, with the
+// methods of classes. Declarations end before the next line that is not
+// indented more than them.
+func pythonOutline(content []byte) ([]outlineItem, error) {
+	lines := splitOutlineLines(content)
+	type open struct {
+		indent  int
+		isClass bool
+	}
+	var stack []open
+	var items []outlineItem
+	docstring := ""
+	for i, line := range lines {
+		if docstring != "" {
+			if strings.Count(line, docstring)%2 == 1 {
+				docstring = ""
+			}
+			continue
+		}
+		m := pythonDeclaration.FindStringSubmatch(line)
+		if m == nil {
+			for _, quote := range []string{`"""`, `'''`} {
+				if strings.Count(line, quote)%2 == 1 {
+					docstring = quote
+					break
+				}
+			}
+			continue
+		}
+
+		indent := indentWidth(m[1])
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		// functions nested in functions are not part of the outline
+		if len(stack) > 0 && !stack[len(stack)-1].isClass {
+			continue
+		}
+
+		header := i
+		for header < len(lines)-1 && header-i < 20 && !strings.HasSuffix(strings.TrimSpace(stripPythonComment(lines[header])), ":") {
+			header++
+		}
+		end := header
+		for j := header + 1; j < len(lines); j++ {
+			trimmed := strings.TrimSpace(lines[j])
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			if indentWidth(lines[j]) <= indent {
+				break
+			}
+			end = j
+		}
+
+		signature := strings.Join(lines[i:header+1], " ")
+		signature = strings.TrimSuffix(strings.TrimSpace(stripPythonComment(signature)), ":")
+		items = append(items, outlineItem{
+			signature: compactSignature(signature),
+			line:      i + 1,
+			endLine:   end + 1,
+			depth:     len(stack),
+		})
+		stack = append(stack, open{indent: indent, isClass: strings.HasPrefix(strings.TrimSpace(line), "class")})
+	}
+	return items, nil
+}
+
+func stripPythonComment(line string) string {
+	if i := strings.Index(line, " #"); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+func indentWidth(line string) int {
+	width := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			width++
+		case '\t':
+			width += 8 - width%8
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+var (
+	markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	markdownFence   = regexp.MustCompile("^\\s*(```|~~~)")
+)
+
+// markdownOutline lists the headings of a Markdown file. A section ends
+// before the next heading of the same or a higher level.
+func markdownOutline(content []byte) ([]outlineItem, error) {
+	lines := splitOutlineLines(content)
+	type heading struct {
+		line  int
+		level int
+		text  string
+	}
+	var headings []heading
+	fence := ""
+	minLevel := 6
+	for i, line := range lines {
+		if m := markdownFence.FindStringSubmatch(line); m != nil {
+			if fence == "" {
+				fence = m[1]
+			} else if fence == m[1] {
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+		if m := markdownHeading.FindStringSubmatch(line); m != nil {
+			headings = append(headings, heading{line: i, level: len(m[1]), text: m[2]})
+			minLevel = min(minLevel, len(m[1]))
+		}
+	}
+
+	items := make([]outlineItem, 0, len(headings))
+	for i, h := range headings {
+		end := len(lines) - 1
+		for _, next := range headings[i+1:] {
+			if next.level <= h.level {
+				end = next.line - 1
+				break
+			}
+		}
+		for end > h.line && strings.TrimSpace(lines[end]) == "" {
+			end--
+		}
+		items = append(items, outlineItem{
+			signature: compactSignature(strings.Repeat("#", h.level) + " " + h.text),
+			line:      h.line + 1,
+			endLine:   end + 1,
+			depth:     h.level - minLevel,
+		})
+	}
+	return items, nil
+}
+
+// outlineRule recognizes a declaration of a language delimiting blocks with
+// braces. Rules are matched against lines without their comments.
+type outlineRule struct {
+	re *regexp.Regexp
+	// container declarations, like classes, list the declarations of their
+	// body, the bodies of other declarations are skipped
+	container bool
+	// member rules only apply to the body of a container, like methods
+	member bool
+}
+
+// outlineKeywords are not declarations when a member rule captures them as
+// the name, like in "if (".
+var outlineKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true,
+	"new": true, "else": true, "do": true, "try": true, "throw": true, "super": true, "this": true,
+}
+
+var jsOutlineRules = []outlineRule{
+	{re: regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?class\b`), container: true},
+	{re: regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?(?:namespace|module)\s+[\w.]+`), container: true},
+	{re: regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:async\s+)?function\b`)},
+	{re: regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?(?:interface|type|enum|const\s+enum)\s+\w+`)},
+	{re: regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+[\w$]+\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|[\w$]+\s*=>)`)},
+	{re: regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|async|abstract|override|readonly|declare|get|set)\s+)*\*?(?P<name>#?[\w$]+)\s*(?:<[^>]*>)?\s*\(`), member: true},
+	{re: regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|readonly)\s+)*(?P<name>#?[\w$]+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:\([^)]*\)|[\w$]+)\s*=>`), member: true},
+}
+
+var rustOutlineRules = []outlineRule{
+	{re: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:unsafe\s+)?(?:impl|trait)\b`), container: true},
+	{re: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?mod\s+\w+`), container: true},
+	{re: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:(?:const|async|unsafe|extern\s+"[^"]*")\s+)*fn\s+\w+`)},
+	{re: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:struct|enum|union|type)\s+\w+`)},
+	{re: regexp.MustCompile(`^\s*macro_rules!\s*\w+`)},
+}
+
+var javaOutlineRules = []outlineRule{
+	{re: regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s+)*(?:(?:public|protected|private|static|final|abstract|sealed|non-sealed|strictfp)\s+)*(?:class|interface|enum|record|@interface)\s+\w+`), container: true},
+	{re: regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s+)*(?:(?:public|protected|private|static|final|abstract|synchronized|native|default|strictfp)\s+)*(?:<[^>]*>\s+)?[\w.]+(?:<[^()]*>)?(?:\[\])*\s+(?P<name>\w+)\s*\(`), member: true},
+	{re: regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s+)*(?:public|protected|private)\s+(?P<name>[A-Z]\w*)\s*\(`), member: true},
+}
+
+// braceLine is a line of a file with its comments removed and the depth of
+// the braces around it.
+type braceLine struct {
+	code string
+	// depth is the brace depth at the start of the line
+	depth    int
+	endDepth int
+	maxDepth int
+	// parens is the depth of the open parentheses and brackets at the end of
+	// the line, in the innermost block
+	parens int
+	// inside reports if the line starts in a comment or a string
+	inside bool
+}
+
+// scanBraceLines tracks the braces of a file, skipping comments and the
+// strings delimited by quotes.
+func scanBraceLines(lines []string, quotes string) []braceLine {
+	result := make([]braceLine, len(lines))
+	depth, parens := 0, 0
+	var quote byte
+	inComment := false
+	for i, line := range lines {
+		bl := braceLine{depth: depth, maxDepth: depth, inside: inComment || quote != 0}
+		var code strings.Builder
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+			switch {
+			case inComment:
+				if c == '*' && j+1 < len(line) && line[j+1] == '/' {
+					inComment = false
+					j++
+				}
+				continue
+			case quote != 0:
+				code.WriteByte(c)
+				if c == '\\' && j+1 < len(line) {
+					j++
+					code.WriteByte(line[j])
+				} else if c == quote {
+					quote = 0
+				}
+				continue
+			case c == '/' && j+1 < len(line) && line[j+1] == '/':
+				j = len(line)
+				continue
+			case c == '/' && j+1 < len(line) && line[j+1] == '*':
+				inComment = true
+				j++
+				continue
+			case strings.IndexByte(quotes, c) >= 0:
+				quote = c
+			case c == '\'':
+				// a character literal like 'a' or '\n', otherwise a Rust lifetime
+				if n := charLiteralLength(line[j:]); n > 0 {
+					code.WriteString(line[j : j+n])
+					j += n - 1
+					continue
+				}
+			case c == '{':
+				depth++
+				parens = 0
+				bl.maxDepth = max(bl.maxDepth, depth)
+			case c == '}':
+				depth = max(depth-1, 0)
+				parens = 0
+			case c == '(' || c == '[':
+				parens++
+			case c == ')' || c == ']':
+				parens = max(parens-1, 0)
+			}
+			code.WriteByte(c)
+		}
+		// only template literals span lines
+		if quote != '`' {
+			quote = 0
+		}
+		bl.code = code.String()
+		bl.endDepth = depth
+		bl.parens = parens
+		result[i] = bl
+	}
+	return result
+}
+
+func charLiteralLength(s string) int {
+	if len(s) < 3 {
+		return 0
+	}
+	if s[1] == '\\' {
+		if end := strings.IndexByte(s[2:], '\''); end >= 0 && end < 10 {
+			return end + 3
+		}
+		return 0
+	}
+	_, size := utf8.DecodeRuneInString(s[1:])
+	if 1+size < len(s) && s[1+size] == '\'' {
+		return size + 2
+	}
+	return 0
+}
+
+// braceOutline returns a parser for a language delimiting blocks with braces.
+// Declarations are only looked for at the top level and in the bodies of
+// containers, so statements in function bodies are never mistaken for them.
+func braceOutline(rules []outlineRule, quotes string) outlineParser {
+	return func(content []byte) ([]outlineItem, error) {
+		lines := splitOutlineLines(content)
+		scanned := scanBraceLines(lines, quotes)
+		type open struct {
+			endLine   int
+			depth     int
+			container bool
+		}
+		var stack []open
+		var items []outlineItem
+		for i, l := range scanned {
+			for len(stack) > 0 && stack[len(stack)-1].endLine < i {
+				stack = stack[:len(stack)-1]
+			}
+			if l.inside {
+				continue
+			}
+			inContainer := false
+			depth := 0
+			if len(stack) > 0 {
+				top := stack[len(stack)-1]
+				if !top.container {
+					continue
+				}
+				inContainer = true
+				depth = top.depth + 1
+			}
+			if l.depth != depth {
+				continue
+			}
+
+			for _, rule := range rules {
+				if rule.member && !inContainer {
+					continue
+				}
+				m := rule.re.FindStringSubmatch(l.code)
+				if m == nil {
+					continue
+				}
+				if name := rule.re.SubexpIndex("name"); name > 0 && outlineKeywords[m[name]] {
+					continue
+				}
+				end := braceDeclarationEnd(scanned, i)
+				items = append(items, outlineItem{
+					signature: braceSignature(scanned, i, end),
+					line:      i + 1,
+					endLine:   end + 1,
+					depth:     len(stack),
+				})
+				stack = append(stack, open{endLine: end, depth: l.depth, container: rule.container})
+				break
+			}
+		}
+		return items, nil
+	}
+}
+
+// braceDeclarationEnd returns the line a declaration starting at line start
+// ends at: where its body is closed, or where the statement ends for
+// declarations without a body.
+func braceDeclarationEnd(lines []braceLine, start int) int {
+	depth := lines[start].depth
+	opened := false
+	for j := start; j < len(lines); j++ {
+		l := lines[j]
+		if l.maxDepth > depth {
+			opened = true
+		}
+		if l.endDepth > depth || l.parens > 0 {
+			continue
+		}
+		if opened || (!continuesDeclaration(l.code) && !nextLineContinues(lines, j)) {
+			return j
+		}
+	}
+	return len(lines) - 1
+}
+
+func continuesDeclaration(code string) bool {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return false
+	}
+	for _, suffix := range []string{"=>", "->", " where", " extends", " implements"} {
+		if strings.HasSuffix(" "+code, suffix) {
+			return true
+		}
+	}
+	return strings.ContainsAny(code[len(code)-1:], "=,([:|&+-*/<.")
+}
+
+var continuationPrefixes = []string{"{", "where", "extends", "implements", "throws", ".", "=>", "->", ":", "|", "&"}
+
+func nextLineContinues(lines []braceLine, j int) bool {
+	for _, l := range lines[j+1:] {
+		code := strings.TrimSpace(l.code)
+		if code == "" {
+			continue
+		}
+		for _, prefix := range continuationPrefixes {
+			if strings.HasPrefix(code, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// braceSignature joins the lines of a declaration up to its body.
+func braceSignature(lines []braceLine, start, end int) string {
+	var sb strings.Builder
+	parens := 0
+	for j := start; j <= end && j < start+10; j++ {
+		code := lines[j].code
+		for k := 0; k < len(code); k++ {
+			switch code[k] {
+			case '(', '[':
+				parens++
+			case ')', ']':
+				parens = max(parens-1, 0)
+			case '{':
+				if parens == 0 {
+					return strings.TrimSuffix(compactSignature(sb.String()), ",")
+				}
+			case ';':
+				if parens == 0 {
+					return compactSignature(sb.String())
+				}
+			}
+			sb.WriteByte(code[k])
+		}
+		sb.WriteByte(' ')
+	}
+	return compactSignature(sb.String())
+}
+
+func outlineDescription() string {
+	return `Lists the declarations of a source file (types, functions, methods and classes) with their signatures and line ranges, without reading the whole file.
+
+WHEN TO USE THIS TOOL:
+- Use before reading a large file to find the part you need
+- Use to get an overview of the API of a file
+
+HOW TO USE:
+- Provide the path to the file
+- Read a declaration with the View tool, using its first line minus one as offset and its number of lines as limit
+
+FEATURES:
+- Go files are parsed with the Go parser, with the signatures of functions and methods and the constants and variables
+- Python, JavaScript, TypeScript, Rust and Java files list classes, functions and methods, methods are indented under their class
+- Markdown files list their headings with the range of their sections
+- Works without a language server
+
+LIMITATIONS:
+- Only the extensions listed in the error for other files are supported
+- Declarations nested in function bodies are not listed
+- Outside Go, declarations are recognized line by line and unusual formatting can be missed
+- Maximum file size is 5MB
+
+TIPS:
+- Use the Symbols tool for languages with a language server but no outline support
+- Use the Grep tool to find which file declares something, then outline that file`
+}
+
+func NewOutlineTool() BaseTool {
+	return &outlineTool{}
+}
@@ -0,0 +1,327 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutlineParsers(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		source   string
+		expected string
+	}{
+		{
+			name: "go",
+			file: "a.go",
+			source: `package shapes
+
+import "fmt"
+
+// Shape has an area.
+type Shape interface {
+	Area() float64
+}
+
+type (
+	Square struct {
+		Side float64
+	}
+	ID = string
+)
+
+const (
+	A = 1
+	B = 2
+)
+
+func (s *Square) Area() float64 {
+	return s.Side * s.Side
+}
+
+func Map[T, U any](
+	items []T,
+	fn func(T) U,
+) []U {
+	return nil
+}
+
+func main() { fmt.Println("{") }
+`,
+			expected: `type Shape interface (lines 6-8)
+type Square struct (lines 11-13)
+type ID = string (line 14)
+const A, B (lines 17-20)
+func (s *Square) Area() float64 (lines 22-24)
+func Map[T, U any](items []T, fn func(T) U) []U (lines 26-31)
+func main() (line 33)`,
+		},
+		{
+			name: "python",
+			file: "a.py",
+			source: `import os
+
+class Greeter:
+    """A greeter.
+
+    def fake(): not a function
+    """
+
+    def __init__(self, name):
+        self.name = name
+
+    async def greet(self,
+                    loud=False):  # comment
+        def inner():
+            pass
+        return inner
+
+def top(x: int) -> int:
+    return x
+
+
+@decorator
+def last():
+    pass
+`,
+			expected: `class Greeter (lines 3-16)
+  def __init__(self, name) (lines 9-10)
+  async def greet(self, loud=False) (lines 12-16)
+def top(x: int) -> int (lines 18-19)
+def last() (lines 23-24)`,
+		},
+		{
+			name: "typescript",
+			file: "a.ts",
+			source: `import x from "y";
+
+export interface Props {
+  name: string;
+}
+
+export type ID = string;
+
+/* function commented() {} */
+export class Widget extends Base implements Thing {
+  private count = 0;
+  static create(props: Props): Widget {
+    if (props) {
+      return new Widget();
+    }
+  }
+  handle = (e: Event) => {
+    console.log("}");
+  };
+  get value(): number { return this.count; }
+}
+
+export async function load({ id, name }: Props) {
+  const s = ` + "`" + `template {
+  ${id}` + "`" + `;
+  function nested() {}
+}
+
+const add = (a: number, b: number) => a + b;
+
+export default function () {}
+`,
+			expected: `export interface Props (lines 3-5)
+export type ID = string (line 7)
+export class Widget extends Base implements Thing (lines 10-21)
+  static create(props: Props): Widget (lines 12-16)
+  handle = (e: Event) => (lines 17-19)
+  get value(): number (line 20)
+export async function load({ id, name }: Props) (lines 23-27)
+const add = (a: number, b: number) => a + b (line 29)
+export default function () (line 31)`,
+		},
+		{
+			name: "rust",
+			file: "a.rs",
+			source: `use std::fmt;
+
+pub struct Point<'a> {
+    name: &'a str,
+}
+
+impl<'a> fmt::Display for Point<'a> {
+    fn fmt(&self, f: &mut fmt::Formatter) -> fmt::Result {
+        let c = '{';
+        write!(f, "{}", self.name)
+    }
+}
+
+pub fn parse<T>(input: &str) -> Option<T>
+where
+    T: Default,
+{
+    None
+}
+
+mod tests;
+
+macro_rules! square {
+    ($x:expr) => { $x * $x };
+}
+`,
+			expected: `pub struct Point<'a> (lines 3-5)
+impl<'a> fmt::Display for Point<'a> (lines 7-12)
+  fn fmt(&self, f: &mut fmt::Formatter) -> fmt::Result (lines 8-11)
+pub fn parse<T>(input: &str) -> Option<T> where T: Default (lines 14-19)
+mod tests (line 21)
+macro_rules! square (lines 23-25)`,
+		},
+		{
+			name: "java",
+			file: "A.java",
+			source: `package a;
+
+@Service
+public class A<T> extends B {
+    private final List<String> names = new ArrayList<>();
+
+    public A(String name) {
+        super(name);
+    }
+
+    @Override
+    public static <K> Map<K, List<T>> group(List<T> items)
+        throws IOException {
+        if (items == null) { return null; }
+        return null;
+    }
+
+    enum Color { RED, GREEN }
+
+    abstract void run();
+}
+`,
+			expected: `public class A<T> extends B (lines 4-21)
+  public A(String name) (lines 7-9)
+  public static <K> Map<K, List<T>> group(List<T> items) throws IOException (lines 12-16)
+  enum Color (line 18)
+  abstract void run() (line 20)`,
+		},
+		{
+			name: "markdown",
+			file: "a.md",
+			source: `# Title
+
+Intro.
+
+## Install
+
+` + "`" + `` + "`" + `` + "`" + `sh
+# not a heading
+` + "`" + `` + "`" + `` + "`" + `
+
+### From source
+
+## Usage
+
+Text.
+`,
+			expected: `# Title (lines 1-15)
+  ## Install (lines 5-11)
+    ### From source (line 11)
+  ## Usage (lines 13-15)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parse, ok := outlineParsers[filepath.Ext(tt.file)]
+			require.True(t, ok)
+			items, err := parse([]byte(tt.source))
+			require.NoError(t, err)
+			output := formatOutline(tt.file, 0, items)
+			assert.Equal(t, tt.expected, strings.TrimPrefix(output, tt.file+" (0 lines)\n\n"))
+		})
+	}
+}
+
+func TestOutlineTool(t *testing.T) {
+	dir := t.TempDir()
+	oldWd := viper.GetString("wd")
+	viper.Set("wd", dir)
+	t.Cleanup(func() {
+		viper.Set("wd", oldWd)
+	})
+
+	files := map[string]string{
+		"shapes.go":  navigationTestSource,
+		"broken.go":  "package broken\n\nfunc ok() {}\n\nfunc broken( {\n",
+		"empty.py":   "x = 1\n",
+		"notes.txt":  "notes\n",
+		"sub/app.ts": "export function main() {\n}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	tool := NewOutlineTool()
+	run := func(t *testing.T, path string) ToolResponse {
+		input, err := json.Marshal(OutlineParams{FilePath: path})
+		require.NoError(t, err)
+		response, err := tool.Run(context.Background(), ToolCall{Input: string(input)})
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("relative path", func(t *testing.T) {
+		response := run(t, "shapes.go")
+		require.False(t, response.IsError, response.Content)
+		assert.Equal(t, filepath.Join(dir, "shapes.go")+` (17 lines)
+
+type Shape interface (lines 3-5)
+type Square struct (lines 7-9)
+func (s *Square) Area() float64 (lines 11-13)
+func NewSquare(side float64) *Square (lines 15-17)`, response.Content)
+	})
+
+	t.Run("absolute path", func(t *testing.T) {
+		response := run(t, filepath.Join(dir, "sub", "app.ts"))
+		require.False(t, response.IsError, response.Content)
+		assert.Contains(t, response.Content, "export function main() (lines 1-2)")
+	})
+
+	t.Run("syntax errors", func(t *testing.T) {
+		response := run(t, "broken.go")
+		require.False(t, response.IsError, response.Content)
+		assert.Contains(t, response.Content, "func ok() (line 3)")
+		assert.Contains(t, response.Content, "the outline may be incomplete")
+	})
+
+	t.Run("no declarations", func(t *testing.T) {
+		response := run(t, "empty.py")
+		assert.False(t, response.IsError)
+		assert.Equal(t, "No declarations found in "+filepath.Join(dir, "empty.py")+" (1 line)", response.Content)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			path  string
+			error string
+		}{
+			{path: "", error: "file_path is required"},
+			{path: "missing.go", error: "file not found"},
+			{path: "sub", error: "path is a directory"},
+			{path: "notes.txt", error: "outlines are not supported for notes.txt"},
+		}
+		for _, tt := range tests {
+			response := run(t, tt.path)
+			assert.True(t, response.IsError, tt.path)
+			assert.Contains(t, response.Content, tt.error)
+		}
+	})
+}
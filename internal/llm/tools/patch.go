@@ -0,0 +1,621 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
+	"github.com/imnulhaqueruman/opencode-poc/internal/lsp"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+)
+
+type patchTool struct {
+	lspManager *lsp.Manager
+	files      filerecord.Service
+}
+
+const (
+	PatchToolName = "apply_patch"
+
+	// maxPatchFuzz is the number of context lines that may be dropped from
+	// each end of a hunk when its context does not match exactly
+	maxPatchFuzz = 2
+)
+
+type PatchParams struct {
+	Patch string `json:"patch"`
+}
+
+type PatchPermissionsParams struct {
+	Files []string `json:"files"`
+	Diff  string   `json:"diff"`
+}
+
+type patchHunk struct {
+	header   string
+	oldStart int
+	newStart int
+	lines    []string // hunk body, each line keeps its ' ', '-' or '+' prefix
+	oldNoEOL bool
+	newNoEOL bool
+}
+
+type filePatch struct {
+	oldPath  string // empty when the file is created
+	newPath  string // empty when the file is deleted
+	isNew    bool
+	isDelete bool
+	hunks    []patchHunk
+}
+
+// patchChange is the result of applying a filePatch in memory.
+type patchChange struct {
+	patch      filePatch
+	oldPath    string
+	newPath    string
+	newContent string
+	format     textFormat
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+func (p *patchTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        PatchToolName,
+		Description: patchDescription(),
+		Parameters: map[string]any{
+			"patch": map[string]any{
+				"type":        "string",
+				"description": "The unified diff to apply, it can contain changes to multiple files",
+			},
+		},
+		Required: []string{"patch"},
+	}
+}
+
+// Run implements Tool.
+func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params PatchParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if strings.TrimSpace(params.Patch) == "" {
+		return NewTextErrorResponse("patch is required"), nil
+	}
+
+	patches, err := parsePatch(params.Patch)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing patch: %s", err)), nil
+	}
+
+	var changes []patchChange
+	var failures []string
+	for _, fp := range patches {
+		change, err := p.preparePatchChange(ctx, fp)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		changes = append(changes, change)
+	}
+	if len(failures) > 0 {
+		return NewTextErrorResponse(fmt.Sprintf("patch was not applied, no files were changed:\n\n%s", strings.Join(failures, "\n\n"))), nil
+	}
+
+	files := make([]string, 0, len(changes))
+	for _, c := range changes {
+		files = append(files, describePatchChange(c))
+	}
+
+	perm := permission.Default.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   GetSessionFromContext(ctx),
+			Path:        config.WorkingDirectory(),
+			ToolName:    PatchToolName,
+			Action:      "apply",
+			Description: fmt.Sprintf("Apply patch to %d files", len(changes)),
+			Params: PatchPermissionsParams{
+				Files: files,
+				Diff:  params.Patch,
+			},
+		},
+	)
+	if !perm {
+		return NewTextErrorResponse("permission denied"), nil
+	}
+
+	var touched []string
+	for i, c := range changes {
+		if err := p.writePatchChange(ctx, c); err != nil {
+			path := c.newPath
+			if c.patch.isDelete {
+				path = c.oldPath
+			}
+			// the files are written one by one, the agent has to know which
+			// ones already changed
+			applied := "No files were changed."
+			if i > 0 {
+				applied = fmt.Sprintf("These changes were already applied, the rest of the patch was not:\n%s", strings.Join(files[:i], "\n"))
+			}
+			return NewTextErrorResponse(fmt.Sprintf("error applying patch to %s: %s\n\n%s", path, err, applied)), nil
+		}
+		// removed files are synced too, so language servers close them
+		if c.oldPath != "" && c.oldPath != c.newPath {
+			touched = append(touched, c.oldPath)
+		}
+		if c.newPath != "" {
+			touched = append(touched, c.newPath)
+		}
+	}
+
+	response := NewTextResponse(fmt.Sprintf("Patch applied successfully:\n%s", strings.Join(files, "\n")))
+	return appendDiagnostics(ctx, p.lspManager, response, touched...), nil
+}
+
+func describePatchChange(c patchChange) string {
+	switch {
+	case c.patch.isNew:
+		return "created " + c.newPath
+	case c.patch.isDelete:
+		return "deleted " + c.oldPath
+	case c.oldPath != c.newPath:
+		return fmt.Sprintf("renamed %s -> %s", c.oldPath, c.newPath)
+	default:
+		return "modified " + c.newPath
+	}
+}
+
+func resolvePatchPath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(config.WorkingDirectory(), path)
+}
+
+func (p *patchTool) preparePatchChange(ctx context.Context, fp filePatch) (patchChange, error) {
+	change := patchChange{
+		patch:   fp,
+		oldPath: resolvePatchPath(fp.oldPath),
+		newPath: resolvePatchPath(fp.newPath),
+		format:  textFormat{mode: defaultFileMode},
+	}
+	for _, path := range []string{change.oldPath, change.newPath} {
+		if path == "" {
+			continue
+		}
+		if err := checkFileAccess(path); err != nil {
+			return change, err
+		}
+	}
+
+	if fp.isNew {
+		if _, err := os.Stat(change.newPath); err == nil {
+			return change, fmt.Errorf("%s: file already exists", change.newPath)
+		}
+		var lines []string
+		noEOL := false
+		for _, h := range fp.hunks {
+			for _, line := range h.lines {
+				if strings.HasPrefix(line, "+") {
+					lines = append(lines, line[1:])
+				}
+			}
+			noEOL = h.newNoEOL
+		}
+		change.newContent = strings.Join(lines, "\n")
+		if len(lines) > 0 && !noEOL {
+			change.newContent += "\n"
+		}
+		return change, nil
+	}
+
+	info, err := os.Stat(change.oldPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return change, fmt.Errorf("%s: file not found", change.oldPath)
+		}
+		return change, fmt.Errorf("%s: failed to access file: %w", change.oldPath, err)
+	}
+	if info.IsDir() {
+		return change, fmt.Errorf("%s: path is a directory, not a file", change.oldPath)
+	}
+	if err := checkFileFresh(ctx, p.files, change.oldPath); err != nil {
+		return change, fmt.Errorf("%s: %w", change.oldPath, err)
+	}
+	if !fp.isDelete && change.newPath != change.oldPath {
+		if _, err := os.Stat(change.newPath); err == nil {
+			return change, fmt.Errorf("%s: cannot rename, destination already exists", change.newPath)
+		}
+	}
+
+	content, format, err := readFileText(change.oldPath)
+	if err != nil {
+		return change, fmt.Errorf("%s: failed to read file: %w", change.oldPath, err)
+	}
+	// the patch decides of the final newline
+	format.finalNewline = nil
+	change.format = format
+
+	newContent, failures := applyHunks(content, fp.hunks)
+	if len(failures) > 0 {
+		return change, fmt.Errorf("%s:\n%s", change.oldPath, strings.Join(failures, "\n"))
+	}
+	change.newContent = newContent
+	return change, nil
+}
+
+func (p *patchTool) writePatchChange(ctx context.Context, c patchChange) error {
+	sessionID := GetSessionFromContext(ctx)
+	if c.patch.isDelete {
+		if _, err := moveToTrash(c.oldPath); err != nil {
+			return fmt.Errorf("failed to move the file to the trash: %w", err)
+		}
+		_ = p.files.Forget(sessionID, c.oldPath)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.newPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create parent directories: %w", err)
+	}
+	written, err := writeFileText(c.newPath, c.newContent, c.format)
+	if err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if !c.patch.isNew && c.oldPath != c.newPath {
+		if err := os.Remove(c.oldPath); err != nil {
+			return fmt.Errorf("failed to remove renamed file: %w", err)
+		}
+		_ = p.files.Forget(sessionID, c.oldPath)
+	}
+	recordFileWrite(ctx, p.files, c.newPath, written)
+	return nil
+}
+
+func stripPatchPath(path string) string {
+	// drop timestamps some diff tools append after a tab
+	if idx := strings.Index(path, "\t"); idx >= 0 {
+		path = path[:idx]
+	}
+	path = strings.TrimSpace(path)
+	if path == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		return path[2:]
+	}
+	return path
+}
+
+// parsePatch parses a unified diff, with or without git extended headers,
+// into per file patches.
+func parsePatch(patch string) ([]filePatch, error) {
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+	var patches []filePatch
+	var current *filePatch
+
+	flush := func() {
+		if current != nil {
+			patches = append(patches, *current)
+			current = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			current = &filePatch{}
+			if fields := strings.Fields(strings.TrimPrefix(line, "diff --git ")); len(fields) == 2 {
+				current.oldPath = stripPatchPath(fields[0])
+				current.newPath = stripPatchPath(fields[1])
+			}
+		case strings.HasPrefix(line, "new file mode") && current != nil:
+			current.isNew = true
+			current.oldPath = ""
+		case strings.HasPrefix(line, "deleted file mode") && current != nil:
+			current.isDelete = true
+			current.newPath = ""
+		case strings.HasPrefix(line, "rename from ") && current != nil:
+			current.oldPath = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to ") && current != nil:
+			current.newPath = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if current == nil || len(current.hunks) > 0 {
+				flush()
+				current = &filePatch{}
+			}
+			current.oldPath = stripPatchPath(strings.TrimPrefix(line, "--- "))
+			current.newPath = stripPatchPath(strings.TrimPrefix(lines[i+1], "+++ "))
+			current.isNew = current.oldPath == ""
+			current.isDelete = current.newPath == ""
+			i++
+		case strings.HasPrefix(line, "@@"):
+			if current == nil {
+				return nil, fmt.Errorf("hunk %q found before any file header", line)
+			}
+			hunk, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			current.hunks = append(current.hunks, hunk)
+			i = next - 1
+		}
+	}
+	flush()
+
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no file changes found, the patch must be in unified diff format")
+	}
+	for _, p := range patches {
+		if p.oldPath == "" && p.newPath == "" {
+			return nil, fmt.Errorf("missing file path in patch")
+		}
+		if p.isNew && p.isDelete {
+			return nil, fmt.Errorf("invalid patch for %s: file is both created and deleted", p.newPath)
+		}
+		if !p.isNew && !p.isDelete && p.oldPath == p.newPath && len(p.hunks) == 0 {
+			return nil, fmt.Errorf("no hunks found for %s", p.newPath)
+		}
+	}
+	return patches, nil
+}
+
+// parseHunk parses the hunk starting at lines[start] and returns it together
+// with the index of the first line after it.
+func parseHunk(lines []string, start int) (patchHunk, int, error) {
+	header := lines[start]
+	match := hunkHeaderRegex.FindStringSubmatch(header)
+	if match == nil {
+		return patchHunk{}, 0, fmt.Errorf("invalid hunk header %q", header)
+	}
+	count := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	hunk := patchHunk{header: header}
+	hunk.oldStart, _ = strconv.Atoi(match[1])
+	hunk.newStart, _ = strconv.Atoi(match[3])
+	oldRemaining := count(match[2])
+	newRemaining := count(match[4])
+
+	i := start + 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, `\`) {
+			// "\ No newline at end of file" applies to the previous line
+			if len(hunk.lines) > 0 {
+				switch hunk.lines[len(hunk.lines)-1][0] {
+				case '-':
+					hunk.oldNoEOL = true
+				case '+':
+					hunk.newNoEOL = true
+				default:
+					hunk.oldNoEOL = true
+					hunk.newNoEOL = true
+				}
+			}
+			continue
+		}
+		if oldRemaining <= 0 && newRemaining <= 0 {
+			break
+		}
+		if line == "" {
+			// some tools strip the space of empty context lines
+			line = " "
+		}
+		switch line[0] {
+		case ' ':
+			oldRemaining--
+			newRemaining--
+		case '-':
+			oldRemaining--
+		case '+':
+			newRemaining--
+		default:
+			return hunk, i, nil
+		}
+		hunk.lines = append(hunk.lines, line)
+	}
+	return hunk, i, nil
+}
+
+// applyHunks applies the hunks to content in order. Context that does not
+// match exactly is located with increasing tolerance, and every hunk that
+// cannot be placed is reported.
+func applyHunks(content string, hunks []patchHunk) (string, []string) {
+	trailingNewline := strings.HasSuffix(content, "\n")
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = []string{}
+	}
+
+	var failures []string
+	offset := 0
+	minPos := 0
+	for idx, hunk := range hunks {
+		oldLines, newLines := splitHunk(hunk.lines)
+		expected := hunk.oldStart - 1 + offset
+		if hunk.oldStart == 0 {
+			expected = 0
+		}
+
+		pos, fuzz, found := -1, 0, false
+		for fuzz = 0; fuzz <= maxPatchFuzz && !found; fuzz++ {
+			trimmed, front, back := trimHunkContext(hunk.lines, fuzz)
+			if fuzz > 0 && front == 0 && back == 0 {
+				break
+			}
+			searchOld, searchNew := splitHunk(trimmed)
+			if p := locateLines(lines, searchOld, expected+front, minPos); p >= 0 {
+				pos, oldLines, newLines, found = p, searchOld, searchNew, true
+			}
+		}
+		if !found {
+			failures = append(failures, describeHunkFailure(idx, hunk, lines, oldLines, expected))
+			continue
+		}
+
+		result := make([]string, 0, len(lines)-len(oldLines)+len(newLines))
+		result = append(result, lines[:pos]...)
+		result = append(result, newLines...)
+		result = append(result, lines[pos+len(oldLines):]...)
+		lines = result
+
+		offset = pos + len(newLines) - (hunk.oldStart - 1 + len(oldLines))
+		if hunk.oldStart == 0 {
+			offset = 0
+		}
+		minPos = pos + len(newLines)
+
+		if pos+len(newLines) == len(lines) {
+			if hunk.newNoEOL {
+				trailingNewline = false
+			} else if hunk.oldNoEOL {
+				trailingNewline = true
+			}
+		}
+	}
+	if len(failures) > 0 {
+		return "", failures
+	}
+
+	result := strings.Join(lines, "\n")
+	if trailingNewline && len(lines) > 0 {
+		result += "\n"
+	}
+	return result, nil
+}
+
+func splitHunk(hunkLines []string) ([]string, []string) {
+	var oldLines, newLines []string
+	for _, line := range hunkLines {
+		switch line[0] {
+		case ' ':
+			oldLines = append(oldLines, line[1:])
+			newLines = append(newLines, line[1:])
+		case '-':
+			oldLines = append(oldLines, line[1:])
+		case '+':
+			newLines = append(newLines, line[1:])
+		}
+	}
+	return oldLines, newLines
+}
+
+// trimHunkContext drops up to fuzz context lines from each end of a hunk and
+// reports how many were dropped from the front and the back.
+func trimHunkContext(hunkLines []string, fuzz int) ([]string, int, int) {
+	front, back := 0, 0
+	for front < fuzz && front < len(hunkLines) && hunkLines[front][0] == ' ' {
+		front++
+	}
+	for back < fuzz && len(hunkLines)-back-1 > front && hunkLines[len(hunkLines)-back-1][0] == ' ' {
+		back++
+	}
+	return hunkLines[front : len(hunkLines)-back], front, back
+}
+
+// locateLines finds where target occurs in lines at or after minPos, preferring
+// the position closest to expected. An exact match is tried first, then one
+// ignoring trailing whitespace, then one ignoring all surrounding whitespace.
+func locateLines(lines, target []string, expected, minPos int) int {
+	if len(target) == 0 {
+		return max(minPos, min(expected, len(lines)))
+	}
+	comparators := []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		func(a, b string) bool { return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t") },
+		func(a, b string) bool { return strings.TrimSpace(a) == strings.TrimSpace(b) },
+	}
+	last := len(lines) - len(target)
+	for _, equal := range comparators {
+		matchesAt := func(pos int) bool {
+			if pos < minPos || pos > last {
+				return false
+			}
+			for i, t := range target {
+				if !equal(lines[pos+i], t) {
+					return false
+				}
+			}
+			return true
+		}
+		for distance := 0; distance <= len(lines); distance++ {
+			if matchesAt(expected - distance) {
+				return expected - distance
+			}
+			if distance > 0 && matchesAt(expected+distance) {
+				return expected + distance
+			}
+		}
+	}
+	return -1
+}
+
+func describeHunkFailure(idx int, hunk patchHunk, lines, oldLines []string, expected int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "  hunk %d (%s) failed: could not find the expected lines near line %d.\n", idx+1, hunk.header, expected+1)
+	sb.WriteString("  expected:\n")
+	for _, l := range oldLines {
+		sb.WriteString("    |" + l + "\n")
+	}
+	start := max(0, min(expected, len(lines)))
+	end := min(len(lines), start+max(len(oldLines), 1))
+	if start < end {
+		fmt.Fprintf(&sb, "  file has at line %d:\n", start+1)
+		for _, l := range lines[start:end] {
+			sb.WriteString("    |" + l + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func patchDescription() string {
+	return `Applies a unified diff patch that can change multiple files at once, including creating, deleting and renaming files.
+
+WHEN TO USE THIS TOOL:
+- Use when you need to make related changes across several files
+- Helpful when the change is easiest to express as a diff
+- Prefer the Edit or MultiEdit tools for simple changes to a single file
+
+HOW TO USE:
+- Provide the patch in unified diff format, as produced by "diff -u" or "git diff"
+- Each file starts with "--- a/path" and "+++ b/path" lines followed by one or more hunks
+- Hunks start with "@@ -old_start,old_count +new_start,new_count @@"
+- Lines starting with a space are context, "-" are removed and "+" are added
+- Paths can be absolute or relative to the working directory
+
+SPECIAL CASES:
+- To create a file use "--- /dev/null" and "+++ b/path"
+- To delete a file use "--- a/path" and "+++ /dev/null" with a hunk removing all of its lines
+- To rename a file use git headers: "diff --git a/old b/new", "rename from old" and "rename to new"
+
+FEATURES:
+- Context lines are matched with tolerance for shifted line numbers and whitespace differences
+- The whole patch is shown in a single permission request
+- Deleted files are moved to the trash, they can be restored with the Move tool
+- If any hunk does not apply nothing is written, and the failing hunks are reported with the lines found in the file
+
+LIMITATIONS:
+- Files you modify, delete or rename must be read with the View tool first
+- Binary patches are not supported
+- Files are written one by one, if writing a file fails the changes already applied are listed in the error
+
+TIPS:
+- Include 3 lines of context around each change
+- If a hunk fails, view the reported region of the file and retry with corrected context`
+}
+
+func NewPatchTool(lspManager *lsp.Manager, files filerecord.Service) BaseTool {
+	return &patchTool{lspManager: lspManager, files: files}
+}
@@ -0,0 +1,312 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchTool_Info(t *testing.T) {
+	tool := NewPatchTool(nil, nil)
+	info := tool.Info()
+
+	assert.Equal(t, PatchToolName, info.Name)
+	assert.NotEmpty(t, info.Description)
+	assert.Contains(t, info.Parameters, "patch")
+	assert.Contains(t, info.Required, "patch")
+}
+
+func TestParsePatch(t *testing.T) {
+	t.Run("parses multiple files", func(t *testing.T) {
+		patches, err := parsePatch(`--- a/one.txt
++++ b/one.txt
+@@ -1,2 +1,2 @@
+ keep
+-old
++new
+--- /dev/null
++++ b/two.txt
+@@ -0,0 +1 @@
++created
+--- a/three.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+`)
+		require.NoError(t, err)
+		require.Len(t, patches, 3)
+
+		assert.Equal(t, "one.txt", patches[0].oldPath)
+		assert.Equal(t, "one.txt", patches[0].newPath)
+		require.Len(t, patches[0].hunks, 1)
+		assert.Equal(t, []string{" keep", "-old", "+new"}, patches[0].hunks[0].lines)
+
+		assert.True(t, patches[1].isNew)
+		assert.Equal(t, "two.txt", patches[1].newPath)
+
+		assert.True(t, patches[2].isDelete)
+		assert.Equal(t, "three.txt", patches[2].oldPath)
+	})
+
+	t.Run("parses git renames", func(t *testing.T) {
+		patches, err := parsePatch(`diff --git a/old.txt b/new.txt
+similarity index 100%
+rename from old.txt
+rename to new.txt
+`)
+		require.NoError(t, err)
+		require.Len(t, patches, 1)
+		assert.Equal(t, "old.txt", patches[0].oldPath)
+		assert.Equal(t, "new.txt", patches[0].newPath)
+	})
+
+	t.Run("rejects text without file changes", func(t *testing.T) {
+		_, err := parsePatch("just some text")
+		assert.Error(t, err)
+	})
+}
+
+func TestApplyHunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		patch    string
+		expected string
+		failure  string
+	}{
+		{
+			name:     "applies at the stated line",
+			content:  "a\nb\nc\n",
+			patch:    "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c",
+			expected: "a\nB\nc\n",
+		},
+		{
+			name:     "applies when lines have shifted",
+			content:  "x\ny\nz\na\nb\nc\n",
+			patch:    "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c",
+			expected: "x\ny\nz\na\nB\nc\n",
+		},
+		{
+			name:     "tolerates whitespace differences in context",
+			content:  "func f() {\n\treturn 1\n}\n",
+			patch:    "@@ -1,3 +1,3 @@\n func f() {\n-    return 1\n+\treturn 2\n }",
+			expected: "func f() {\n\treturn 2\n}\n",
+		},
+		{
+			name:     "drops mismatched outer context",
+			content:  "changed\na\nb\nc\nalso changed\n",
+			patch:    "@@ -1,5 +1,5 @@\n first\n a\n-b\n+B\n c\n last",
+			expected: "changed\na\nB\nc\nalso changed\n",
+		},
+		{
+			name:     "applies several hunks in order",
+			content:  "1\n2\n3\n4\n5\n6\n7\n8\n",
+			patch:    "@@ -1,2 +1,3 @@\n 1\n+1.5\n 2\n@@ -7,2 +8,2 @@\n 7\n-8\n+eight",
+			expected: "1\n1.5\n2\n3\n4\n5\n6\n7\neight\n",
+		},
+		{
+			name:     "keeps a missing trailing newline",
+			content:  "a\nb",
+			patch:    "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file",
+			expected: "a\nc",
+		},
+		{
+			name:    "reports hunks that do not apply",
+			content: "a\nb\nc\n",
+			patch:   "@@ -1,3 +1,3 @@\n q\n-r\n+R\n s",
+			failure: "hunk 1 (@@ -1,3 +1,3 @@) failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches, err := parsePatch("--- a/file\n+++ b/file\n" + tt.patch)
+			require.NoError(t, err)
+			require.Len(t, patches, 1)
+
+			result, failures := applyHunks(tt.content, patches[0].hunks)
+			if tt.failure != "" {
+				require.Len(t, failures, 1)
+				assert.Contains(t, failures[0], tt.failure)
+				assert.Contains(t, failures[0], "file has at line 1")
+				return
+			}
+			assert.Empty(t, failures)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestPatchTool_Run(t *testing.T) {
+	origPermission := permission.Default
+	defer func() {
+		permission.Default = origPermission
+	}()
+	// deleted files go to the trash of the workspace
+	workspace := setupWorkspace(t)
+	files := newMockFileRecordService()
+
+	runPatch := func(t *testing.T, patch string) ToolResponse {
+		paramsJSON, err := json.Marshal(PatchParams{Patch: patch})
+		require.NoError(t, err)
+
+		response, err := NewPatchTool(nil, files).Run(context.Background(), ToolCall{
+			Name:  PatchToolName,
+			Input: string(paramsJSON),
+		})
+		require.NoError(t, err)
+		return response
+	}
+
+	writeAndRead := func(t *testing.T, path, content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		recordFileRead(context.Background(), files, path)
+	}
+
+	t.Run("modifies, creates, deletes and renames files", func(t *testing.T) {
+		permission.Default = newMockPermissionService(true)
+		dir := filepath.Join(workspace, "all")
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		modified := filepath.Join(dir, "modified.txt")
+		deleted := filepath.Join(dir, "deleted.txt")
+		renamed := filepath.Join(dir, "renamed.txt")
+		created := filepath.Join(dir, "sub", "created.txt")
+		target := filepath.Join(dir, "target.txt")
+		writeAndRead(t, modified, "one\ntwo\n")
+		writeAndRead(t, deleted, "bye\n")
+		writeAndRead(t, renamed, "same\n")
+
+		response := runPatch(t, "--- "+modified+"\n+++ "+modified+"\n@@ -1,2 +1,2 @@\n one\n-two\n+2\n"+
+			"--- /dev/null\n+++ "+created+"\n@@ -0,0 +1 @@\n+hello\n"+
+			"--- "+deleted+"\n+++ /dev/null\n@@ -1 +0,0 @@\n-bye\n"+
+			"diff --git a/"+renamed+" b/"+target+"\nrename from "+renamed+"\nrename to "+target+"\n")
+		assert.False(t, response.IsError, response.Content)
+
+		content, err := os.ReadFile(modified)
+		require.NoError(t, err)
+		assert.Equal(t, "one\n2\n", string(content))
+
+		content, err = os.ReadFile(created)
+		require.NoError(t, err)
+		assert.Equal(t, "hello\n", string(content))
+
+		content, err = os.ReadFile(target)
+		require.NoError(t, err)
+		assert.Equal(t, "same\n", string(content))
+
+		assert.NoFileExists(t, deleted)
+		assert.NoFileExists(t, renamed)
+		trashed, err := filepath.Glob(filepath.Join(workspace, ".termai/trash/*/all/deleted.txt"))
+		require.NoError(t, err)
+		assert.Len(t, trashed, 1)
+	})
+
+	t.Run("forgets deleted and renamed files", func(t *testing.T) {
+		permission.Default = newMockPermissionService(true)
+		dir := filepath.Join(workspace, "watched")
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		deleted := filepath.Join(dir, "deleted.txt")
+		renamed := filepath.Join(dir, "renamed.txt")
+		target := filepath.Join(dir, "target.txt")
+		external := filepath.Join(dir, "external.txt")
+		writeAndRead(t, deleted, "bye\n")
+		writeAndRead(t, renamed, "same\n")
+		writeAndRead(t, external, "before\n")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		watcher, err := NewFileWatcher(ctx, dir, files)
+		require.NoError(t, err)
+		defer watcher.Close()
+		events := watcher.Subscribe(ctx)
+
+		response := runPatch(t, "--- "+deleted+"\n+++ /dev/null\n@@ -1 +0,0 @@\n-bye\n"+
+			"diff --git a/"+renamed+" b/"+target+"\nrename from "+renamed+"\nrename to "+target+"\n")
+		require.False(t, response.IsError, response.Content)
+		_, err = files.Get("", deleted)
+		assert.ErrorIs(t, err, filerecord.ErrNotRecorded)
+		_, err = files.Get("", renamed)
+		assert.ErrorIs(t, err, filerecord.ErrNotRecorded)
+
+		// a change made outside afterwards shows the watcher has seen the
+		// patch, the events of a directory come in order
+		require.NoError(t, os.WriteFile(external, []byte("after\n"), 0o644))
+		timeout := time.After(5 * time.Second)
+		for waiting := true; waiting; {
+			select {
+			case event := <-events:
+				waiting = event.Payload.Path != external
+			case <-timeout:
+				t.Fatal("timed out waiting for the change")
+			}
+		}
+		assert.Equal(t, []ExternalChange{{Path: external}}, watcher.TakeExternalChanges(""))
+	})
+
+	t.Run("does not write anything when a hunk fails", func(t *testing.T) {
+		permission.Default = newMockPermissionService(true)
+		dir := t.TempDir()
+		good := filepath.Join(dir, "good.txt")
+		bad := filepath.Join(dir, "bad.txt")
+		writeAndRead(t, good, "a\nb\n")
+		writeAndRead(t, bad, "c\nd\n")
+
+		response := runPatch(t, "--- "+good+"\n+++ "+good+"\n@@ -1,2 +1,2 @@\n a\n-b\n+B\n"+
+			"--- "+bad+"\n+++ "+bad+"\n@@ -1,2 +1,2 @@\n x\n-y\n+Y\n")
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, bad)
+		assert.Contains(t, response.Content, "hunk 1")
+
+		content, err := os.ReadFile(good)
+		require.NoError(t, err)
+		assert.Equal(t, "a\nb\n", string(content))
+	})
+
+	t.Run("lists the files written before a failed write", func(t *testing.T) {
+		permission.Default = newMockPermissionService(true)
+		dir := t.TempDir()
+		written := filepath.Join(dir, "written.txt")
+		blocker := filepath.Join(dir, "blocker")
+		writeAndRead(t, written, "a\n")
+		require.NoError(t, os.WriteFile(blocker, []byte("not a directory\n"), 0o644))
+		created := filepath.Join(blocker, "created.txt")
+
+		response := runPatch(t, "--- "+written+"\n+++ "+written+"\n@@ -1 +1 @@\n-a\n+b\n"+
+			"--- /dev/null\n+++ "+created+"\n@@ -0,0 +1 @@\n+hello\n")
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "error applying patch to "+created)
+		assert.Contains(t, response.Content, "already applied, the rest of the patch was not:\nmodified "+written)
+	})
+
+	t.Run("requires files to be read first", func(t *testing.T) {
+		permission.Default = newMockPermissionService(true)
+		path := filepath.Join(t.TempDir(), "unread.txt")
+		require.NoError(t, os.WriteFile(path, []byte("a\n"), 0o644))
+
+		response := runPatch(t, "--- "+path+"\n+++ "+path+"\n@@ -1 +1 @@\n-a\n+b\n")
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "you must read the file")
+	})
+
+	t.Run("handles permission denied", func(t *testing.T) {
+		permission.Default = newMockPermissionService(false)
+		path := filepath.Join(t.TempDir(), "denied.txt")
+		writeAndRead(t, path, "a\n")
+
+		response := runPatch(t, "--- "+path+"\n+++ "+path+"\n@@ -1 +1 @@\n-a\n+b\n")
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "permission denied")
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "a\n", string(content))
+	})
+}
@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+type readOutputTool struct{}
+
+const (
+	ReadOutputToolName       = "read_output"
+	defaultReadOutputLines   = 500
+	defaultReadOutputMatches = 100
+)
+
+type ReadOutputParams struct {
+	ArtifactID string `json:"artifact_id"`
+	Offset     int    `json:"offset"`
+	Limit      int    `json:"limit"`
+	Pattern    string `json:"pattern"`
+}
+
+func (r *readOutputTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        ReadOutputToolName,
+		Description: readOutputDescription(),
+		Parameters: map[string]any{
+			"artifact_id": map[string]any{
+				"type":        "string",
+				"description": "The id of the stored output, given in the tool response that was truncated",
+			},
+			"offset": map[string]any{
+				"type":        "integer",
+				"description": "The line number to start reading from (0-based), or the number of matches to skip when a pattern is given",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("The number of lines to read (defaults to %d), or of matches to return when a pattern is given (defaults to %d)", defaultReadOutputLines, defaultReadOutputMatches),
+			},
+			"pattern": map[string]any{
+				"type":        "string",
+				"description": "A regex pattern, only the lines matching it are returned",
+			},
+		},
+		Required: []string{"artifact_id"},
+	}
+}
+
+// Run implements Tool.
+func (r *readOutputTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params ReadOutputParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.ArtifactID == "" {
+		return NewTextErrorResponse("artifact_id is required"), nil
+	}
+	if params.Offset < 0 || params.Limit < 0 {
+		return NewTextErrorResponse("offset and limit cannot be negative"), nil
+	}
+
+	path, err := artifactPath(GetSessionFromContext(ctx), params.ArtifactID)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewTextErrorResponse(fmt.Sprintf("artifact %s not found in this session", params.ArtifactID)), nil
+	}
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("failed to read artifact: %s", err)), nil
+	}
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+
+	if params.Pattern != "" {
+		re, err := regexp.Compile(params.Pattern)
+		if err != nil {
+			return NewTextErrorResponse(fmt.Sprintf("invalid pattern: %s", err)), nil
+		}
+		return NewTextResponse(truncateOutput(searchArtifact(lines, re, params))), nil
+	}
+	return NewTextResponse(truncateOutput(pageArtifact(lines, params))), nil
+}
+
+func pageArtifact(lines []string, params ReadOutputParams) string {
+	limit := params.Limit
+	if limit == 0 {
+		limit = defaultReadOutputLines
+	}
+	if params.Offset >= len(lines) {
+		return fmt.Sprintf("Artifact %s has %d lines, offset %d is past the end", params.ArtifactID, len(lines), params.Offset)
+	}
+	end := min(params.Offset+limit, len(lines))
+
+	output := addLineNumbers(strings.Join(lines[params.Offset:end], "\n"), params.Offset+1)
+	if end < len(lines) {
+		output += fmt.Sprintf("\n\n(Artifact has %d lines. Use offset %d to read further)", len(lines), end)
+	}
+	return output
+}
+
+func searchArtifact(lines []string, re *regexp.Regexp, params ReadOutputParams) string {
+	limit := params.Limit
+	if limit == 0 {
+		limit = defaultReadOutputMatches
+	}
+	var matches []string
+	total := 0
+	for i, line := range lines {
+		if !re.MatchString(line) {
+			continue
+		}
+		total++
+		if total > params.Offset && len(matches) < limit {
+			matches = append(matches, addLineNumbers(line, i+1))
+		}
+	}
+	if total == 0 {
+		return fmt.Sprintf("No lines of artifact %s match %s", params.ArtifactID, params.Pattern)
+	}
+	if len(matches) == 0 {
+		return fmt.Sprintf("Artifact %s has %d matching line%s, offset %d is past the end", params.ArtifactID, total, pluralize(total), params.Offset)
+	}
+
+	output := strings.Join(matches, "\n")
+	if shown := params.Offset + len(matches); shown < total {
+		output += fmt.Sprintf("\n\n(Showing matches %d-%d of %d. Use offset %d to see more)", params.Offset+1, shown, total, shown)
+	}
+	return output
+}
+
+func readOutputDescription() string {
+	return `Reads the full output of a tool call that was too long to be returned, like a long build or test log.
+
+WHEN TO USE THIS TOOL:
+- Use when a tool response says that the full output is stored, with an artifact id
+- Use to find the failing tests or the errors hidden in the truncated middle of a long output
+
+HOW TO USE:
+- Provide the artifact_id given in the truncated response
+- Page through the output with offset and limit, lines are numbered like with the View tool
+- Give a pattern to only get the matching lines, with their line numbers
+- Combine both: search for a pattern first, then read the lines around a match with offset
+
+FEATURES:
+- Outputs are stored with the session and deleted with it
+- Pattern matching uses Go regular expressions
+
+LIMITATIONS:
+- Only the outputs stored in the current session can be read
+- Each response is limited in size like other tool outputs
+
+TIPS:
+- Search for patterns like "FAIL|panic|error" before paging through a long log
+- Prefer reading a small range around a match over reading the whole output`
+}
+
+func NewReadOutputTool() BaseTool {
+	return &readOutputTool{}
+}
@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOutputTool(t *testing.T) {
+	origDirectory := config.Get().Data.Directory
+	defer func() {
+		config.Get().Data.Directory = origDirectory
+	}()
+	config.Get().Data.Directory = t.TempDir()
+
+	session := context.WithValue(context.Background(), SessionIDContextKey, "session")
+	other := context.WithValue(context.Background(), SessionIDContextKey, "other")
+
+	var lines []string
+	for i := 1; i <= 3000; i++ {
+		status := "ok"
+		if i%1000 == 0 {
+			status = "FAIL"
+		}
+		lines = append(lines, fmt.Sprintf("--- %s: TestCase%d", status, i))
+	}
+	output := strings.Join(lines, "\n") + "\n"
+	require.Greater(t, len(output), MaxOutputLength)
+
+	run := func(t *testing.T, ctx context.Context, params ReadOutputParams) ToolResponse {
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		response, err := NewReadOutputTool().Run(ctx, ToolCall{Input: string(input)})
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("keeps short outputs", func(t *testing.T) {
+		assert.Equal(t, "short", spillOutput(session, BashToolName, "short"))
+	})
+
+	t.Run("only truncates outputs without a session", func(t *testing.T) {
+		assert.Equal(t, truncateOutput(output), spillOutput(context.Background(), BashToolName, output))
+	})
+
+	spilled := spillOutput(session, BashToolName, output)
+	match := regexp.MustCompile(`is stored in (\S+)\. Use the read_output tool with artifact_id "(bash-[0-9a-f]{8})"`).FindStringSubmatch(spilled)
+	require.NotNil(t, match, spilled)
+	path, id := match[1], match[2]
+
+	t.Run("stores long outputs", func(t *testing.T) {
+		assert.True(t, strings.HasPrefix(spilled, truncateOutput(output)))
+		assert.Contains(t, spilled, fmt.Sprintf("The full output (%d bytes, 3001 lines)", len(output)))
+		stored, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, output, string(stored))
+	})
+
+	t.Run("pages through an output", func(t *testing.T) {
+		response := run(t, session, ReadOutputParams{ArtifactID: id, Offset: 998, Limit: 3})
+		require.False(t, response.IsError, response.Content)
+		assert.Equal(t, "   999\t|--- ok: TestCase999\n"+
+			"  1000\t|--- FAIL: TestCase1000\n"+
+			"  1001\t|--- ok: TestCase1001\n\n"+
+			"(Artifact has 3000 lines. Use offset 1001 to read further)", response.Content)
+
+		response = run(t, session, ReadOutputParams{ArtifactID: id, Offset: 2999})
+		assert.Equal(t, "  3000\t|--- FAIL: TestCase3000", response.Content)
+
+		response = run(t, session, ReadOutputParams{ArtifactID: id, Offset: 3000})
+		assert.Contains(t, response.Content, "offset 3000 is past the end")
+	})
+
+	t.Run("searches an output", func(t *testing.T) {
+		response := run(t, session, ReadOutputParams{ArtifactID: id, Pattern: "FAIL", Limit: 2})
+		require.False(t, response.IsError, response.Content)
+		assert.Equal(t, "  1000\t|--- FAIL: TestCase1000\n"+
+			"  2000\t|--- FAIL: TestCase2000\n\n"+
+			"(Showing matches 1-2 of 3. Use offset 2 to see more)", response.Content)
+
+		response = run(t, session, ReadOutputParams{ArtifactID: id, Pattern: "FAIL", Offset: 2})
+		assert.Equal(t, "  3000\t|--- FAIL: TestCase3000", response.Content)
+
+		response = run(t, session, ReadOutputParams{ArtifactID: id, Pattern: "panic"})
+		assert.Equal(t, fmt.Sprintf("No lines of artifact %s match panic", id), response.Content)
+
+		response = run(t, session, ReadOutputParams{ArtifactID: id, Pattern: "("})
+		assert.True(t, response.IsError)
+	})
+
+	t.Run("only reads outputs of the session", func(t *testing.T) {
+		response := run(t, other, ReadOutputParams{ArtifactID: id})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "not found in this session")
+
+		response = run(t, session, ReadOutputParams{ArtifactID: "../other/" + id})
+		assert.True(t, response.IsError)
+		assert.Contains(t, response.Content, "invalid artifact id")
+	})
+
+	t.Run("removes the outputs with the session", func(t *testing.T) {
+		require.NoError(t, RemoveSessionArtifacts("session"))
+		_, err := os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+
+		response := run(t, session, ReadOutputParams{ArtifactID: id})
+		assert.True(t, response.IsError)
+	})
+}
+
+func TestArtifactsDir(t *testing.T) {
+	dir := setupWorkspace(t)
+	assert.Equal(t, filepath.Join(dir, ".termai", "artifacts", "session"), artifactsDir("session"))
+}
@@ -0,0 +1,138 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/lsp"
+)
+
+type referencesTool struct {
+	lspManager *lsp.Manager
+}
+
+const (
+	ReferencesToolName = "references"
+)
+
+type ReferencesParams struct {
+	Symbol   string `json:"symbol"`
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Path     string `json:"path"`
+}
+
+func (r *referencesTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        ReferencesToolName,
+		Description: referencesDescription(),
+		Parameters: map[string]any{
+			"symbol": map[string]any{
+				"type":        "string",
+				"description": "The name of the symbol, methods and fields can be qualified with their type (e.g. \"Client.Call\")",
+			},
+			"file_path": map[string]any{
+				"type":        "string",
+				"description": "A file where the symbol is declared or used, to resolve it precisely together with line",
+			},
+			"line": map[string]any{
+				"type":        "integer",
+				"description": "The line number (1-based) in file_path where the symbol appears",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "The directory to limit results to. Defaults to the current working directory.",
+			},
+		},
+		Required: []string{"symbol"},
+	}
+}
+
+// Run implements Tool.
+func (r *referencesTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params ReferencesParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.Symbol == "" {
+		return NewTextErrorResponse("symbol is required"), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, navigationTimeout)
+	defer cancel()
+
+	root := navigationRoot(params.Path)
+	references := func(c *lsp.Client, path string, pos lsp.Position) ([]lsp.Location, error) {
+		return c.References(ctx, path, pos)
+	}
+
+	var locations []codeLocation
+	if params.FilePath != "" && params.Line > 0 {
+		if !filepath.IsAbs(params.FilePath) {
+			params.FilePath = filepath.Join(config.WorkingDirectory(), params.FilePath)
+		}
+		found, err := lspPositionLookup(ctx, r.lspManager, params.FilePath, params.Line, params.Symbol,
+			func(c *lsp.Client, pos lsp.Position) ([]lsp.Location, error) {
+				return references(c, params.FilePath, pos)
+			})
+		if err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		locations = found
+	}
+	if len(locations) == 0 {
+		// find the declarations first, then ask for the references to them
+		for _, def := range lspDefinitions(ctx, r.lspManager, params.Symbol) {
+			pos := lsp.Position{Line: def.line - 1, Character: def.column - 1}
+			for _, c := range r.lspManager.Clients(ctx, def.path) {
+				found, err := references(c, def.path, pos)
+				if err == nil {
+					locations = append(locations, fromLSPLocations(found)...)
+				}
+			}
+		}
+	}
+	if len(locations) == 0 {
+		locations = goReferences(root, params.Symbol)
+	}
+
+	output := formatLocations(root, locations)
+	if output == "" {
+		return NewTextResponse(fmt.Sprintf("No references found for %s", params.Symbol)), nil
+	}
+	return NewTextResponse(output), nil
+}
+
+func referencesDescription() string {
+	return `Finds every place a symbol (function, method, type, variable, constant or field) is referenced, including its declaration, returning file:line:column locations with the source line.
+
+WHEN TO USE THIS TOOL:
+- Use before renaming or changing the signature of a symbol to find all its usages
+- Helpful to understand how a function or type is used across the codebase
+
+HOW TO USE:
+- Provide the symbol name, qualify methods and fields with their type (e.g. "Client.Call") or package (e.g. "config.Get")
+- To resolve a specific symbol precisely, also provide a file_path and line where it appears
+- Optionally limit results to a directory with path
+
+FEATURES:
+- Uses the configured language servers when available, which resolve references by type
+- Falls back to parsing Go files when no language server answers
+- Results are limited to the working directory and skip hidden and common build directories
+
+LIMITATIONS:
+- The Go fallback matches identifiers by name, so unrelated symbols with the same name are included
+- Other languages need a configured and installed language server
+- Results are limited to 100 locations
+
+TIPS:
+- Qualify the symbol to reduce unrelated matches in the Go fallback
+- Use the Definition tool to find only the declaration`
+}
+
+func NewReferencesTool(lspManager *lsp.Manager) BaseTool {
+	return &referencesTool{lspManager: lspManager}
+}
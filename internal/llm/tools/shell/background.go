@@ -0,0 +1,340 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
+)
+
+type ProcessStatus string
+
+const (
+	ProcessRunning ProcessStatus = "running"
+	ProcessExited  ProcessStatus = "exited"
+	ProcessKilled  ProcessStatus = "killed"
+
+	// maxProcessOutput is how much output is kept per process, older output
+	// is dropped once the limit is reached
+	maxProcessOutput = 1024 * 1024
+
+	// killGracePeriod is how long a process has to exit after SIGTERM before
+	// it gets SIGKILL
+	killGracePeriod = 5 * time.Second
+)
+
+var ErrProcessNotFound = errors.New("background process not found")
+
+// ProcessInfo is a snapshot of the state of a background process.
+type ProcessInfo struct {
+	ID        string
+	SessionID string
+	Command   string
+	Dir       string
+	PID       int
+	Status    ProcessStatus
+	ExitCode  int
+	StartedAt time.Time
+	EndedAt   time.Time
+	// OutputSize is the total number of bytes written by the process
+	OutputSize int
+}
+
+// BackgroundProcess is a command started outside of the persistent shell that
+// keeps running after the tool call returns.
+type BackgroundProcess struct {
+	id        string
+	sessionID string
+	command   string
+	dir       string
+	startedAt time.Time
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	done  chan struct{}
+
+	mu       sync.Mutex
+	output   []byte
+	dropped  int // bytes removed from the start of output
+	readPos  int // absolute position of the next unread byte
+	status   ProcessStatus
+	exitCode int
+	endedAt  time.Time
+}
+
+var (
+	processes   = make(map[string]*BackgroundProcess)
+	processesMu sync.Mutex
+	processSeq  int
+
+	processEvents = pubsub.NewBroker[ProcessInfo]()
+)
+
+// SubscribeProcesses returns the events published when a background process
+// starts, changes status or is removed.
+func SubscribeProcesses(ctx context.Context) <-chan pubsub.Event[ProcessInfo] {
+	return processEvents.Subscribe(ctx)
+}
+
+// StartBackground starts command in dir and returns without waiting for it.
+// Stdout and stderr are combined and kept in memory until read. When sandbox
+// is not nil the command runs in it.
+func StartBackground(sessionID, dir, command string, sandbox *Sandbox) (*BackgroundProcess, error) {
+	cmd, err := shellCommand(sandbox, "-c", command)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Dir = dir
+	cmd.Env = append(cmdEnv(cmd), "GIT_EDITOR=true")
+	// run in its own process group so children are killed with it
+	setProcessGroup(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	processesMu.Lock()
+	processSeq++
+	p := &BackgroundProcess{
+		id:        fmt.Sprintf("bg-%d", processSeq),
+		sessionID: sessionID,
+		command:   command,
+		dir:       dir,
+		cmd:       cmd,
+		stdin:     stdin,
+		done:      make(chan struct{}),
+		status:    ProcessRunning,
+	}
+	processesMu.Unlock()
+
+	cmd.Stdout = p
+	cmd.Stderr = p
+	if err := cmd.Start(); err != nil {
+		if sandbox != nil {
+			return nil, sandbox.startError(err)
+		}
+		return nil, err
+	}
+	p.startedAt = time.Now()
+
+	processesMu.Lock()
+	processes[p.id] = p
+	processesMu.Unlock()
+	processEvents.Publish(pubsub.CreatedEvent, p.Info())
+
+	go p.wait()
+	return p, nil
+}
+
+func (p *BackgroundProcess) wait() {
+	err := p.cmd.Wait()
+
+	p.mu.Lock()
+	if p.status == ProcessRunning {
+		p.status = ProcessExited
+	}
+	p.exitCode = 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		p.exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		p.exitCode = -1
+	}
+	p.endedAt = time.Now()
+	p.mu.Unlock()
+
+	close(p.done)
+	processEvents.Publish(pubsub.UpdatedEvent, p.Info())
+}
+
+// Write implements io.Writer for the process output.
+func (p *BackgroundProcess) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.output = append(p.output, b...)
+	if over := len(p.output) - maxProcessOutput; over > 0 {
+		p.output = p.output[over:]
+		p.dropped += over
+	}
+	return len(b), nil
+}
+
+func (p *BackgroundProcess) ID() string {
+	return p.id
+}
+
+func (p *BackgroundProcess) SessionID() string {
+	return p.sessionID
+}
+
+// Info returns a snapshot of the process state.
+func (p *BackgroundProcess) Info() ProcessInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pid := 0
+	if p.cmd.Process != nil {
+		pid = p.cmd.Process.Pid
+	}
+	return ProcessInfo{
+		ID:         p.id,
+		SessionID:  p.sessionID,
+		Command:    p.command,
+		Dir:        p.dir,
+		PID:        pid,
+		Status:     p.status,
+		ExitCode:   p.exitCode,
+		StartedAt:  p.startedAt,
+		EndedAt:    p.endedAt,
+		OutputSize: p.dropped + len(p.output),
+	}
+}
+
+// ReadOutput returns the output written since the previous call. skipped is
+// the number of unread bytes that were dropped because the process wrote
+// more than the kept limit in between.
+func (p *BackgroundProcess) ReadOutput() (output string, skipped int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.readPos < p.dropped {
+		skipped = p.dropped - p.readPos
+		p.readPos = p.dropped
+	}
+	output = string(p.output[p.readPos-p.dropped:])
+	p.readPos = p.dropped + len(p.output)
+	return output, skipped
+}
+
+// WriteInput sends input to the process stdin.
+func (p *BackgroundProcess) WriteInput(input string) error {
+	if p.Info().Status != ProcessRunning {
+		return fmt.Errorf("process %s is not running", p.id)
+	}
+	_, err := io.WriteString(p.stdin, input)
+	return err
+}
+
+// Kill terminates the process group, first with SIGTERM then with SIGKILL if
+// it is still running after the grace period. It returns once the process
+// has exited.
+func (p *BackgroundProcess) Kill() {
+	p.mu.Lock()
+	if p.status != ProcessRunning {
+		p.mu.Unlock()
+		return
+	}
+	p.status = ProcessKilled
+	process := p.cmd.Process
+	p.mu.Unlock()
+
+	p.stdin.Close()
+	terminateProcessGroup(process)
+	select {
+	case <-p.done:
+	case <-time.After(killGracePeriod):
+		killProcessGroup(process)
+		<-p.done
+	}
+}
+
+// Done is closed when the process exits.
+func (p *BackgroundProcess) Done() <-chan struct{} {
+	return p.done
+}
+
+// GetBackgroundProcess returns the process with the given id if it belongs to
+// the session.
+func GetBackgroundProcess(sessionID, id string) (*BackgroundProcess, error) {
+	processesMu.Lock()
+	defer processesMu.Unlock()
+
+	p, ok := processes[id]
+	if !ok || p.sessionID != sessionID {
+		return nil, ErrProcessNotFound
+	}
+	return p, nil
+}
+
+// ListBackgroundProcesses returns the processes of a session, or of every
+// session if sessionID is empty, oldest first.
+func ListBackgroundProcesses(sessionID string) []ProcessInfo {
+	processesMu.Lock()
+	list := make([]*BackgroundProcess, 0, len(processes))
+	for _, p := range processes {
+		if sessionID == "" || p.sessionID == sessionID {
+			list = append(list, p)
+		}
+	}
+	processesMu.Unlock()
+
+	infos := make([]ProcessInfo, len(list))
+	for i, p := range list {
+		infos[i] = p.Info()
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartedAt.Before(infos[j].StartedAt)
+	})
+	return infos
+}
+
+// RemoveBackgroundProcess kills the process if needed and forgets it.
+func RemoveBackgroundProcess(sessionID, id string) error {
+	p, err := GetBackgroundProcess(sessionID, id)
+	if err != nil {
+		return err
+	}
+	p.Kill()
+
+	processesMu.Lock()
+	delete(processes, id)
+	processesMu.Unlock()
+	processEvents.Publish(pubsub.DeletedEvent, p.Info())
+	return nil
+}
+
+// KillSessionProcesses kills and forgets every process of the session.
+func KillSessionProcesses(sessionID string) {
+	killProcesses(func(p *BackgroundProcess) bool {
+		return p.sessionID == sessionID
+	})
+}
+
+// KillAllProcesses kills and forgets every background process, it is used
+// when the app exits.
+func KillAllProcesses() {
+	killProcesses(func(p *BackgroundProcess) bool {
+		return true
+	})
+}
+
+func killProcesses(match func(p *BackgroundProcess) bool) {
+	processesMu.Lock()
+	var matched []*BackgroundProcess
+	for id, p := range processes {
+		if match(p) {
+			matched = append(matched, p)
+			delete(processes, id)
+		}
+	}
+	processesMu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, p := range matched {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Kill()
+			processEvents.Publish(pubsub.DeletedEvent, p.Info())
+		}()
+	}
+	wg.Wait()
+}
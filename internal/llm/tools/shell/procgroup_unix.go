@@ -0,0 +1,28 @@
+//go:build !windows
+
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes cmd run in its own process group, so the processes it
+// starts are stopped with it.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// terminateProcessGroup asks the process group of p to stop with SIGTERM.
+func terminateProcessGroup(p *os.Process) {
+	syscall.Kill(-p.Pid, syscall.SIGTERM)
+}
+
+// killProcessGroup kills the process group of p with SIGKILL.
+func killProcessGroup(p *os.Process) {
+	syscall.Kill(-p.Pid, syscall.SIGKILL)
+}
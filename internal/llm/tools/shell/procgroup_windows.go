@@ -0,0 +1,27 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes cmd run in its own process group, so it does not
+// receive the console signals of termai.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// terminateProcessGroup kills p, windows has no signal asking a process to
+// stop. The processes it started are not killed.
+func terminateProcessGroup(p *os.Process) {
+	p.Kill()
+}
+
+// killProcessGroup kills p.
+func killProcessGroup(p *os.Process) {
+	p.Kill()
+}
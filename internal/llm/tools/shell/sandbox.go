@@ -0,0 +1,65 @@
+package shell
+
+import (
+	"strings"
+)
+
+// Sandbox restricts what the commands run by a shell can do. Writes are only
+// allowed beneath WritablePaths, everything else is read only, and the
+// network is unavailable when DisableNetwork is set.
+type Sandbox struct {
+	WritablePaths  []string `json:"writablePaths"`
+	DisableNetwork bool     `json:"disableNetwork"`
+}
+
+// sandboxEnv holds the JSON encoded sandbox of the helper process that sets
+// up the sandbox before starting the shell, see RunSandboxHelper.
+const sandboxEnv = "TERMAI_SANDBOX"
+
+// sandboxErrors are the messages of the errors commands usually print when
+// the sandbox blocks them.
+var (
+	sandboxWriteErrors = []string{
+		"permission denied",
+		"operation not permitted",
+		"read-only file system",
+	}
+	sandboxNetworkErrors = []string{
+		"network is unreachable",
+		"could not resolve host",
+		"temporary failure in name resolution",
+		"name or service not known",
+		"no such host",
+	}
+)
+
+// BlockedHint returns an explanation to add to the output of a failed command
+// when the output suggests the sandbox blocked it, or an empty string.
+func (s *Sandbox) BlockedHint(output string) string {
+	if s == nil {
+		return ""
+	}
+	output = strings.ToLower(output)
+	blocked := containsAny(output, sandboxWriteErrors)
+	if s.DisableNetwork && containsAny(output, sandboxNetworkErrors) {
+		blocked = true
+	}
+	if !blocked {
+		return ""
+	}
+
+	hint := "The command may have been blocked by the sandbox. Writes are only allowed in " + strings.Join(s.WritablePaths, ", ")
+	if s.DisableNetwork {
+		hint += " and network access is disabled"
+	}
+	return hint + ". If the command needs more access, run it again with outside_sandbox set to true, the user will be asked to approve it."
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,220 @@
+//go:build linux
+
+package shell
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// landlock access rights known by each ABI version
+	landlockFSAccessV1 = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+		unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+	landlockFSAccessV2 = landlockFSAccessV1 | unix.LANDLOCK_ACCESS_FS_REFER
+	landlockFSAccessV3 = landlockFSAccessV2 | unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	landlockFSAccessV5 = landlockFSAccessV3 | unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+
+	landlockReadAccess = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_DIR |
+		unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+
+	// rights that can be granted on a file, the others only apply to
+	// directories
+	landlockFileAccess = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+		unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_TRUNCATE |
+		unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+)
+
+// sandboxDevices are the device files commands can still write to.
+var sandboxDevices = []string{
+	"/dev/null", "/dev/zero", "/dev/full", "/dev/random", "/dev/urandom", "/dev/tty",
+}
+
+// SandboxSupported returns an error explaining why commands cannot be
+// sandboxed on this system.
+func SandboxSupported() error {
+	_, err := landlockABI()
+	return err
+}
+
+func landlockABI() (int, error) {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		switch errno {
+		case unix.ENOSYS:
+			return 0, errors.New("the sandbox needs Landlock, which is not supported by this kernel (Linux 5.13 or later is required)")
+		case unix.EOPNOTSUPP:
+			return 0, errors.New("the sandbox needs Landlock, which is disabled on this system (enable it with the lsm= boot parameter)")
+		}
+		return 0, fmt.Errorf("the sandbox needs Landlock, which is not available: %w", errno)
+	}
+	return int(abi), nil
+}
+
+// command returns a command that starts name in the sandbox. The termai
+// binary is started instead and restricts itself before executing name,
+// Landlock cannot be applied to another process.
+func (s *Sandbox) command(name string, args ...string) (*exec.Cmd, error) {
+	if err := SandboxSupported(); err != nil {
+		return nil, err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("cannot find the termai binary to start the sandbox: %w", err)
+	}
+	policy, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(self, append([]string{name}, args...)...)
+	cmd.Env = append(os.Environ(), sandboxEnv+"="+string(policy))
+	cmd.SysProcAttr = &syscall.SysProcAttr{}
+	if s.DisableNetwork {
+		// a new network namespace only has a loopback interface that is down,
+		// the user namespace makes it possible without privileges
+		cmd.SysProcAttr.Cloneflags = syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET
+		cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+		cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	}
+	return cmd, nil
+}
+
+// startError explains why a sandboxed command failed to start.
+func (s *Sandbox) startError(err error) error {
+	if s.DisableNetwork {
+		return fmt.Errorf("failed to start the sandbox without network, unprivileged user namespaces may be disabled on this system: %w", err)
+	}
+	return fmt.Errorf("failed to start the sandbox: %w", err)
+}
+
+// RunSandboxHelper must be called at the very start of main. When the
+// process was started by Sandbox.command it restricts itself and executes
+// the sandboxed command, it never returns in that case.
+func RunSandboxHelper() {
+	policy, ok := os.LookupEnv(sandboxEnv)
+	if !ok {
+		return
+	}
+	os.Unsetenv(sandboxEnv)
+
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "termai sandbox: %s\n", err)
+		os.Exit(126)
+	}
+	if len(os.Args) < 2 {
+		fail(errors.New("no command to run"))
+	}
+	var s Sandbox
+	if err := json.Unmarshal([]byte(policy), &s); err != nil {
+		fail(err)
+	}
+	path, err := exec.LookPath(os.Args[1])
+	if err != nil {
+		fail(err)
+	}
+
+	// Landlock restricts the calling thread only, exec must happen on the
+	// same thread, it becomes the only one of the new program
+	runtime.LockOSThread()
+	if err := s.restrictSelf(); err != nil {
+		fail(err)
+	}
+	fail(syscall.Exec(path, os.Args[1:], os.Environ()))
+}
+
+func (s *Sandbox) restrictSelf() error {
+	abi, err := landlockABI()
+	if err != nil {
+		return err
+	}
+	handled := uint64(landlockFSAccessV1)
+	switch {
+	case abi >= 5:
+		handled = landlockFSAccessV5
+	case abi >= 3:
+		handled = landlockFSAccessV3
+	case abi == 2:
+		handled = landlockFSAccessV2
+	}
+
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create the landlock ruleset: %w", errno)
+	}
+	ruleset := int(fd)
+	defer unix.Close(ruleset)
+
+	if err := addLandlockRule(ruleset, "/", landlockReadAccess&handled); err != nil {
+		return err
+	}
+	for _, path := range sandboxDevices {
+		if err := addLandlockRule(ruleset, path, handled); err != nil {
+			return err
+		}
+	}
+	for _, path := range s.WritablePaths {
+		if err := addLandlockRule(ruleset, path, handled); err != nil {
+			return err
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, uintptr(ruleset), 0, 0); errno != 0 {
+		return fmt.Errorf("failed to enforce the landlock ruleset: %w", errno)
+	}
+	return nil
+}
+
+// addLandlockRule grants access beneath path, missing paths are ignored.
+func addLandlockRule(ruleset int, path string, access uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		if errors.Is(err, unix.ENOENT) {
+			return nil
+		}
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer unix.Close(fd)
+
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if st.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access &= landlockFileAccess
+	}
+
+	rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset), unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to allow access to %s: %w", path, errno)
+	}
+	return nil
+}
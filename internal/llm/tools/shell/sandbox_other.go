@@ -0,0 +1,28 @@
+//go:build !linux
+
+package shell
+
+import (
+	"errors"
+	"os/exec"
+)
+
+var errSandboxUnsupported = errors.New("the sandbox is only supported on Linux")
+
+// SandboxSupported returns an error explaining why commands cannot be
+// sandboxed on this system.
+func SandboxSupported() error {
+	return errSandboxUnsupported
+}
+
+func (s *Sandbox) command(name string, args ...string) (*exec.Cmd, error) {
+	return nil, errSandboxUnsupported
+}
+
+func (s *Sandbox) startError(err error) error {
+	return err
+}
+
+// RunSandboxHelper must be called at the very start of main, it does nothing
+// on this system.
+func RunSandboxHelper() {}
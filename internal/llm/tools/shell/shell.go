@@ -7,19 +7,44 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
+)
+
+const (
+	// shellIdleTimeout is how long a shell is kept without running a command,
+	// the next command of the session starts a new shell
+	shellIdleTimeout = 30 * time.Minute
+	// shellReapInterval is how often idle shells are looked for
+	shellReapInterval = time.Minute
 )
 
 type PersistentShell struct {
 	cmd          *exec.Cmd
 	stdin        *os.File
-	isAlive      bool
+	sessionID    string
+	sandboxed    bool
+	tempDir      string
+	mu           sync.Mutex // held while a command runs
+	stateMu      sync.Mutex // guards cwd and lastUsed
 	cwd          string
-	mu           sync.Mutex
+	lastUsed     time.Time
 	commandQueue chan *commandExecution
+	done         chan struct{}
+	closeOnce    sync.Once
+}
+
+// ShellInfo is a snapshot of the state of a persistent shell.
+type ShellInfo struct {
+	SessionID string
+	Sandboxed bool
+	Cwd       string
+	LastUsed  time.Time
 }
 
 type commandExecution struct {
@@ -37,69 +62,230 @@ type commandResult struct {
 	err         error
 }
 
+// shellKey identifies a shell, a session has separate shells for the commands
+// run in the sandbox and outside of it.
+type shellKey struct {
+	sessionID string
+	sandboxed bool
+}
+
 var (
-	shellInstance     *PersistentShell
-	shellInstanceOnce sync.Once
+	shellInstances   = make(map[shellKey]*PersistentShell)
+	shellInstancesMu sync.Mutex
+	shellReaperOnce  sync.Once
+
+	shellEvents = pubsub.NewBroker[ShellInfo]()
 )
 
-func GetPersistentShell(workingDir string) *PersistentShell {
-	shellInstanceOnce.Do(func() {
-		shellInstance = newPersistentShell(workingDir)
+// SubscribeShells returns the events published when a shell starts, runs a
+// command or exits.
+func SubscribeShells(ctx context.Context) <-chan pubsub.Event[ShellInfo] {
+	return shellEvents.Subscribe(ctx)
+}
+
+// GetPersistentShell returns the shell owned by the given session, starting a
+// new one in workingDir if needed. Shells are not shared between sessions so
+// that state like the current directory does not leak from one to another.
+// When sandbox is not nil the commands of the shell run in it. Shells idle
+// for longer than shellIdleTimeout are closed.
+func GetPersistentShell(sessionID, workingDir string, sandbox *Sandbox) (*PersistentShell, error) {
+	shellReaperOnce.Do(func() {
+		go reapIdleShells()
 	})
 
-	if !shellInstance.isAlive {
-		shellInstance = newPersistentShell(shellInstance.cwd)
+	shellInstancesMu.Lock()
+	defer shellInstancesMu.Unlock()
+
+	key := shellKey{sessionID: sessionID, sandboxed: sandbox != nil}
+	shell, ok := shellInstances[key]
+	if !ok || shell == nil || !shell.alive() {
+		var err error
+		shell, err = newPersistentShell(sessionID, workingDir, sandbox)
+		if err != nil {
+			return nil, err
+		}
+		shellInstances[key] = shell
+	}
+
+	return shell, nil
+}
+
+func reapIdleShells() {
+	ticker := time.NewTicker(shellReapInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		closeIdleShells(shellIdleTimeout)
 	}
+}
 
-	return shellInstance
+// closeIdleShells closes the shells that did not run a command for longer
+// than maxIdle. Shells running a command are never idle.
+func closeIdleShells(maxIdle time.Duration) {
+	closeShells(func(s *PersistentShell) bool {
+		if !s.mu.TryLock() {
+			return false
+		}
+		defer s.mu.Unlock()
+		return time.Since(s.Info().LastUsed) > maxIdle
+	})
 }
 
-func newPersistentShell(cwd string) *PersistentShell {
+// CloseSessionShells closes the shells of a session with the commands they
+// run, the next command of the session starts in a new shell.
+func CloseSessionShells(sessionID string) {
+	closeShells(func(s *PersistentShell) bool {
+		return s.sessionID == sessionID
+	})
+}
+
+// CloseAllShells closes every shell, it is used when the app exits.
+func CloseAllShells() {
+	closeShells(func(s *PersistentShell) bool {
+		return true
+	})
+}
+
+func closeShells(match func(s *PersistentShell) bool) {
+	shellInstancesMu.Lock()
+	var matched []*PersistentShell
+	for key, s := range shellInstances {
+		if match(s) {
+			matched = append(matched, s)
+			delete(shellInstances, key)
+		}
+	}
+	shellInstancesMu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, s := range matched {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Close()
+		}()
+	}
+	wg.Wait()
+}
+
+// ListShells returns the shells of a session, or of every session if
+// sessionID is empty, the most recently used first.
+func ListShells(sessionID string) []ShellInfo {
+	shellInstancesMu.Lock()
+	list := make([]*PersistentShell, 0, len(shellInstances))
+	for _, s := range shellInstances {
+		if (sessionID == "" || s.sessionID == sessionID) && s.alive() {
+			list = append(list, s)
+		}
+	}
+	shellInstancesMu.Unlock()
+
+	infos := make([]ShellInfo, len(list))
+	for i, s := range list {
+		infos[i] = s.Info()
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].LastUsed.After(infos[j].LastUsed)
+	})
+	return infos
+}
+
+// shellCommand returns the command running shellPath with args, in the
+// sandbox if it is not nil.
+func shellCommand(sandbox *Sandbox, args ...string) (*exec.Cmd, error) {
 	shellPath := os.Getenv("SHELL")
 	if shellPath == "" {
 		shellPath = "/bin/bash"
 	}
+	if sandbox == nil {
+		return exec.Command(shellPath, args...), nil
+	}
+	return sandbox.command(shellPath, args...)
+}
 
-	cmd := exec.Command(shellPath, "-l")
+func newPersistentShell(sessionID, cwd string, sandbox *Sandbox) (*PersistentShell, error) {
+	cmd, err := shellCommand(sandbox, "-l")
+	if err != nil {
+		return nil, err
+	}
 	cmd.Dir = cwd
+	// run in its own process group so the commands are killed with it
+	setProcessGroup(cmd)
 
 	stdinPipe, err := cmd.StdinPipe()
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
-	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	cmd.Env = append(cmdEnv(cmd), "GIT_EDITOR=true")
+
+	// the output of the commands is written to files of the shell, removed
+	// when it exits
+	tempDir, err := os.MkdirTemp("", "termai-shell-")
+	if err != nil {
+		return nil, err
+	}
 
 	err = cmd.Start()
 	if err != nil {
-		return nil
+		os.RemoveAll(tempDir)
+		if sandbox != nil {
+			return nil, sandbox.startError(err)
+		}
+		return nil, err
 	}
 
 	shell := &PersistentShell{
 		cmd:          cmd,
 		stdin:        stdinPipe.(*os.File),
-		isAlive:      true,
+		sessionID:    sessionID,
+		sandboxed:    sandbox != nil,
+		tempDir:      tempDir,
 		cwd:          cwd,
+		lastUsed:     time.Now(),
 		commandQueue: make(chan *commandExecution, 10),
+		done:         make(chan struct{}),
 	}
 
 	go shell.processCommands()
 
 	go func() {
-		err := cmd.Wait()
-		if err != nil {
-		}
-		shell.isAlive = false
-		close(shell.commandQueue)
+		cmd.Wait()
+		close(shell.done)
+		shell.stdin.Close()
+		os.RemoveAll(tempDir)
+		shellEvents.Publish(pubsub.DeletedEvent, shell.Info())
 	}()
 
-	return shell
+	shellEvents.Publish(pubsub.CreatedEvent, shell.Info())
+	return shell, nil
+}
+
+func (s *PersistentShell) alive() bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// cmdEnv returns the environment of cmd, which is the one of the current
+// process unless it was already set.
+func cmdEnv(cmd *exec.Cmd) []string {
+	if cmd.Env != nil {
+		return cmd.Env
+	}
+	return os.Environ()
 }
 
 func (s *PersistentShell) processCommands() {
-	for cmd := range s.commandQueue {
-		result := s.execCommand(cmd.command, cmd.timeout, cmd.ctx)
-		cmd.resultChan <- result
+	for {
+		select {
+		case cmd := <-s.commandQueue:
+			cmd.resultChan <- s.execCommand(cmd.command, cmd.timeout, cmd.ctx)
+		case <-s.done:
+			return
+		}
 	}
 }
 
@@ -107,19 +293,21 @@ func (s *PersistentShell) execCommand(command string, timeout time.Duration, ctx
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.isAlive {
+	if !s.alive() {
 		return commandResult{
 			stderr:   "Shell is not alive",
 			exitCode: 1,
 			err:      errors.New("shell is not alive"),
 		}
 	}
+	s.touch()
+	defer s.touch()
 
-	tempDir := os.TempDir()
-	stdoutFile := filepath.Join(tempDir, fmt.Sprintf("termai-stdout-%d", time.Now().UnixNano()))
-	stderrFile := filepath.Join(tempDir, fmt.Sprintf("termai-stderr-%d", time.Now().UnixNano()))
-	statusFile := filepath.Join(tempDir, fmt.Sprintf("termai-status-%d", time.Now().UnixNano()))
-	cwdFile := filepath.Join(tempDir, fmt.Sprintf("termai-cwd-%d", time.Now().UnixNano()))
+	id := time.Now().UnixNano()
+	stdoutFile := filepath.Join(s.tempDir, fmt.Sprintf("stdout-%d", id))
+	stderrFile := filepath.Join(s.tempDir, fmt.Sprintf("stderr-%d", id))
+	statusFile := filepath.Join(s.tempDir, fmt.Sprintf("status-%d", id))
+	cwdFile := filepath.Join(s.tempDir, fmt.Sprintf("cwd-%d", id))
 
 	defer func() {
 		os.Remove(stdoutFile)
@@ -164,6 +352,11 @@ echo $EXEC_EXIT_CODE > %s
 				done <- true
 				return
 
+			case <-s.done:
+				interrupted = true
+				done <- true
+				return
+
 			case <-time.After(10 * time.Millisecond):
 				if fileExists(statusFile) && fileSize(statusFile) > 0 {
 					done <- true
@@ -199,8 +392,12 @@ echo $EXEC_EXIT_CODE > %s
 	}
 
 	if newCwd != "" {
+		s.stateMu.Lock()
 		s.cwd = strings.TrimSpace(newCwd)
+		s.stateMu.Unlock()
 	}
+	s.touch()
+	shellEvents.Publish(pubsub.UpdatedEvent, s.Info())
 
 	return commandResult{
 		stdout:      stdout,
@@ -236,36 +433,76 @@ func (s *PersistentShell) killChildren() {
 }
 
 func (s *PersistentShell) Exec(ctx context.Context, command string, timeoutMs int) (string, string, int, bool, error) {
-	if !s.isAlive {
-		return "", "Shell is not alive", 1, false, errors.New("shell is not alive")
-	}
-
 	timeout := time.Duration(timeoutMs) * time.Millisecond
 
-	resultChan := make(chan commandResult)
-	s.commandQueue <- &commandExecution{
+	resultChan := make(chan commandResult, 1)
+	select {
+	case s.commandQueue <- &commandExecution{
 		command:    command,
 		timeout:    timeout,
 		resultChan: resultChan,
 		ctx:        ctx,
+	}:
+	case <-s.done:
+		return "", "Shell is not alive", 1, false, errors.New("shell is not alive")
 	}
 
-	result := <-resultChan
-	return result.stdout, result.stderr, result.exitCode, result.interrupted, result.err
+	select {
+	case result := <-resultChan:
+		return result.stdout, result.stderr, result.exitCode, result.interrupted, result.err
+	case <-s.done:
+		// the shell exited before running the queued command
+		select {
+		case result := <-resultChan:
+			return result.stdout, result.stderr, result.exitCode, result.interrupted, result.err
+		case <-time.After(100 * time.Millisecond):
+			return "", "Shell is not alive", 1, true, errors.New("shell is not alive")
+		}
+	}
 }
 
-func (s *PersistentShell) Close() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// Cwd returns the current directory of the shell as of the last command.
+func (s *PersistentShell) Cwd() string {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.cwd
+}
 
-	if !s.isAlive {
-		return
-	}
+func (s *PersistentShell) touch() {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.lastUsed = time.Now()
+}
 
-	s.stdin.Write([]byte("exit\n"))
+// Info returns a snapshot of the shell state.
+func (s *PersistentShell) Info() ShellInfo {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return ShellInfo{
+		SessionID: s.sessionID,
+		Sandboxed: s.sandboxed,
+		Cwd:       s.cwd,
+		LastUsed:  s.lastUsed,
+	}
+}
 
-	s.cmd.Process.Kill()
-	s.isAlive = false
+// Close terminates the shell and its process group, first with SIGTERM then
+// with SIGKILL if it is still running after the grace period. A running
+// command is interrupted. It returns once the shell has exited.
+func (s *PersistentShell) Close() {
+	s.closeOnce.Do(func() {
+		if !s.alive() {
+			return
+		}
+		s.stdin.Close()
+		terminateProcessGroup(s.cmd.Process)
+		select {
+		case <-s.done:
+		case <-time.After(killGracePeriod):
+			killProcessGroup(s.cmd.Process)
+			<-s.done
+		}
+	})
 }
 
 func shellQuote(s string) string {
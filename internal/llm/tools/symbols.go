@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/lsp"
+)
+
+type symbolsTool struct {
+	lspManager *lsp.Manager
+}
+
+const (
+	SymbolsToolName = "symbols"
+)
+
+type SymbolsParams struct {
+	FilePath string `json:"file_path"`
+}
+
+func (s *symbolsTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        SymbolsToolName,
+		Description: symbolsDescription(),
+		Parameters: map[string]any{
+			"file_path": map[string]any{
+				"type":        "string",
+				"description": "The path to the file to list the symbols of",
+			},
+		},
+		Required: []string{"file_path"},
+	}
+}
+
+// Run implements Tool.
+func (s *symbolsTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params SymbolsParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.FilePath == "" {
+		return NewTextErrorResponse("file_path is required"), nil
+	}
+	if !filepath.IsAbs(params.FilePath) {
+		params.FilePath = filepath.Join(config.WorkingDirectory(), params.FilePath)
+	}
+
+	info, err := os.Stat(params.FilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewTextErrorResponse(fmt.Sprintf("file not found: %s", params.FilePath)), nil
+		}
+		return NewTextErrorResponse(fmt.Sprintf("failed to access file: %s", err)), nil
+	}
+	if info.IsDir() {
+		return NewTextErrorResponse(fmt.Sprintf("path is a directory, not a file: %s", params.FilePath)), nil
+	}
+	if !isNavigablePath(newIgnoreMatcher(config.WorkingDirectory()), config.WorkingDirectory(), params.FilePath) {
+		return NewTextErrorResponse(fmt.Sprintf("file is outside the working directory or ignored: %s", params.FilePath)), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, navigationTimeout)
+	defer cancel()
+
+	var symbols []codeSymbol
+	for _, c := range s.lspManager.Clients(ctx, params.FilePath) {
+		found, err := c.DocumentSymbols(ctx, params.FilePath)
+		if err == nil && len(found) > 0 {
+			symbols = fromDocumentSymbols(found)
+			break
+		}
+	}
+	if len(symbols) == 0 && strings.HasSuffix(params.FilePath, ".go") {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, params.FilePath, nil, parser.SkipObjectResolution)
+		if file == nil {
+			return NewTextErrorResponse(fmt.Sprintf("failed to parse file: %s", err)), nil
+		}
+		symbols = goSymbols(params.FilePath, fset, file)
+	} else if len(symbols) == 0 && !s.lspManager.HasServerFor(params.FilePath) {
+		return NewTextErrorResponse(fmt.Sprintf("no language server is configured for %s", params.FilePath)), nil
+	}
+
+	if len(symbols) == 0 {
+		return NewTextResponse("No symbols found"), nil
+	}
+	var sb strings.Builder
+	writeSymbols(&sb, symbols, 0)
+	return NewTextResponse(strings.TrimRight(sb.String(), "\n")), nil
+}
+
+func fromDocumentSymbols(symbols []lsp.DocumentSymbol) []codeSymbol {
+	result := make([]codeSymbol, 0, len(symbols))
+	for _, s := range symbols {
+		result = append(result, codeSymbol{
+			name:     s.Name,
+			kind:     s.Kind.String(),
+			line:     s.SelectionRange.Start.Line + 1,
+			column:   s.SelectionRange.Start.Character + 1,
+			endLine:  s.Range.End.Line + 1,
+			children: fromDocumentSymbols(s.Children),
+		})
+	}
+	return result
+}
+
+func writeSymbols(sb *strings.Builder, symbols []codeSymbol, depth int) {
+	for _, s := range symbols {
+		name := s.name
+		if s.container != "" && depth == 0 {
+			name = s.container + "." + name
+		}
+		lines := fmt.Sprintf("line %d", s.line)
+		if s.endLine > s.line {
+			lines = fmt.Sprintf("lines %d-%d", s.line, s.endLine)
+		}
+		fmt.Fprintf(sb, "%s%s %s (%s)\n", strings.Repeat("  ", depth), s.kind, name, lines)
+		writeSymbols(sb, s.children, depth+1)
+	}
+}
+
+func symbolsDescription() string {
+	return `Lists the symbols declared in a file (types, functions, methods, fields, variables and constants) with their line ranges.
+
+WHEN TO USE THIS TOOL:
+- Use to get an overview of a file before reading it
+- Helpful to find the line range of a function to view or edit only that part
+
+HOW TO USE:
+- Provide the path to the file
+
+FEATURES:
+- Uses the configured language servers when available
+- Falls back to parsing Go files when no language server answers
+- Nested symbols, like struct fields, are indented under their parent
+
+LIMITATIONS:
+- Other languages need a configured and installed language server
+- Only files inside the working directory can be listed
+
+TIPS:
+- Use the View tool with the returned line as offset to read a symbol`
+}
+
+func NewSymbolsTool(lspManager *lsp.Manager) BaseTool {
+	return &symbolsTool{lspManager: lspManager}
+}
@@ -0,0 +1,630 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools/shell"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+type testTool struct {
+	sandbox *config.Sandbox
+}
+
+const (
+	TestToolName = "test"
+
+	DefaultTestTimeout = 5 * 60 * 1000 // 5 minutes in milliseconds
+
+	testFormatGo    = "go"
+	testFormatJUnit = "junit"
+
+	// maxTestFailures is the number of failures described in a summary
+	maxTestFailures = 20
+	// maxTestLogLines is the number of log lines kept for a failure, from its
+	// start and its end
+	maxTestLogLines = 30
+	// maxTestMessageLines is the number of lines kept for an assertion message
+	maxTestMessageLines = 10
+)
+
+// defaultGoTestRunner is used for Go modules when no runner is configured.
+var defaultGoTestRunner = config.TestRunner{
+	Command: `go test -json {{if .Test}}-run {{.Test}} {{end}}{{if .Package}}{{.Package}}{{else}}./...{{end}}`,
+	Format:  testFormatGo,
+}
+
+type TestParams struct {
+	Runner  string `json:"runner"`
+	Package string `json:"package"`
+	Test    string `json:"test"`
+	Timeout int    `json:"timeout"`
+}
+
+type TestPermissionsParams struct {
+	Runner  string `json:"runner"`
+	Command string `json:"command"`
+}
+
+// testResult is a failed test, or a package or suite that failed outside of
+// its tests, like a build failure.
+type testResult struct {
+	pkg      string
+	name     string
+	location string
+	message  string
+	log      []string
+}
+
+type testSummary struct {
+	passed   int
+	failed   int
+	skipped  int
+	failures []testResult
+}
+
+func (t *testTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        TestToolName,
+		Description: testDescription(),
+		Parameters: map[string]any{
+			"runner": map[string]any{
+				"type":        "string",
+				"description": "The name of the configured test runner to use, only needed when several runners are configured",
+			},
+			"package": map[string]any{
+				"type":        "string",
+				"description": "The package or path to test, like ./internal/config/... for Go. Defaults to all the tests",
+			},
+			"test": map[string]any{
+				"type":        "string",
+				"description": "The name of the test to run, a regex for Go like ^TestParse$",
+			},
+			"timeout": map[string]any{
+				"type":        "number",
+				"description": "Optional timeout in milliseconds (default 300000, max 600000)",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+// Run implements Tool.
+func (t *testTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params TestParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.Timeout > MaxTimeout {
+		params.Timeout = MaxTimeout
+	} else if params.Timeout <= 0 {
+		params.Timeout = DefaultTestTimeout
+	}
+
+	name, runner, err := selectTestRunner(params.Runner)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	command, err := testCommand(runner, params)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("invalid command for test runner %s: %s", name, err)), nil
+	}
+
+	sessionID := GetSessionFromContext(ctx)
+	p := permission.Default.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        config.WorkingDirectory(),
+			ToolName:    TestToolName,
+			Action:      "execute",
+			Description: fmt.Sprintf("Run tests: %s", command),
+			Params: TestPermissionsParams{
+				Runner:  name,
+				Command: command,
+			},
+		},
+	)
+	if !p {
+		return NewTextErrorResponse("permission denied"), nil
+	}
+
+	wd := config.WorkingDirectory()
+	sandbox := shellSandbox(t.sandbox)
+	persistentShell, err := shell.GetPersistentShell(sessionID, wd, sandbox)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error starting shell: %s", err)), nil
+	}
+	quotedWd, err := syntax.Quote(wd, syntax.LangBash)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error quoting the working directory: %s", err)), nil
+	}
+
+	// reports written before the run are left out, they may be stale
+	started := time.Now().Add(-time.Second)
+	// a subshell so the current directory of the shell is kept
+	stdout, stderr, exitCode, interrupted, err := persistentShell.Exec(ctx, fmt.Sprintf("(cd %s && %s)", quotedWd, command), params.Timeout)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error running tests: %s", err)), nil
+	}
+
+	var summary testSummary
+	switch runner.Format {
+	case testFormatGo:
+		summary = parseGoTestJSON(stdout, stderr)
+	case testFormatJUnit:
+		reports, err := findJUnitReports(wd, runner.Reports, started)
+		if err == nil && len(reports) == 0 {
+			err = fmt.Errorf("no report matching %s was written", runner.Reports)
+		}
+		if err != nil {
+			return NewTextErrorResponse(spillOutput(ctx, TestToolName, fmt.Sprintf("error reading the test reports: %s\n\n%s", err, commandOutput(stdout, stderr, exitCode)))), nil
+		}
+		summary, err = parseJUnitReports(reports)
+		if err != nil {
+			return NewTextErrorResponse(fmt.Sprintf("error parsing the test reports: %s", err)), nil
+		}
+	}
+
+	output := formatTestSummary(summary)
+	if interrupted {
+		output = fmt.Sprintf("The tests were stopped before completion, after %s or when cancelled. The results are partial.\n\n%s", time.Duration(params.Timeout)*time.Millisecond, output)
+	} else if exitCode != 0 && summary.failed == 0 && len(summary.failures) == 0 {
+		// the command failed without a test failing, like a missing tool
+		output = fmt.Sprintf("%s\n\nThe command failed:\n%s", output, commandOutput(stdout, stderr, exitCode))
+	}
+	return NewTextResponse(spillOutput(ctx, TestToolName, output)), nil
+}
+
+// selectTestRunner returns the runner to use, the given one or the only one
+// configured. Go modules get a go test runner when none is configured.
+func selectTestRunner(name string) (string, config.TestRunner, error) {
+	runners := config.Get().Tests
+	if len(runners) == 0 {
+		runners = make(map[string]config.TestRunner)
+		if _, err := os.Stat(filepath.Join(config.WorkingDirectory(), "go.mod")); err == nil {
+			runners[testFormatGo] = defaultGoTestRunner
+		}
+	}
+	names := make([]string, 0, len(runners))
+	for runnerName := range runners {
+		names = append(names, runnerName)
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		return "", config.TestRunner{}, fmt.Errorf("no test runner is configured, add one to the tests section of the configuration or run the tests with the %s tool", BashToolName)
+	}
+	if name == "" {
+		if len(names) > 1 {
+			return "", config.TestRunner{}, fmt.Errorf("several test runners are configured, choose one of: %s", strings.Join(names, ", "))
+		}
+		name = names[0]
+	}
+	runner, ok := runners[name]
+	if !ok {
+		return "", config.TestRunner{}, fmt.Errorf("unknown test runner %q, the runners are: %s", name, strings.Join(names, ", "))
+	}
+	switch runner.Format {
+	case testFormatGo:
+	case testFormatJUnit:
+		if runner.Reports == "" {
+			return "", config.TestRunner{}, fmt.Errorf("test runner %s has no reports to read", name)
+		}
+	default:
+		return "", config.TestRunner{}, fmt.Errorf("test runner %s has an unknown format %q, use go or junit", name, runner.Format)
+	}
+	return name, runner, nil
+}
+
+// quoteTemplateValue quotes a parameter for the shell, an empty one staying
+// empty for the conditions of the template.
+func quoteTemplateValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	return syntax.Quote(value, syntax.LangBash)
+}
+
+// testCommand renders the command of the runner for the tool parameters.
+func testCommand(runner config.TestRunner, params TestParams) (string, error) {
+	tmpl, err := template.New("command").Parse(runner.Command)
+	if err != nil {
+		return "", err
+	}
+	var data struct {
+		Package string
+		Test    string
+	}
+	if data.Package, err = quoteTemplateValue(params.Package); err != nil {
+		return "", err
+	}
+	if data.Test, err = quoteTemplateValue(params.Test); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	command := strings.TrimSpace(sb.String())
+	if command == "" {
+		return "", fmt.Errorf("the command is empty")
+	}
+	return command, nil
+}
+
+func commandOutput(stdout, stderr string, exitCode int) string {
+	output := strings.TrimSpace(strings.Join([]string{trimLog(stdout), trimLog(stderr)}, "\n"))
+	return fmt.Sprintf("%s\nExit code %d", output, exitCode)
+}
+
+// trimLog keeps the start and the end of a long output.
+func trimLog(output string) string {
+	lines := trimLogLines(strings.Split(strings.TrimSpace(output), "\n"))
+	return strings.Join(lines, "\n")
+}
+
+func trimLogLines(lines []string) []string {
+	if len(lines) <= maxTestLogLines {
+		return lines
+	}
+	half := maxTestLogLines / 2
+	trimmed := slices.Clone(lines[:half])
+	trimmed = append(trimmed, fmt.Sprintf("[... %d lines ...]", len(lines)-2*half))
+	return append(trimmed, lines[len(lines)-half:]...)
+}
+
+type goTestEvent struct {
+	Action     string
+	Package    string
+	ImportPath string
+	Test       string
+	Output     string
+}
+
+// parseGoTestJSON summarizes the events printed by go test -json. Lines that
+// are not events, like build errors of older Go versions, are kept for the
+// packages that failed without a failing test.
+func parseGoTestJSON(stdout, stderr string) testSummary {
+	type testKey struct{ pkg, test string }
+	var (
+		order      []testKey
+		results    = make(map[testKey]string)
+		outputs    = make(map[testKey][]string)
+		failedPkgs []string
+		buildLines []string
+	)
+	for _, line := range strings.Split(stdout, "\n") {
+		var event goTestEvent
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil {
+			if strings.TrimSpace(line) != "" {
+				buildLines = append(buildLines, line)
+			}
+			continue
+		}
+		key := testKey{event.Package, event.Test}
+		switch event.Action {
+		case "output":
+			outputs[key] = append(outputs[key], strings.TrimSuffix(event.Output, "\n"))
+		case "build-output":
+			buildLines = append(buildLines, strings.TrimSuffix(event.Output, "\n"))
+		case "pass", "fail", "skip":
+			if event.Test == "" {
+				if event.Action == "fail" {
+					failedPkgs = append(failedPkgs, event.Package)
+				}
+				continue
+			}
+			if _, ok := results[key]; !ok {
+				order = append(order, key)
+			}
+			results[key] = event.Action
+		}
+	}
+	for _, line := range strings.Split(stderr, "\n") {
+		if strings.TrimSpace(line) != "" {
+			buildLines = append(buildLines, line)
+		}
+	}
+
+	var summary testSummary
+	pkgsWithFailures := make(map[string]bool)
+	for _, key := range order {
+		// parent tests are left out, their subtests are counted
+		if slices.ContainsFunc(order, func(other testKey) bool {
+			return other.pkg == key.pkg && strings.HasPrefix(other.test, key.test+"/")
+		}) {
+			continue
+		}
+		switch results[key] {
+		case "pass":
+			summary.passed++
+		case "skip":
+			summary.skipped++
+		case "fail":
+			summary.failed++
+			pkgsWithFailures[key.pkg] = true
+			summary.failures = append(summary.failures, goTestFailure(key.pkg, key.test, outputs[key]))
+		}
+	}
+	for _, pkg := range failedPkgs {
+		if pkgsWithFailures[pkg] {
+			continue
+		}
+		var log []string
+		for _, line := range outputs[testKey{pkg: pkg}] {
+			if trimmed := strings.TrimSpace(line); trimmed != "FAIL" && !strings.HasPrefix(trimmed, "FAIL\t") {
+				log = append(log, line)
+			}
+		}
+		if len(log) == 0 {
+			log = buildLines
+		}
+		summary.failures = append(summary.failures, testResult{pkg: pkg, log: trimLogLines(log)})
+	}
+	return summary
+}
+
+var (
+	goTestLocationPattern = regexp.MustCompile(`(?:^|\s)(\S+\.go:\d+)(?::\s*(.*))?$`)
+	testifyLabelPattern   = regexp.MustCompile(`^(Error Trace|Error|Test|Messages|Diff):`)
+)
+
+// goTestFailure extracts the location and the message of a failure from the
+// output of the test, as printed by the testing package or testify.
+func goTestFailure(pkg, name string, output []string) testResult {
+	result := testResult{pkg: pkg, name: name}
+	var log []string
+	for _, line := range output {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- FAIL:") || trimmed == "" {
+			continue
+		}
+		log = append(log, line)
+	}
+
+	var message []string
+	inTestifyError := false
+	for _, line := range log {
+		trimmed := strings.TrimSpace(line)
+		if inTestifyError {
+			if testifyLabelPattern.MatchString(trimmed) {
+				inTestifyError = false
+			} else {
+				message = append(message, trimmed)
+				continue
+			}
+		}
+		if result.location == "" {
+			if m := goTestLocationPattern.FindStringSubmatch(trimmed); m != nil {
+				result.location = m[1]
+				if m[2] != "" && len(message) == 0 {
+					message = append(message, m[2])
+				}
+			}
+		}
+		switch {
+		case strings.HasPrefix(trimmed, "Error:") && len(message) == 0:
+			message = append(message, strings.TrimSpace(strings.TrimPrefix(trimmed, "Error:")))
+			inTestifyError = true
+		case strings.HasPrefix(trimmed, "panic:") && len(message) == 0:
+			message = append(message, trimmed)
+		}
+	}
+	if len(message) > maxTestMessageLines {
+		message = append(message[:maxTestMessageLines], "...")
+	}
+	result.message = strings.Join(message, "\n")
+	result.log = trimLogLines(dedentLines(log))
+	return result
+}
+
+// dedentLines removes the indentation common to all the lines, go test
+// indents the log of tests by their depth.
+func dedentLines(lines []string) []string {
+	indent := -1
+	for _, line := range lines {
+		n := len(line) - len(strings.TrimLeft(line, " "))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent <= 0 {
+		return lines
+	}
+	dedented := make([]string, len(lines))
+	for i, line := range lines {
+		dedented[i] = line[indent:]
+	}
+	return dedented
+}
+
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string         `xml:"name,attr"`
+	Classname string         `xml:"classname,attr"`
+	File      string         `xml:"file,attr"`
+	Line      string         `xml:"line,attr"`
+	Failures  []junitFailure `xml:"failure"`
+	Errors    []junitFailure `xml:"error"`
+	Skipped   *struct{}      `xml:"skipped"`
+	SystemOut string         `xml:"system-out"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+var junitLocationPattern = regexp.MustCompile(`([\w./\\-]+\.\w+):(\d+)`)
+
+// findJUnitReports returns the reports matching pattern, relative to dir,
+// written since a time.
+func findJUnitReports(dir, pattern string, since time.Time) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+	paths, err := doublestar.FilepathGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var reports []string
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() && !info.ModTime().Before(since) {
+			reports = append(reports, path)
+		}
+	}
+	return reports, nil
+}
+
+// parseJUnitReports summarizes JUnit XML reports, their root is either a
+// testsuites or a testsuite element.
+func parseJUnitReports(paths []string) (testSummary, error) {
+	var summary testSummary
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return summary, err
+		}
+		var root junitSuite
+		if err := xml.NewDecoder(bytes.NewReader(content)).Decode(&root); err != nil {
+			return summary, fmt.Errorf("%s: %w", path, err)
+		}
+		addJUnitSuite(&summary, root)
+	}
+	return summary, nil
+}
+
+func addJUnitSuite(summary *testSummary, suite junitSuite) {
+	for _, nested := range suite.Suites {
+		addJUnitSuite(summary, nested)
+	}
+	for _, testCase := range suite.Cases {
+		failures := append(slices.Clone(testCase.Failures), testCase.Errors...)
+		switch {
+		case len(failures) > 0:
+			summary.failed++
+			summary.failures = append(summary.failures, junitTestFailure(suite, testCase, failures[0]))
+		case testCase.Skipped != nil:
+			summary.skipped++
+		default:
+			summary.passed++
+		}
+	}
+}
+
+func junitTestFailure(suite junitSuite, testCase junitCase, failure junitFailure) testResult {
+	result := testResult{pkg: testCase.Classname, name: testCase.Name}
+	if result.pkg == "" {
+		result.pkg = suite.Name
+	}
+	text := strings.TrimSpace(failure.Text)
+	if testCase.File != "" && testCase.Line != "" {
+		result.location = testCase.File + ":" + testCase.Line
+	} else if m := junitLocationPattern.FindString(text); m != "" {
+		result.location = m
+	}
+
+	result.message = strings.TrimSpace(failure.Message)
+	if result.message == "" && text != "" {
+		result.message = strings.Split(text, "\n")[0]
+	}
+	if failure.Type != "" && !strings.Contains(result.message, failure.Type) {
+		result.message = strings.TrimSpace(failure.Type + ": " + result.message)
+	}
+	if text != "" {
+		result.log = trimLogLines(strings.Split(text, "\n"))
+	}
+	return result
+}
+
+func formatTestSummary(summary testSummary) string {
+	counts := fmt.Sprintf("%d passed, %d failed, %d skipped", summary.passed, summary.failed, summary.skipped)
+	if len(summary.failures) == 0 {
+		if summary.passed+summary.skipped == 0 {
+			return "No tests were run"
+		}
+		return "PASS: " + counts
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "FAIL: %s", counts)
+	for i, failure := range summary.failures {
+		if i == maxTestFailures {
+			fmt.Fprintf(&sb, "\n\n... and %d more failures", len(summary.failures)-maxTestFailures)
+			break
+		}
+		if failure.name == "" {
+			fmt.Fprintf(&sb, "\n\n--- FAIL: %s", failure.pkg)
+		} else {
+			fmt.Fprintf(&sb, "\n\n--- FAIL: %s (%s)", failure.name, failure.pkg)
+		}
+		switch {
+		case failure.location != "" && failure.message != "":
+			fmt.Fprintf(&sb, "\n%s: %s", failure.location, failure.message)
+		case failure.location != "":
+			fmt.Fprintf(&sb, "\n%s", failure.location)
+		case failure.message != "":
+			fmt.Fprintf(&sb, "\n%s", failure.message)
+		}
+		if len(failure.log) > 0 {
+			sb.WriteString("\nLog:")
+			for _, line := range failure.log {
+				fmt.Fprintf(&sb, "\n    %s", strings.TrimRight(line, " \t"))
+			}
+		}
+	}
+	return sb.String()
+}
+
+func testDescription() string {
+	return fmt.Sprintf(`Runs the tests of the project and returns a compact summary: the number of passed, failed and skipped tests, and for each failure its location, assertion message and a trimmed log.
+
+WHEN TO USE THIS TOOL:
+- Use to run tests instead of the %s tool, the summary is much shorter than the raw output
+- Use after a change to check that the tests still pass, or to reproduce a failing test
+
+HOW TO USE:
+- Without parameters, all the tests of the project are run
+- Give a package or path to only test it, like ./internal/config/... for Go
+- Give a test name to only run the matching tests, it is a regex for Go like ^TestParse$
+- When several test runners are configured, choose one with runner
+
+FEATURES:
+- Go modules are tested with go test -json without any configuration
+- Other frameworks are run with the commands of the tests section of the configuration, writing JUnit XML reports
+- Failures report the file and line, the message and the log of the failing test or package, including build failures
+
+LIMITATIONS:
+- Only the configured test runners can be used, run other test commands with the %s tool
+- Logs are trimmed to their first and last lines
+- The default timeout is 5 minutes
+
+TIPS:
+- Run the failing test alone while fixing it, then the whole package
+- Read the file at the failure location before changing the code or the test`, BashToolName, BashToolName)
+}
+
+func NewTestTool(sandbox *config.Sandbox) BaseTool {
+	return &testTool{sandbox: sandbox}
+}
@@ -0,0 +1,219 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   TestParams
+		expected string
+	}{
+		{
+			name:     "runs all the tests",
+			expected: "go test -json ./...",
+		},
+		{
+			name:     "runs a package",
+			params:   TestParams{Package: "./internal/config/..."},
+			expected: "go test -json ./internal/config/...",
+		},
+		{
+			name:     "quotes the test name",
+			params:   TestParams{Package: "./pkg", Test: "^TestParse$"},
+			expected: "go test -json -run '^TestParse$' ./pkg",
+		},
+		{
+			name:     "quotes injected commands",
+			params:   TestParams{Test: "x; rm -rf /"},
+			expected: "go test -json -run 'x; rm -rf /' ./...",
+		},
+		{
+			name:     "package and test with the same value",
+			params:   TestParams{Package: "./calc", Test: "./calc"},
+			expected: "go test -json -run ./calc ./calc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, err := testCommand(defaultGoTestRunner, tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, command)
+		})
+	}
+
+	_, err := testCommand(config.TestRunner{Command: "{{if}}"}, TestParams{})
+	assert.Error(t, err)
+}
+
+const goTestJSONOutput = `{"Action":"start","Package":"example.com/calc"}
+{"Action":"run","Package":"example.com/calc","Test":"TestAdd"}
+{"Action":"output","Package":"example.com/calc","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestAdd","Output":"--- PASS: TestAdd (0.00s)\n"}
+{"Action":"pass","Package":"example.com/calc","Test":"TestAdd","Elapsed":0}
+{"Action":"run","Package":"example.com/calc","Test":"TestDiv"}
+{"Action":"output","Package":"example.com/calc","Test":"TestDiv","Output":"=== RUN   TestDiv\n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestDiv","Output":"    calc_test.go:21: Div(1, 0) = 0, want an error\n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestDiv","Output":"--- FAIL: TestDiv (0.00s)\n"}
+{"Action":"fail","Package":"example.com/calc","Test":"TestDiv","Elapsed":0}
+{"Action":"run","Package":"example.com/calc","Test":"TestMul"}
+{"Action":"run","Package":"example.com/calc","Test":"TestMul/negative"}
+{"Action":"output","Package":"example.com/calc","Test":"TestMul/negative","Output":"=== RUN   TestMul/negative\n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestMul/negative","Output":"    calc_test.go:34: \n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestMul/negative","Output":"        \tError Trace:\t/src/calc/calc_test.go:34\n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestMul/negative","Output":"        \tError:      \tNot equal: \n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestMul/negative","Output":"        \t            \texpected: -6\n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestMul/negative","Output":"        \t            \tactual  : 6\n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestMul/negative","Output":"        \tTest:       \tTestMul/negative\n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestMul/negative","Output":"    --- FAIL: TestMul/negative (0.00s)\n"}
+{"Action":"fail","Package":"example.com/calc","Test":"TestMul/negative","Elapsed":0}
+{"Action":"run","Package":"example.com/calc","Test":"TestMul/positive"}
+{"Action":"pass","Package":"example.com/calc","Test":"TestMul/positive","Elapsed":0}
+{"Action":"output","Package":"example.com/calc","Test":"TestMul","Output":"--- FAIL: TestMul (0.00s)\n"}
+{"Action":"fail","Package":"example.com/calc","Test":"TestMul","Elapsed":0}
+{"Action":"run","Package":"example.com/calc","Test":"TestPow"}
+{"Action":"skip","Package":"example.com/calc","Test":"TestPow","Elapsed":0}
+{"Action":"output","Package":"example.com/calc","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/calc","Elapsed":0.01}
+{"ImportPath":"example.com/broken","Action":"build-output","Output":"# example.com/broken\n"}
+{"ImportPath":"example.com/broken","Action":"build-output","Output":"broken/broken.go:3:8: undefined: missing\n"}
+{"ImportPath":"example.com/broken","Action":"build-fail"}
+{"Action":"start","Package":"example.com/broken"}
+{"Action":"output","Package":"example.com/broken","Output":"FAIL\texample.com/broken [build failed]\n"}
+{"Action":"fail","Package":"example.com/broken","Elapsed":0,"FailedBuild":"example.com/broken"}
+`
+
+func TestParseGoTestJSON(t *testing.T) {
+	summary := parseGoTestJSON(goTestJSONOutput, "")
+	assert.Equal(t, 2, summary.passed)
+	assert.Equal(t, 2, summary.failed)
+	assert.Equal(t, 1, summary.skipped)
+	require.Len(t, summary.failures, 3)
+
+	assert.Equal(t, testResult{
+		pkg:      "example.com/calc",
+		name:     "TestDiv",
+		location: "calc_test.go:21",
+		message:  "Div(1, 0) = 0, want an error",
+		log:      []string{"calc_test.go:21: Div(1, 0) = 0, want an error"},
+	}, summary.failures[0])
+
+	negative := summary.failures[1]
+	assert.Equal(t, "TestMul/negative", negative.name)
+	assert.Equal(t, "calc_test.go:34", negative.location)
+	assert.Equal(t, "Not equal:\nexpected: -6\nactual  : 6", negative.message)
+
+	assert.Equal(t, testResult{
+		pkg: "example.com/broken",
+		log: []string{"# example.com/broken", "broken/broken.go:3:8: undefined: missing"},
+	}, summary.failures[2])
+
+	t.Run("trims long logs", func(t *testing.T) {
+		var output strings.Builder
+		for range 100 {
+			output.WriteString(`{"Action":"output","Package":"p","Test":"TestLong","Output":"line\n"}` + "\n")
+		}
+		output.WriteString(`{"Action":"fail","Package":"p","Test":"TestLong"}` + "\n")
+		summary := parseGoTestJSON(output.String(), "")
+		require.Len(t, summary.failures, 1)
+		assert.Len(t, summary.failures[0].log, maxTestLogLines+1)
+		assert.Contains(t, summary.failures[0].log, "[... 70 lines ...]")
+	})
+}
+
+func TestParseJUnitReports(t *testing.T) {
+	dir := t.TempDir()
+	report := filepath.Join(dir, "reports", "junit.xml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(report), 0o755))
+	require.NoError(t, os.WriteFile(report, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="calc">
+    <testcase classname="tests.test_calc" name="test_add" file="tests/test_calc.py" line="4"/>
+    <testcase classname="tests.test_calc" name="test_div" file="tests/test_calc.py" line="8">
+      <failure message="assert 0 == 1">def test_div():
+&gt;       assert div(1, 1) == 1
+E       assert 0 == 1
+
+tests/test_calc.py:9: AssertionError</failure>
+    </testcase>
+    <testcase classname="tests.test_calc" name="test_pow">
+      <skipped message="not implemented"/>
+    </testcase>
+    <testcase classname="CalcTest" name="mul">
+      <error type="TypeError">TypeError: x is undefined
+    at Object.mul (src/calc.test.js:12:5)</error>
+    </testcase>
+  </testsuite>
+</testsuites>`), 0o644))
+	stale := filepath.Join(dir, "reports", "old.xml")
+	require.NoError(t, os.WriteFile(stale, []byte("<testsuite/>"), 0o644))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(stale, old, old))
+
+	reports, err := findJUnitReports(dir, "reports/*.xml", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []string{report}, reports)
+
+	summary, err := parseJUnitReports(reports)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.passed)
+	assert.Equal(t, 2, summary.failed)
+	assert.Equal(t, 1, summary.skipped)
+	require.Len(t, summary.failures, 2)
+
+	assert.Equal(t, "tests.test_calc", summary.failures[0].pkg)
+	assert.Equal(t, "test_div", summary.failures[0].name)
+	assert.Equal(t, "tests/test_calc.py:8", summary.failures[0].location)
+	assert.Equal(t, "assert 0 == 1", summary.failures[0].message)
+
+	assert.Equal(t, "src/calc.test.js:12", summary.failures[1].location)
+	assert.Equal(t, "TypeError: x is undefined", summary.failures[1].message)
+
+	_, err = parseJUnitReports([]string{stale + ".missing"})
+	assert.Error(t, err)
+}
+
+func TestFormatTestSummary(t *testing.T) {
+	assert.Equal(t, "No tests were run", formatTestSummary(testSummary{}))
+	assert.Equal(t, "PASS: 3 passed, 0 failed, 1 skipped", formatTestSummary(testSummary{passed: 3, skipped: 1}))
+
+	summary := parseGoTestJSON(goTestJSONOutput, "")
+	assert.Equal(t, `FAIL: 2 passed, 2 failed, 1 skipped
+
+--- FAIL: TestDiv (example.com/calc)
+calc_test.go:21: Div(1, 0) = 0, want an error
+Log:
+    calc_test.go:21: Div(1, 0) = 0, want an error
+
+--- FAIL: TestMul/negative (example.com/calc)
+calc_test.go:34: Not equal:
+expected: -6
+actual  : 6
+Log:
+    calc_test.go:34:
+        	Error Trace:	/src/calc/calc_test.go:34
+        	Error:      	Not equal:
+        	            	expected: -6
+        	            	actual  : 6
+        	Test:       	TestMul/negative
+
+--- FAIL: example.com/broken
+Log:
+    # example.com/broken
+    broken/broken.go:3:8: undefined: missing`, formatTestSummary(summary))
+
+	many := testSummary{failed: maxTestFailures + 5}
+	for range maxTestFailures + 5 {
+		many.failures = append(many.failures, testResult{pkg: "p", name: "TestX"})
+	}
+	assert.True(t, strings.HasSuffix(formatTestSummary(many), "... and 5 more failures"))
+}
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// utf8BOM is the byte order mark some editors put at the start of UTF-8 files
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// defaultFileMode is the mode of the files created by the tools
+const defaultFileMode fs.FileMode = 0o644
+
+// textFormat is the encoding convention of a text file. The tools work on
+// content without byte order mark and with LF line endings, the convention of
+// the file is restored when it is written back.
+type textFormat struct {
+	bom  bool
+	crlf bool
+	// finalNewline is nil for new and empty files, which have no convention
+	finalNewline *bool
+	mode         fs.FileMode
+}
+
+// detectTextFormat returns the convention of content. Line endings are only
+// converted when every line ends with CRLF, files mixing both are kept as is.
+func detectTextFormat(content []byte, mode fs.FileMode) textFormat {
+	format := textFormat{mode: mode}
+	if bytes.HasPrefix(content, utf8BOM) {
+		format.bom = true
+		content = content[len(utf8BOM):]
+	}
+	if crlf := bytes.Count(content, []byte("\r\n")); crlf > 0 && crlf == bytes.Count(content, []byte("\n")) {
+		format.crlf = true
+	}
+	if len(content) > 0 {
+		finalNewline := content[len(content)-1] == '\n'
+		format.finalNewline = &finalNewline
+	}
+	return format
+}
+
+// decode returns content without byte order mark and with LF line endings.
+func (f textFormat) decode(content []byte) string {
+	text := string(bytes.TrimPrefix(content, utf8BOM))
+	if f.crlf {
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	}
+	return text
+}
+
+// encode returns text in the convention of the file.
+func (f textFormat) encode(text string) []byte {
+	text = strings.TrimPrefix(text, string(utf8BOM))
+	if f.crlf {
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	}
+	if f.finalNewline != nil && text != "" {
+		hasNewline := strings.HasSuffix(text, "\n")
+		if *f.finalNewline && !hasNewline {
+			text += "\n"
+		} else if !*f.finalNewline && hasNewline {
+			text = strings.TrimSuffix(text, "\n")
+		}
+	}
+	if f.crlf {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	if f.bom {
+		return append(append([]byte{}, utf8BOM...), text...)
+	}
+	return []byte(text)
+}
+
+// readFileText returns the decoded content of a file and its convention.
+func readFileText(path string) (string, textFormat, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", textFormat{}, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", textFormat{}, err
+	}
+	format := detectTextFormat(content, info.Mode().Perm())
+	return format.decode(content), format, nil
+}
+
+// writeFileText encodes text in the convention of the file and writes it
+// atomically, and returns the bytes written.
+func writeFileText(path, text string, format textFormat) ([]byte, error) {
+	content := format.encode(text)
+	return content, writeFileAtomic(path, content, format.mode)
+}
+
+// writeFileAtomic writes content to a temporary file next to path and renames
+// it over path, so a crash never leaves a truncated file. A symbolic link is
+// kept, its target is replaced.
+func writeFileAtomic(path string, content []byte, mode fs.FileMode) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	_, statErr := os.Stat(path)
+	tmp, err := createTempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp-", mode)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	// a new file gets the mode less the umask, an existing one keeps its mode
+	// exactly
+	if statErr == nil {
+		if err := tmp.Chmod(mode); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// createTempFile creates a new file in dir, named prefix followed by random
+// hex digits, with perm less the umask. os.CreateTemp always uses 0600.
+func createTempFile(dir, prefix string, perm fs.FileMode) (*os.File, error) {
+	for range 100 {
+		suffix := make([]byte, 4)
+		if _, err := rand.Read(suffix); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(filepath.Join(dir, prefix+hex.EncodeToString(suffix)), os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if os.IsExist(err) {
+			continue
+		}
+		return f, err
+	}
+	return nil, &fs.PathError{Op: "createtemp", Path: filepath.Join(dir, prefix+"*"), Err: fs.ErrExist}
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		decoded  string
+		edited   string
+		encoded  string
+	}{
+		{name: "new file", original: "", decoded: "", edited: "a", encoded: "a"},
+		{name: "LF", original: "a\nb\n", decoded: "a\nb\n", edited: "a\nc", encoded: "a\nc\n"},
+		{name: "CRLF", original: "a\r\nb\r\n", decoded: "a\nb\n", edited: "a\nc\n", encoded: "a\r\nc\r\n"},
+		{name: "CRLF in the edit", original: "a\r\nb\r\n", decoded: "a\nb\n", edited: "a\r\nc\n", encoded: "a\r\nc\r\n"},
+		{name: "mixed", original: "a\r\nb\n", decoded: "a\r\nb\n", edited: "a\r\nc\n", encoded: "a\r\nc\n"},
+		{name: "no final newline", original: "a\nb", decoded: "a\nb", edited: "a\nc\n", encoded: "a\nc"},
+		{name: "BOM", original: "\xEF\xBB\xBFa\n", decoded: "a\n", edited: "\xEF\xBB\xBFc\n", encoded: "\xEF\xBB\xBFc\n"},
+		{name: "emptied file", original: "a\n", decoded: "a\n", edited: "", encoded: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format := detectTextFormat([]byte(tt.original), 0o644)
+			assert.Equal(t, tt.decoded, format.decode([]byte(tt.original)))
+			assert.Equal(t, tt.encoded, string(format.encode(tt.edited)))
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.sh")
+
+	require.NoError(t, writeFileAtomic(path, []byte("#!/bin/sh\n"), 0o755))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\n", string(content))
+
+	// a new file is created like any other, with the umask applied
+	reference := filepath.Join(t.TempDir(), "reference")
+	require.NoError(t, os.WriteFile(reference, nil, 0o755))
+	expected, err := os.Stat(reference)
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, expected.Mode().Perm(), info.Mode().Perm())
+
+	// an existing file keeps its mode
+	require.NoError(t, os.Chmod(path, 0o775))
+	require.NoError(t, writeFileAtomic(path, []byte("#!/bin/sh\n"), 0o775))
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o775), info.Mode().Perm())
+
+	// a failed write leaves the file and no temporary file
+	require.NoError(t, os.Chmod(dir, 0o555))
+	defer os.Chmod(dir, 0o755)
+	if os.Geteuid() != 0 {
+		assert.Error(t, writeFileAtomic(path, []byte("truncated"), 0o755))
+	}
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\n", string(content))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
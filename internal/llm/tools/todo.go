@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/todo"
+)
+
+type todoTool struct {
+	todos todo.Service
+}
+
+const (
+	TodoToolName = "todo"
+	maxTodos     = 50
+)
+
+type TodoParams struct {
+	// Todos replaces the list when set, a nil list only reads it
+	Todos []TodoItem `json:"todos"`
+}
+
+type TodoItem struct {
+	Content string `json:"content"`
+	Status  string `json:"status"`
+}
+
+func (t *todoTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        TodoToolName,
+		Description: todoDescription(),
+		Parameters: map[string]any{
+			"todos": map[string]any{
+				"type":        "array",
+				"description": "The complete todo list, replacing the current one. Omit it to read the current list",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"content": map[string]any{
+							"type":        "string",
+							"description": "What needs to be done, as a short imperative sentence",
+						},
+						"status": map[string]any{
+							"type":        "string",
+							"enum":        []string{string(todo.Pending), string(todo.InProgress), string(todo.Done)},
+							"description": "The status of the todo",
+						},
+					},
+					"required": []string{"content", "status"},
+				},
+			},
+		},
+		Required: []string{},
+	}
+}
+
+// Run implements Tool.
+func (t *todoTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params TodoParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	sessionID := GetSessionFromContext(ctx)
+	if sessionID == "" {
+		return NewTextErrorResponse("the todo list needs a session"), nil
+	}
+
+	if params.Todos == nil {
+		list, err := t.todos.Get(sessionID)
+		if err != nil {
+			return NewTextErrorResponse(fmt.Sprintf("failed to read the todo list: %s", err)), nil
+		}
+		return NewTextResponse(FormatTodoList(list)), nil
+	}
+
+	todos, err := validateTodos(params.Todos)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	list, err := t.todos.Save(sessionID, todos)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("failed to save the todo list: %s", err)), nil
+	}
+	return NewTextResponse(FormatTodoList(list)), nil
+}
+
+func validateTodos(items []TodoItem) ([]todo.Todo, error) {
+	if len(items) > maxTodos {
+		return nil, fmt.Errorf("too many todos (%d), the maximum is %d", len(items), maxTodos)
+	}
+	todos := make([]todo.Todo, 0, len(items))
+	inProgress := 0
+	for i, item := range items {
+		content := strings.TrimSpace(item.Content)
+		if content == "" {
+			return nil, fmt.Errorf("todo %d has no content", i+1)
+		}
+		status := todo.Status(item.Status)
+		switch status {
+		case todo.Pending, todo.Done:
+		case todo.InProgress:
+			inProgress++
+		default:
+			return nil, fmt.Errorf("todo %d has an invalid status %q, use pending, in_progress or done", i+1, item.Status)
+		}
+		todos = append(todos, todo.Todo{Content: content, Status: status})
+	}
+	if inProgress > 1 {
+		return nil, fmt.Errorf("%d todos are in progress, only one can be in progress at a time", inProgress)
+	}
+	return todos, nil
+}
+
+// FormatTodoList renders a todo list as a checklist.
+func FormatTodoList(list todo.List) string {
+	if len(list.Todos) == 0 {
+		return "The todo list is empty"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Todo list (%d/%d done):\n", list.Counts()[todo.Done], len(list.Todos))
+	for _, t := range list.Todos {
+		mark := " "
+		switch t.Status {
+		case todo.InProgress:
+			mark = "~"
+		case todo.Done:
+			mark = "x"
+		}
+		fmt.Fprintf(&sb, "[%s] %s\n", mark, t.Content)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func todoDescription() string {
+	return `Creates and updates the todo list of the current session, a checklist shown to the user to follow the progress of a task.
+
+WHEN TO USE THIS TOOL:
+- Use for tasks that need three steps or more, or when the user gives a list of things to do
+- Update it as soon as a step starts or is done, do not batch updates
+- Skip it for simple tasks that take a step or two
+
+HOW TO USE:
+- Send the complete list every time, it replaces the current one
+- Each todo has a content and a status: pending, in_progress or done
+- Mark a todo in_progress before working on it, and done right after finishing it
+- Omit todos to read the current list
+
+FEATURES:
+- The list is saved with the session and shown in a panel next to the conversation
+- The list is added back to the context when earlier messages are no longer available
+
+LIMITATIONS:
+- Only one todo can be in progress at a time
+- A list holds at most 50 todos
+
+TIPS:
+- Only mark a todo done when it is fully done, keep it in progress if tests fail or the work is partial
+- Add the new steps discovered while working to the list
+- Remove todos that are no longer relevant instead of leaving them pending`
+}
+
+func NewTodoTool(todos todo.Service) BaseTool {
+	return &todoTool{todos: todos}
+}
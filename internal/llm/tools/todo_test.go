@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
+	"github.com/imnulhaqueruman/opencode-poc/internal/todo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockTodoService struct {
+	*pubsub.Broker[todo.List]
+	lists map[string][]todo.Todo
+}
+
+func (m *mockTodoService) Get(sessionID string) (todo.List, error) {
+	return todo.List{SessionID: sessionID, Todos: m.lists[sessionID]}, nil
+}
+
+func (m *mockTodoService) Save(sessionID string, todos []todo.Todo) (todo.List, error) {
+	m.lists[sessionID] = todos
+	return m.Get(sessionID)
+}
+
+func newMockTodoService() *mockTodoService {
+	return &mockTodoService{
+		Broker: pubsub.NewBroker[todo.List](),
+		lists:  make(map[string][]todo.Todo),
+	}
+}
+
+func TestTodoTool(t *testing.T) {
+	todos := newMockTodoService()
+	tool := NewTodoTool(todos)
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "session")
+
+	run := func(t *testing.T, ctx context.Context, input string) ToolResponse {
+		response, err := tool.Run(ctx, ToolCall{Input: input})
+		require.NoError(t, err)
+		return response
+	}
+	write := func(t *testing.T, items ...TodoItem) ToolResponse {
+		input, err := json.Marshal(TodoParams{Todos: items})
+		require.NoError(t, err)
+		return run(t, ctx, string(input))
+	}
+
+	t.Run("read an empty list", func(t *testing.T) {
+		response := run(t, ctx, `{}`)
+		assert.False(t, response.IsError)
+		assert.Equal(t, "The todo list is empty", response.Content)
+	})
+
+	t.Run("write the list", func(t *testing.T) {
+		response := write(t,
+			TodoItem{Content: "Read the parser", Status: "done"},
+			TodoItem{Content: " Add the flag ", Status: "in_progress"},
+			TodoItem{Content: "Run the tests", Status: "pending"},
+		)
+		require.False(t, response.IsError, response.Content)
+		expected := "Todo list (1/3 done):\n[x] Read the parser\n[~] Add the flag\n[ ] Run the tests"
+		assert.Equal(t, expected, response.Content)
+		assert.Equal(t, []todo.Todo{
+			{Content: "Read the parser", Status: todo.Done},
+			{Content: "Add the flag", Status: todo.InProgress},
+			{Content: "Run the tests", Status: todo.Pending},
+		}, todos.lists["session"])
+
+		response = run(t, ctx, `{}`)
+		assert.Equal(t, expected, response.Content)
+	})
+
+	t.Run("an empty list clears it", func(t *testing.T) {
+		response := run(t, ctx, `{"todos": []}`)
+		require.False(t, response.IsError, response.Content)
+		assert.Equal(t, "The todo list is empty", response.Content)
+		assert.Empty(t, todos.lists["session"])
+	})
+
+	t.Run("invalid lists", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			items []TodoItem
+			error string
+		}{
+			{name: "no content", items: []TodoItem{{Content: " ", Status: "pending"}}, error: "todo 1 has no content"},
+			{name: "invalid status", items: []TodoItem{{Content: "a", Status: "started"}}, error: `todo 1 has an invalid status "started"`},
+			{name: "two in progress", items: []TodoItem{{Content: "a", Status: "in_progress"}, {Content: "b", Status: "in_progress"}}, error: "only one can be in progress"},
+			{name: "too many", items: make([]TodoItem, maxTodos+1), error: "too many todos"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				response := write(t, tt.items...)
+				assert.True(t, response.IsError)
+				assert.Contains(t, response.Content, tt.error)
+			})
+		}
+	})
+
+	t.Run("needs a session", func(t *testing.T) {
+		response := run(t, context.Background(), `{}`)
+		assert.True(t, response.IsError)
+	})
+}
@@ -46,4 +46,13 @@ type ToolCall struct {
 type BaseTool interface {
 	Info() ToolInfo
 	Run(ctx context.Context, params ToolCall) (ToolResponse, error)
-}
\ No newline at end of file
+}
+
+type sessionIDContextKey string
+
+const SessionIDContextKey sessionIDContextKey = "session_id"
+
+func GetSessionFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(SessionIDContextKey).(string)
+	return sessionID
+}
@@ -11,9 +11,14 @@ import (
 	"strings"
 
 	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
+	"github.com/imnulhaqueruman/opencode-poc/internal/lsp"
 )
 
-type viewTool struct{}
+type viewTool struct {
+	lspManager *lsp.Manager
+	files      filerecord.Service
+}
 
 const (
 	ViewToolName     = "view"
@@ -66,6 +71,9 @@ func (v *viewTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 	if !filepath.IsAbs(filePath) {
 		filePath = filepath.Join(config.WorkingDirectory(), filePath)
 	}
+	if err := checkFileAccess(filePath); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
 
 	// Check if file exists
 	fileInfo, err := os.Stat(filePath)
@@ -136,7 +144,8 @@ func (v *viewTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 			params.Offset+len(strings.Split(content, "\n")))
 	}
 
-	recordFileRead(filePath)
+	recordFileRead(ctx, v.files, filePath)
+	v.lspManager.OpenFile(filePath)
 	return NewTextResponse(output), nil
 }
 
@@ -283,13 +292,15 @@ LIMITATIONS:
 - Lines longer than 2000 characters are truncated
 - Cannot display binary files or images
 - Images can be identified but not displayed
+- Ignored files that may hold secrets, like a .env file listed in .gitignore, cannot be read
 
 TIPS:
 - Use with Glob tool to first find files you want to view
 - For code exploration, first use Grep to find relevant files, then View to examine them
-- When viewing large files, use the offset parameter to read specific sections`
+- When viewing large files, use the offset parameter to read specific sections
+- For large source files, use the Outline tool first to find the lines of the declaration you need`
 }
 
-func NewViewTool() BaseTool {
-	return &viewTool{}
+func NewViewTool(lspManager *lsp.Manager, files filerecord.Service) BaseTool {
+	return &viewTool{lspManager: lspManager, files: files}
 }
\ No newline at end of file
@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
+	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
+)
+
+// watcherDebounce is how long the watcher waits for events to settle before
+// looking at the changed files, editors often write a file in several steps.
+const watcherDebounce = 200 * time.Millisecond
+
+// ExternalChange is a change made outside termai to a file a session read.
+type ExternalChange struct {
+	SessionID string
+	Path      string
+	Removed   bool
+}
+
+// FileWatcher watches the working directory for changes made to the files the
+// sessions read, by the user in their editor or by another program. Changes
+// are published as created events when they are seen, and as deleted events
+// once taken.
+type FileWatcher interface {
+	pubsub.Suscriber[ExternalChange]
+	// TakeExternalChanges returns the changes to the files of a session since
+	// they were last taken, leaving out the files the session read again.
+	TakeExternalChanges(sessionID string) []ExternalChange
+	Close() error
+}
+
+type fileWatcher struct {
+	*pubsub.Broker[ExternalChange]
+	root    string
+	files   filerecord.Service
+	watcher *fsnotify.Watcher
+
+	mu      sync.Mutex
+	pending map[string]map[string]ExternalChange // sessionID -> path -> change
+}
+
+func (w *fileWatcher) TakeExternalChanges(sessionID string) []ExternalChange {
+	w.mu.Lock()
+	pending := w.pending[sessionID]
+	delete(w.pending, sessionID)
+	w.mu.Unlock()
+
+	changes := make([]ExternalChange, 0, len(pending))
+	for _, change := range pending {
+		w.Publish(pubsub.DeletedEvent, change)
+		// the session may have read or written the file since, or moved or
+		// deleted it itself
+		record, err := w.files.Get(sessionID, change.Path)
+		if errors.Is(err, filerecord.ErrNotRecorded) || (err == nil && !w.changed(record, change.Path)) {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func (w *fileWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+func (w *fileWatcher) run(ctx context.Context) {
+	defer w.watcher.Close()
+
+	dirty := make(map[string]bool)
+	timer := time.NewTimer(watcherDebounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = w.addTree(event.Name)
+					continue
+				}
+			}
+			dirty[event.Name] = true
+			timer.Reset(watcherDebounce)
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+		case <-timer.C:
+			matcher := newIgnoreMatcher(w.root)
+			for path := range dirty {
+				if !matcher.Ignored(path, false) {
+					w.check(path)
+				}
+			}
+			dirty = make(map[string]bool)
+		}
+	}
+}
+
+// check records a change of path for the sessions whose last read of the file
+// does not match its content anymore.
+func (w *fileWatcher) check(path string) {
+	records, err := w.files.ListByPath(path)
+	if err != nil {
+		return
+	}
+	for _, record := range records {
+		if !w.changed(record, path) {
+			continue
+		}
+		_, err := os.Stat(path)
+		change := ExternalChange{
+			SessionID: record.SessionID,
+			Path:      path,
+			Removed:   os.IsNotExist(err),
+		}
+		w.mu.Lock()
+		if w.pending[change.SessionID] == nil {
+			w.pending[change.SessionID] = make(map[string]ExternalChange)
+		}
+		w.pending[change.SessionID][path] = change
+		w.mu.Unlock()
+		w.Publish(pubsub.CreatedEvent, change)
+	}
+}
+
+func (w *fileWatcher) changed(record filerecord.Record, path string) bool {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return true
+	}
+	return err == nil && filerecord.Hash(content) != record.Hash
+}
+
+// addTree watches dir and the directories below it, skipping the ignored ones
+// and the .git directories, dir included unless it is the root.
+func (w *fileWatcher) addTree(dir string) error {
+	matcher := newIgnoreMatcher(w.root)
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path == w.root {
+			return w.watcher.Add(path)
+		}
+		ignored := matcher.match(path, true)
+		if path == dir {
+			// a directory created while watching, below a watched one
+			ignored = matcher.Ignored(path, true)
+		}
+		if d.Name() == ".git" || ignored {
+			return filepath.SkipDir
+		}
+		return w.watcher.Add(path)
+	})
+}
+
+// FormatExternalChanges describes the changes made outside termai for the
+// agent.
+func FormatExternalChanges(changes []ExternalChange) string {
+	var sb strings.Builder
+	sb.WriteString("These files were changed outside termai since you last read them, read them again before editing them:\n")
+	for _, change := range changes {
+		state := "modified"
+		if change.Removed {
+			state = "deleted"
+		}
+		fmt.Fprintf(&sb, "- %s (%s)\n", change.Path, state)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// NewFileWatcher starts watching root until ctx is done or the watcher is
+// closed.
+func NewFileWatcher(ctx context.Context, root string, files filerecord.Service) (FileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	w := &fileWatcher{
+		Broker:  pubsub.NewBroker[ExternalChange](),
+		root:    root,
+		files:   files,
+		watcher: watcher,
+		pending: make(map[string]map[string]ExternalChange),
+	}
+	if err := w.addTree(root); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", root, err)
+	}
+	go w.run(ctx)
+	return w, nil
+}
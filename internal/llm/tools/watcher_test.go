@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileWatcher(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log\nout/\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg"), 0o755))
+
+	files := newMockFileRecordService()
+	session := context.WithValue(context.Background(), SessionIDContextKey, "session")
+	other := context.WithValue(context.Background(), SessionIDContextKey, "other")
+	writeAndRead := func(t *testing.T, ctx context.Context, name string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("before\n"), 0o644))
+		recordFileRead(ctx, files, path)
+		return path
+	}
+	external := writeAndRead(t, session, "pkg/main.go")
+	removed := writeAndRead(t, session, "notes.md")
+	written := writeAndRead(t, session, "written.go")
+	ignored := writeAndRead(t, session, "debug.log")
+	notRead := filepath.Join(dir, "other.go")
+	require.NoError(t, os.WriteFile(notRead, []byte("before\n"), 0o644))
+	otherSession := writeAndRead(t, other, "other_session.go")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher, err := NewFileWatcher(ctx, dir, files)
+	require.NoError(t, err)
+	defer watcher.Close()
+	events := watcher.Subscribe(ctx)
+
+	require.NoError(t, os.WriteFile(external, []byte("after\n"), 0o644))
+	require.NoError(t, os.Remove(removed))
+	require.NoError(t, os.WriteFile(written, []byte("after\n"), 0o644))
+	recordFileWrite(session, files, written, []byte("after\n"))
+	require.NoError(t, os.WriteFile(ignored, []byte("after\n"), 0o644))
+	require.NoError(t, os.WriteFile(notRead, []byte("after\n"), 0o644))
+	require.NoError(t, os.WriteFile(otherSession, []byte("after\n"), 0o644))
+
+	seen := make(map[string]bool)
+	timeout := time.After(5 * time.Second)
+	for len(seen) < 3 {
+		select {
+		case event := <-events:
+			assert.Equal(t, pubsub.CreatedEvent, event.Type)
+			seen[event.Payload.SessionID+":"+event.Payload.Path] = true
+		case <-timeout:
+			t.Fatalf("timed out waiting for the changes, seen %v", seen)
+		}
+	}
+	assert.Equal(t, map[string]bool{
+		"session:" + external:   true,
+		"session:" + removed:    true,
+		"other:" + otherSession: true,
+	}, seen)
+
+	t.Run("takes the changes of a session", func(t *testing.T) {
+		changes := watcher.TakeExternalChanges("session")
+		assert.Equal(t, []ExternalChange{
+			{SessionID: "session", Path: removed, Removed: true},
+			{SessionID: "session", Path: external},
+		}, changes)
+		assert.Empty(t, watcher.TakeExternalChanges("session"))
+		assert.Equal(t,
+			"These files were changed outside termai since you last read them, read them again before editing them:\n"+
+				"- "+removed+" (deleted)\n"+
+				"- "+external+" (modified)",
+			FormatExternalChanges(changes))
+	})
+
+	t.Run("leaves out files read again", func(t *testing.T) {
+		recordFileRead(other, files, otherSession)
+		assert.Empty(t, watcher.TakeExternalChanges("other"))
+	})
+
+	t.Run("watches new directories", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "new"), 0o755))
+		// let the watcher add the directory before writing in it
+		time.Sleep(100 * time.Millisecond)
+		path := writeAndRead(t, session, "new/file.go")
+		require.NoError(t, os.WriteFile(path, []byte("after\n"), 0o644))
+
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case event := <-events:
+				// taking changes publishes deleted events
+				if event.Type == pubsub.CreatedEvent && event.Payload.Path == path {
+					return
+				}
+			case <-timeout:
+				t.Fatal("timed out waiting for the change")
+			}
+		}
+	})
+	t.Run("skips new ignored directories", func(t *testing.T) {
+		fw := watcher.(*fileWatcher)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "out", "sub"), 0o755))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg", ".git"), 0o755))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "last"), 0o755))
+
+		// the events are handled in order, the ignored directories were
+		// skipped once the last one is watched
+		require.Eventually(t, func() bool {
+			return slices.Contains(fw.watcher.WatchList(), filepath.Join(dir, "last"))
+		}, 5*time.Second, 10*time.Millisecond)
+		watched := fw.watcher.WatchList()
+		assert.NotContains(t, watched, filepath.Join(dir, "out"))
+		assert.NotContains(t, watched, filepath.Join(dir, "out", "sub"))
+		assert.NotContains(t, watched, filepath.Join(dir, "pkg", ".git"))
+	})
+}
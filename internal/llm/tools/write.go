@@ -6,13 +6,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
 
 	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/filerecord"
+	"github.com/imnulhaqueruman/opencode-poc/internal/lsp"
 	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
 )
 
-type writeTool struct{}
+type writeTool struct {
+	lspManager *lsp.Manager
+	files      filerecord.Service
+}
 
 const (
 	WriteToolName = "write"
@@ -66,8 +70,12 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	if !filepath.IsAbs(filePath) {
 		filePath = filepath.Join(config.WorkingDirectory(), filePath)
 	}
+	if err := checkFileAccess(filePath); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
 
 	// Check if file exists and is a directory
+	format := textFormat{mode: defaultFileMode}
 	fileInfo, err := os.Stat(filePath)
 	if err == nil {
 		if fileInfo.IsDir() {
@@ -75,17 +83,17 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		}
 
 		// Check if file was modified since last read
-		modTime := fileInfo.ModTime()
-		lastRead := getLastReadTime(filePath)
-		if modTime.After(lastRead) {
-			return NewTextErrorResponse(fmt.Sprintf("File %s has been modified since it was last read.\nLast modification: %s\nLast read: %s\n\nPlease read the file again before modifying it.",
-				filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339))), nil
+		if err := checkFileFresh(ctx, w.files, filePath); err != nil {
+			return NewTextErrorResponse(err.Error()), nil
 		}
 
-		// Optional: Get old content for diff
+		// Keep the mode, line endings, final newline and byte order mark of the file
 		oldContent, readErr := os.ReadFile(filePath)
-		if readErr == nil && string(oldContent) == params.Content {
-			return NewTextErrorResponse(fmt.Sprintf("File %s already contains the exact content. No changes made.", filePath)), nil
+		if readErr == nil {
+			format = detectTextFormat(oldContent, fileInfo.Mode().Perm())
+			if string(oldContent) == string(format.encode(params.Content)) {
+				return NewTextErrorResponse(fmt.Sprintf("File %s already contains the exact content. No changes made.", filePath)), nil
+			}
 		}
 	} else if !os.IsNotExist(err) {
 		return NewTextErrorResponse(fmt.Sprintf("Failed to access file: %s", err)), nil
@@ -98,6 +106,7 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	}
 	p := permission.Default.Request(
 		permission.CreatePermissionRequest{
+			SessionID:   GetSessionFromContext(ctx),
 			Path:        filePath,
 			ToolName:    WriteToolName,
 			Action:      "create",
@@ -113,16 +122,15 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	}
 
 	// Write the file
-	err = os.WriteFile(filePath, []byte(params.Content), 0o644)
+	written, err := writeFileText(filePath, params.Content, format)
 	if err != nil {
 		return NewTextErrorResponse(fmt.Sprintf("Failed to write file: %s", err)), nil
 	}
 
 	// Record the file write
-	recordFileWrite(filePath)
-	recordFileRead(filePath)
+	recordFileWrite(ctx, w.files, filePath, written)
 
-	return NewTextResponse(fmt.Sprintf("File successfully written: %s", filePath)), nil
+	return appendDiagnostics(ctx, w.lspManager, NewTextResponse(fmt.Sprintf("File successfully written: %s", filePath)), filePath), nil
 }
 
 func writeDescription() string {
@@ -143,10 +151,13 @@ FEATURES:
 - Creates parent directories automatically if they don't exist
 - Checks if the file has been modified since last read for safety
 - Avoids unnecessary writes when content hasn't changed
+- Keeps the permissions, line endings (LF or CRLF), final newline and UTF-8 byte order mark of an existing file
+- Writes atomically, an interrupted write never leaves a truncated file
 
 LIMITATIONS:
 - You should read a file before writing to it to avoid conflicts
 - Cannot append to files (rewrites the entire file)
+- Ignored files that may hold secrets, like a .env file listed in .gitignore, cannot be written
 
 
 TIPS:
@@ -156,7 +167,7 @@ TIPS:
 - Always include descriptive comments when making changes to existing code`
 }
 
-func NewWriteTool() BaseTool {
-	return &writeTool{}
+func NewWriteTool(lspManager *lsp.Manager, files filerecord.Service) BaseTool {
+	return &writeTool{lspManager: lspManager, files: files}
 }
 
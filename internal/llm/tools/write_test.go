@@ -3,10 +3,10 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
-	"time"
 
 	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
 	"github.com/stretchr/testify/assert"
@@ -14,7 +14,7 @@ import (
 )
 
 func TestWriteTool_Info(t *testing.T) {
-	tool := NewWriteTool()
+	tool := NewWriteTool(nil, nil)
 	info := tool.Info()
 
 	assert.Equal(t, WriteToolName, info.Name)
@@ -37,10 +37,11 @@ func TestWriteTool_Run(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "write_tool_test")
 	require.NoError(t, err)
 	defer os.RemoveAll(tempDir)
+	files := newMockFileRecordService()
 
 	t.Run("creates a new file successfully", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewWriteTool()
+		tool := NewWriteTool(nil, files)
 		
 		filePath := filepath.Join(tempDir, "new_file.txt")
 		content := "This is a test content"
@@ -70,7 +71,7 @@ func TestWriteTool_Run(t *testing.T) {
 
 	t.Run("creates file with nested directories", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewWriteTool()
+		tool := NewWriteTool(nil, files)
 		
 		filePath := filepath.Join(tempDir, "nested/dirs/new_file.txt")
 		content := "Content in nested directory"
@@ -100,7 +101,7 @@ func TestWriteTool_Run(t *testing.T) {
 
 	t.Run("updates existing file", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewWriteTool()
+		tool := NewWriteTool(nil, files)
 		
 		// Create a file first
 		filePath := filepath.Join(tempDir, "existing_file.txt")
@@ -109,7 +110,7 @@ func TestWriteTool_Run(t *testing.T) {
 		require.NoError(t, err)
 		
 		// Record the file read to avoid modification time check failure
-		recordFileRead(filePath)
+		recordFileRead(context.Background(), files, filePath)
 		
 		// Update the file
 		updatedContent := "Updated content"
@@ -138,7 +139,7 @@ func TestWriteTool_Run(t *testing.T) {
 
 	t.Run("handles invalid parameters", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewWriteTool()
+		tool := NewWriteTool(nil, files)
 		
 		call := ToolCall{
 			Name:  WriteToolName,
@@ -152,7 +153,7 @@ func TestWriteTool_Run(t *testing.T) {
 
 	t.Run("handles missing file_path", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewWriteTool()
+		tool := NewWriteTool(nil, files)
 		
 		params := WriteParams{
 			FilePath: "",
@@ -174,7 +175,7 @@ func TestWriteTool_Run(t *testing.T) {
 
 	t.Run("handles missing content", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewWriteTool()
+		tool := NewWriteTool(nil, files)
 		
 		params := WriteParams{
 			FilePath: filepath.Join(tempDir, "file.txt"),
@@ -196,7 +197,7 @@ func TestWriteTool_Run(t *testing.T) {
 
 	t.Run("handles writing to a directory path", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewWriteTool()
+		tool := NewWriteTool(nil, files)
 		
 		// Create a directory
 		dirPath := filepath.Join(tempDir, "test_dir")
@@ -223,7 +224,7 @@ func TestWriteTool_Run(t *testing.T) {
 
 	t.Run("handles permission denied", func(t *testing.T) {
 		permission.Default = newMockPermissionService(false)
-		tool := NewWriteTool()
+		tool := NewWriteTool(nil, files)
 		
 		filePath := filepath.Join(tempDir, "permission_denied.txt")
 		params := WriteParams{
@@ -250,7 +251,7 @@ func TestWriteTool_Run(t *testing.T) {
 
 	t.Run("detects file modified since last read", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewWriteTool()
+		tool := NewWriteTool(nil, files)
 		
 		// Create a file
 		filePath := filepath.Join(tempDir, "modified_file.txt")
@@ -258,13 +259,11 @@ func TestWriteTool_Run(t *testing.T) {
 		err := os.WriteFile(filePath, []byte(initialContent), 0644)
 		require.NoError(t, err)
 		
-		// Record an old read time
-		fileRecordMutex.Lock()
-		fileRecords[filePath] = fileRecord{
-			path:     filePath,
-			readTime: time.Now().Add(-1 * time.Hour),
-		}
-		fileRecordMutex.Unlock()
+		// Read the file, then change it behind the session's back
+		recordFileRead(context.Background(), files, filePath)
+		changedContent := "Changed outside"
+		err = os.WriteFile(filePath, []byte(changedContent), 0644)
+		require.NoError(t, err)
 		
 		// Try to update the file
 		params := WriteParams{
@@ -287,12 +286,12 @@ func TestWriteTool_Run(t *testing.T) {
 		// Verify file was not modified
 		fileContent, err := os.ReadFile(filePath)
 		require.NoError(t, err)
-		assert.Equal(t, initialContent, string(fileContent))
+		assert.Equal(t, changedContent, string(fileContent))
 	})
 
 	t.Run("skips writing when content is identical", func(t *testing.T) {
 		permission.Default = newMockPermissionService(true)
-		tool := NewWriteTool()
+		tool := NewWriteTool(nil, files)
 		
 		// Create a file
 		filePath := filepath.Join(tempDir, "identical_content.txt")
@@ -301,7 +300,7 @@ func TestWriteTool_Run(t *testing.T) {
 		require.NoError(t, err)
 		
 		// Record a read time
-		recordFileRead(filePath)
+		recordFileRead(context.Background(), files, filePath)
 		
 		// Try to write the same content
 		params := WriteParams{
@@ -321,4 +320,117 @@ func TestWriteTool_Run(t *testing.T) {
 		require.NoError(t, err)
 		assert.Contains(t, response.Content, "already contains the exact content")
 	})
-}
\ No newline at end of file
+}
+func TestWriteTool_PreservesFormat(t *testing.T) {
+	origPermission := permission.Default
+	defer func() {
+		permission.Default = origPermission
+	}()
+	permission.Default = newMockPermissionService(true)
+
+	tempDir := t.TempDir()
+	files := newMockFileRecordService()
+	tool := NewWriteTool(nil, files)
+
+	tests := []struct {
+		name     string
+		original string
+		mode     os.FileMode
+		content  string
+		expected string
+	}{
+		{
+			name:     "keeps the executable bit",
+			original: "#!/bin/sh\necho old\n",
+			mode:     0o755,
+			content:  "#!/bin/sh\necho new\n",
+			expected: "#!/bin/sh\necho new\n",
+		},
+		{
+			name:     "keeps CRLF line endings",
+			original: "one\r\ntwo\r\n",
+			mode:     0o644,
+			content:  "one\ntwo\nthree\n",
+			expected: "one\r\ntwo\r\nthree\r\n",
+		},
+		{
+			name:     "keeps mixed line endings as written",
+			original: "one\r\ntwo\n",
+			mode:     0o644,
+			content:  "one\ntwo\nthree\n",
+			expected: "one\ntwo\nthree\n",
+		},
+		{
+			name:     "adds the final newline",
+			original: "old\n",
+			mode:     0o644,
+			content:  "new",
+			expected: "new\n",
+		},
+		{
+			name:     "keeps a missing final newline",
+			original: "old",
+			mode:     0o644,
+			content:  "new\n",
+			expected: "new",
+		},
+		{
+			name:     "keeps the byte order mark",
+			original: "\xEF\xBB\xBFold\n",
+			mode:     0o600,
+			content:  "new\n",
+			expected: "\xEF\xBB\xBFnew\n",
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(tempDir, fmt.Sprintf("file%d.txt", i))
+			require.NoError(t, os.WriteFile(filePath, []byte(tt.original), 0o644))
+			require.NoError(t, os.Chmod(filePath, tt.mode))
+			recordFileRead(context.Background(), files, filePath)
+
+			paramsJSON, err := json.Marshal(WriteParams{FilePath: filePath, Content: tt.content})
+			require.NoError(t, err)
+			response, err := tool.Run(context.Background(), ToolCall{Name: WriteToolName, Input: string(paramsJSON)})
+			require.NoError(t, err)
+			require.False(t, response.IsError, response.Content)
+
+			content, err := os.ReadFile(filePath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(content))
+			info, err := os.Stat(filePath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.mode, info.Mode().Perm())
+			assert.NoError(t, checkFileFresh(context.Background(), files, filePath))
+		})
+	}
+
+	t.Run("replaces the target of a symbolic link", func(t *testing.T) {
+		target := filepath.Join(tempDir, "target.txt")
+		link := filepath.Join(tempDir, "link.txt")
+		require.NoError(t, os.WriteFile(target, []byte("old\n"), 0o644))
+		require.NoError(t, os.Symlink(target, link))
+		recordFileRead(context.Background(), files, link)
+
+		paramsJSON, err := json.Marshal(WriteParams{FilePath: link, Content: "new\n"})
+		require.NoError(t, err)
+		response, err := tool.Run(context.Background(), ToolCall{Name: WriteToolName, Input: string(paramsJSON)})
+		require.NoError(t, err)
+		require.False(t, response.IsError, response.Content)
+
+		info, err := os.Lstat(link)
+		require.NoError(t, err)
+		assert.NotZero(t, info.Mode()&os.ModeSymlink)
+		content, err := os.ReadFile(target)
+		require.NoError(t, err)
+		assert.Equal(t, "new\n", string(content))
+	})
+
+	t.Run("leaves no temporary file", func(t *testing.T) {
+		entries, err := os.ReadDir(tempDir)
+		require.NoError(t, err)
+		for _, entry := range entries {
+			assert.NotContains(t, entry.Name(), ".tmp-")
+		}
+	})
+}
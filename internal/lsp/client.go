@@ -0,0 +1,367 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrClientClosed = errors.New("language server connection closed")
+
+// Client is a connection to a single language server.
+type Client struct {
+	name string
+	cmd  *exec.Cmd
+
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+	writeMu sync.Mutex
+
+	nextID    atomic.Int64
+	pendingMu sync.Mutex
+	pending   map[int64]chan *message
+
+	filesMu   sync.Mutex
+	openFiles map[string]int
+
+	diagMu       sync.Mutex
+	diagnostics  map[string][]Diagnostic
+	diagVersions map[string]int
+	diagUpdated  chan struct{}
+
+	done chan struct{}
+}
+
+// NewClient starts a language server process and connects to it over stdio.
+// Initialize must be called before the client is used.
+func NewClient(name, command string, args []string, env []string, dir string) (*Client, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stderr = io.Discard
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command, err)
+	}
+
+	c := newClient(name, stdout, stdin)
+	c.cmd = cmd
+	return c, nil
+}
+
+func newClient(name string, r io.Reader, w io.WriteCloser) *Client {
+	c := &Client{
+		name:         name,
+		stdin:        w,
+		stdout:       bufio.NewReader(r),
+		pending:      make(map[int64]chan *message),
+		openFiles:    make(map[string]int),
+		diagnostics:  make(map[string][]Diagnostic),
+		diagVersions: make(map[string]int),
+		diagUpdated:  make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	go c.readLoop()
+	return c
+}
+
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Initialize performs the initialize handshake for the workspace at rootDir.
+func (c *Client) Initialize(ctx context.Context, rootDir string, options any) error {
+	params := InitializeParams{
+		ProcessID: os.Getpid(),
+		RootURI:   PathToURI(rootDir),
+		WorkspaceFolders: []WorkspaceFolder{
+			{URI: PathToURI(rootDir), Name: filepath.Base(rootDir)},
+		},
+		Capabilities: map[string]any{
+			"textDocument": map[string]any{
+				"synchronization": map[string]any{
+					"dynamicRegistration": false,
+					"didSave":             false,
+				},
+				"publishDiagnostics": map[string]any{
+					"versionSupport": true,
+				},
+				"definition":     map[string]any{"linkSupport": false},
+				"references":     map[string]any{},
+				"documentSymbol": map[string]any{"hierarchicalDocumentSymbolSupport": true},
+			},
+			"workspace": map[string]any{
+				"configuration":    true,
+				"workspaceFolders": true,
+				"symbol":           map[string]any{},
+			},
+		},
+		InitializationOptions: options,
+	}
+	if err := c.Call(ctx, "initialize", params, nil); err != nil {
+		return fmt.Errorf("initialize failed: %w", err)
+	}
+	return c.Notify("initialized", map[string]any{})
+}
+
+// Call sends a request and decodes its result into result, which may be nil.
+func (c *Client) Call(ctx context.Context, method string, params, result any) error {
+	id := c.nextID.Add(1)
+	rawID := json.RawMessage(strconv.FormatInt(id, 10))
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	ch := make(chan *message, 1)
+	c.pendingMu.Lock()
+	c.pending[id] = ch
+	c.pendingMu.Unlock()
+	defer func() {
+		c.pendingMu.Lock()
+		delete(c.pending, id)
+		c.pendingMu.Unlock()
+	}()
+
+	if err := c.write(&message{ID: &rawID, Method: method, Params: body}); err != nil {
+		return err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil && len(resp.Result) > 0 && string(resp.Result) != "null" {
+			return json.Unmarshal(resp.Result, result)
+		}
+		return nil
+	case <-c.done:
+		return ErrClientClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Notify sends a notification.
+func (c *Client) Notify(method string, params any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.write(&message{Method: method, Params: body})
+}
+
+func (c *Client) write(msg *message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	select {
+	case <-c.done:
+		return ErrClientClosed
+	default:
+	}
+	return writeMessage(c.stdin, msg)
+}
+
+func (c *Client) readLoop() {
+	defer close(c.done)
+	for {
+		msg, err := readMessage(c.stdout)
+		if err != nil {
+			return
+		}
+
+		switch {
+		case msg.Method == "" && msg.ID != nil:
+			id, err := strconv.ParseInt(string(*msg.ID), 10, 64)
+			if err != nil {
+				continue
+			}
+			c.pendingMu.Lock()
+			ch, ok := c.pending[id]
+			c.pendingMu.Unlock()
+			if ok {
+				ch <- msg
+			}
+		case msg.ID != nil:
+			c.handleServerRequest(msg)
+		case msg.Method == "textDocument/publishDiagnostics":
+			var params PublishDiagnosticsParams
+			if err := json.Unmarshal(msg.Params, &params); err == nil {
+				c.publishDiagnostics(params)
+			}
+		}
+	}
+}
+
+// handleServerRequest answers the requests servers commonly send to clients.
+// termai has no settings or UI to offer, so every request gets an empty result.
+func (c *Client) handleServerRequest(msg *message) {
+	result := json.RawMessage("null")
+	if msg.Method == "workspace/configuration" {
+		var params struct {
+			Items []any `json:"items"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		items := make([]any, len(params.Items))
+		result, _ = json.Marshal(items)
+	}
+	go func() {
+		_ = c.write(&message{ID: msg.ID, Result: result})
+	}()
+}
+
+func (c *Client) publishDiagnostics(params PublishDiagnosticsParams) {
+	path := URIToPath(params.URI)
+	c.diagMu.Lock()
+	defer c.diagMu.Unlock()
+	if len(params.Diagnostics) == 0 {
+		delete(c.diagnostics, path)
+	} else {
+		c.diagnostics[path] = params.Diagnostics
+	}
+	c.diagVersions[path] = params.Version
+	close(c.diagUpdated)
+	c.diagUpdated = make(chan struct{})
+}
+
+// SyncFile opens the file in the language server, or sends its new content if
+// it is already open, and returns the document version. Files that no longer
+// exist are closed.
+func (c *Client) SyncFile(path string) (int, error) {
+	uri := PathToURI(path)
+	content, err := os.ReadFile(path)
+
+	c.filesMu.Lock()
+	defer c.filesMu.Unlock()
+	version, open := c.openFiles[path]
+
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return 0, err
+		}
+		if open {
+			delete(c.openFiles, path)
+			c.clearDiagnostics(path)
+			return 0, c.Notify("textDocument/didClose", DidCloseTextDocumentParams{
+				TextDocument: TextDocumentIdentifier{URI: uri},
+			})
+		}
+		return 0, nil
+	}
+
+	version++
+	c.openFiles[path] = version
+	if !open {
+		return version, c.Notify("textDocument/didOpen", DidOpenTextDocumentParams{
+			TextDocument: TextDocumentItem{
+				URI:        uri,
+				LanguageID: LanguageID(path),
+				Version:    version,
+				Text:       string(content),
+			},
+		})
+	}
+	return version, c.Notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: version},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: string(content)}},
+	})
+}
+
+func (c *Client) clearDiagnostics(path string) {
+	c.diagMu.Lock()
+	defer c.diagMu.Unlock()
+	delete(c.diagnostics, path)
+	delete(c.diagVersions, path)
+}
+
+// SyncFileAndWait syncs the file and waits until the server publishes
+// diagnostics for the new version, or ctx is done. The latest known
+// diagnostics for the file are returned either way.
+func (c *Client) SyncFileAndWait(ctx context.Context, path string) ([]Diagnostic, error) {
+	c.diagMu.Lock()
+	updated := c.diagUpdated
+	c.diagMu.Unlock()
+
+	version, err := c.SyncFile(path)
+	if err != nil || version == 0 {
+		return c.FileDiagnostics(path), err
+	}
+
+	for {
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return c.FileDiagnostics(path), nil
+		case <-c.done:
+			return c.FileDiagnostics(path), ErrClientClosed
+		}
+
+		c.diagMu.Lock()
+		published, ok := c.diagVersions[path]
+		updated = c.diagUpdated
+		c.diagMu.Unlock()
+		// servers without version support publish version 0
+		if ok && (published == 0 || published >= version) {
+			return c.FileDiagnostics(path), nil
+		}
+	}
+}
+
+// FileDiagnostics returns the latest diagnostics published for a file.
+func (c *Client) FileDiagnostics(path string) []Diagnostic {
+	c.diagMu.Lock()
+	defer c.diagMu.Unlock()
+	return append([]Diagnostic(nil), c.diagnostics[path]...)
+}
+
+// Diagnostics returns the latest diagnostics for every file, keyed by path.
+func (c *Client) Diagnostics() map[string][]Diagnostic {
+	c.diagMu.Lock()
+	defer c.diagMu.Unlock()
+	result := make(map[string][]Diagnostic, len(c.diagnostics))
+	for path, diags := range c.diagnostics {
+		result[path] = append([]Diagnostic(nil), diags...)
+	}
+	return result
+}
+
+// Shutdown asks the server to exit and stops its process.
+func (c *Client) Shutdown(ctx context.Context) {
+	if err := c.Call(ctx, "shutdown", nil, nil); err == nil {
+		_ = c.Notify("exit", nil)
+	}
+	c.stdin.Close()
+
+	if c.cmd == nil {
+		return
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = c.cmd.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(2 * time.Second):
+		_ = c.cmd.Process.Kill()
+	}
+}
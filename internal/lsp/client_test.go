@@ -0,0 +1,139 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer answers initialize and reports a diagnostic for every line
+// containing "bad" whenever a document is opened or changed.
+func fakeServer(r io.Reader, w io.WriteCloser) {
+	reader := bufio.NewReader(r)
+	go func() {
+		defer w.Close()
+		for {
+			msg, err := readMessage(reader)
+			if err != nil {
+				return
+			}
+			switch msg.Method {
+			case "initialize":
+				_ = writeMessage(w, &message{ID: msg.ID, Result: json.RawMessage(`{"capabilities":{}}`)})
+				// ask the client for its configuration, like gopls does
+				id := json.RawMessage(`"config-1"`)
+				_ = writeMessage(w, &message{ID: &id, Method: "workspace/configuration", Params: json.RawMessage(`{"items":[{}]}`)})
+			case "shutdown":
+				_ = writeMessage(w, &message{ID: msg.ID, Result: json.RawMessage("null")})
+			case "exit":
+				return
+			case "textDocument/didOpen", "textDocument/didChange":
+				var params struct {
+					TextDocument struct {
+						URI     string `json:"uri"`
+						Version int    `json:"version"`
+						Text    string `json:"text"`
+					} `json:"textDocument"`
+					ContentChanges []struct {
+						Text string `json:"text"`
+					} `json:"contentChanges"`
+				}
+				if err := json.Unmarshal(msg.Params, &params); err != nil {
+					return
+				}
+				text := params.TextDocument.Text
+				if len(params.ContentChanges) > 0 {
+					text = params.ContentChanges[0].Text
+				}
+
+				diagnostics := []Diagnostic{}
+				for i, line := range strings.Split(text, "\n") {
+					if strings.Contains(line, "bad") {
+						diagnostics = append(diagnostics, Diagnostic{
+							Range:    Range{Start: Position{Line: i, Character: 0}},
+							Severity: SeverityError,
+							Source:   "fake",
+							Message:  "bad line",
+						})
+					}
+				}
+				body, _ := json.Marshal(PublishDiagnosticsParams{
+					URI:         params.TextDocument.URI,
+					Version:     params.TextDocument.Version,
+					Diagnostics: diagnostics,
+				})
+				_ = writeMessage(w, &message{Method: "textDocument/publishDiagnostics", Params: body})
+			}
+		}
+	}()
+}
+
+func newTestClient(t *testing.T) *Client {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	fakeServer(serverReader, serverWriter)
+
+	client := newClient("fake", clientReader, clientWriter)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, client.Initialize(ctx, t.TempDir(), nil))
+	t.Cleanup(func() {
+		client.Shutdown(context.Background())
+	})
+	return client
+}
+
+func TestClient_SyncFileAndWait(t *testing.T) {
+	client := newTestClient(t)
+	path := filepath.Join(t.TempDir(), "main.go")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, os.WriteFile(path, []byte("good\nbad\n"), 0o644))
+	diagnostics, err := client.SyncFileAndWait(ctx, path)
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "Error [2:1] bad line (fake)", diagnostics[0].String())
+
+	require.NoError(t, os.WriteFile(path, []byte("good\ngood\n"), 0o644))
+	diagnostics, err = client.SyncFileAndWait(ctx, path)
+	require.NoError(t, err)
+	assert.Empty(t, diagnostics)
+	assert.Empty(t, client.Diagnostics())
+
+	require.NoError(t, os.Remove(path))
+	diagnostics, err = client.SyncFileAndWait(ctx, path)
+	require.NoError(t, err)
+	assert.Empty(t, diagnostics)
+}
+
+func TestURIConversion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir with space", "file.go")
+	uri := PathToURI(path)
+	assert.True(t, strings.HasPrefix(uri, "file:///"))
+	assert.Equal(t, path, URIToPath(uri))
+}
+
+func TestHandlesFile(t *testing.T) {
+	m := NewManager(t.TempDir())
+	m.servers = []*server{{name: "gopls", config: config.LSPConfig{Extensions: []string{".go", "mod"}}}}
+
+	assert.True(t, m.HasServerFor("/src/main.go"))
+	assert.True(t, m.HasServerFor("/src/go.mod"))
+	assert.False(t, m.HasServerFor("/src/main.py"))
+	assert.False(t, m.HasServerFor("/src/Makefile"))
+
+	var nilManager *Manager
+	assert.False(t, nilManager.HasServerFor("/src/main.go"))
+	assert.Empty(t, nilManager.FileDiagnostics(context.Background(), "/src/main.go"))
+}
@@ -0,0 +1,192 @@
+package lsp
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/logging"
+)
+
+var log = logging.Get()
+
+const initializeTimeout = 30 * time.Second
+
+type server struct {
+	name   string
+	config config.LSPConfig
+	client *Client
+	ready  chan struct{}
+}
+
+// Manager owns the language servers of a workspace and routes files to the
+// servers configured for their extension. A nil Manager has no servers.
+type Manager struct {
+	rootDir string
+
+	mu      sync.Mutex
+	servers []*server
+}
+
+func NewManager(rootDir string) *Manager {
+	return &Manager{rootDir: rootDir}
+}
+
+// Start launches every enabled server whose command is found on PATH. Servers
+// initialize in the background, requests wait for them to become ready.
+func (m *Manager) Start(servers map[string]config.LSPConfig) {
+	if m == nil {
+		return
+	}
+	for name, cfg := range servers {
+		if cfg.Disabled || cfg.Command == "" || len(cfg.Extensions) == 0 {
+			continue
+		}
+		command, err := exec.LookPath(cfg.Command)
+		if err != nil {
+			log.Debug("Language server not found", "name", name, "command", cfg.Command)
+			continue
+		}
+
+		s := &server{name: name, config: cfg, ready: make(chan struct{})}
+		m.mu.Lock()
+		m.servers = append(m.servers, s)
+		m.mu.Unlock()
+
+		go func() {
+			defer close(s.ready)
+			client, err := NewClient(name, command, cfg.Args, cfg.Env, m.rootDir)
+			if err != nil {
+				log.Error("Failed to start language server", "name", name, "error", err)
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), initializeTimeout)
+			defer cancel()
+			if err := client.Initialize(ctx, m.rootDir, cfg.Options); err != nil {
+				log.Error("Failed to initialize language server", "name", name, "error", err)
+				client.Shutdown(ctx)
+				return
+			}
+			log.Info("Language server started", "name", name)
+			s.client = client
+		}()
+	}
+}
+
+func handlesFile(cfg config.LSPConfig, path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return false
+	}
+	return slices.ContainsFunc(cfg.Extensions, func(e string) bool {
+		return strings.EqualFold("."+strings.TrimPrefix(e, "."), ext)
+	})
+}
+
+// clients returns the ready clients, limited to those handling path when it
+// is not empty. It waits for servers that are still starting until ctx is done.
+func (m *Manager) clients(ctx context.Context, path string) []*Client {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	servers := slices.Clone(m.servers)
+	m.mu.Unlock()
+
+	var clients []*Client
+	for _, s := range servers {
+		if path != "" && !handlesFile(s.config, path) {
+			continue
+		}
+		select {
+		case <-s.ready:
+		case <-ctx.Done():
+			continue
+		}
+		if s.client != nil {
+			clients = append(clients, s.client)
+		}
+	}
+	return clients
+}
+
+// HasServerFor reports if a server is configured for the file.
+func (m *Manager) HasServerFor(path string) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.ContainsFunc(m.servers, func(s *server) bool {
+		return handlesFile(s.config, path)
+	})
+}
+
+// Clients returns the ready clients for a file, waiting for servers that are
+// still starting until ctx is done.
+func (m *Manager) Clients(ctx context.Context, path string) []*Client {
+	return m.clients(ctx, path)
+}
+
+// OpenFile syncs the file with its servers in the background, so they start
+// analyzing it before it is changed.
+func (m *Manager) OpenFile(path string) {
+	if !m.HasServerFor(path) {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), initializeTimeout)
+		defer cancel()
+		for _, c := range m.clients(ctx, path) {
+			if _, err := c.SyncFile(path); err != nil {
+				log.Debug("Failed to open file in language server", "name", c.Name(), "path", path, "error", err)
+			}
+		}
+	}()
+}
+
+// FileDiagnostics syncs the file with its servers and returns their
+// diagnostics for it, waiting for fresh results until ctx is done.
+func (m *Manager) FileDiagnostics(ctx context.Context, path string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, c := range m.clients(ctx, path) {
+		diags, err := c.SyncFileAndWait(ctx, path)
+		if err != nil {
+			log.Debug("Failed to sync file with language server", "name", c.Name(), "path", path, "error", err)
+		}
+		diagnostics = append(diagnostics, diags...)
+	}
+	return diagnostics
+}
+
+// Diagnostics returns the diagnostics of all servers, keyed by file path.
+func (m *Manager) Diagnostics(ctx context.Context) map[string][]Diagnostic {
+	diagnostics := make(map[string][]Diagnostic)
+	for _, c := range m.clients(ctx, "") {
+		for path, diags := range c.Diagnostics() {
+			diagnostics[path] = append(diagnostics[path], diags...)
+		}
+	}
+	return diagnostics
+}
+
+// Shutdown stops all servers.
+func (m *Manager) Shutdown(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, c := range m.clients(ctx, "") {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+}
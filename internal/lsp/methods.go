@@ -0,0 +1,162 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+)
+
+type SymbolKind int
+
+var symbolKindNames = []string{
+	"", "file", "module", "namespace", "package", "class", "method", "property",
+	"field", "constructor", "enum", "interface", "function", "variable",
+	"constant", "string", "number", "boolean", "array", "object", "key", "null",
+	"enum member", "struct", "event", "operator", "type parameter",
+}
+
+func (k SymbolKind) String() string {
+	if k > 0 && int(k) < len(symbolKindNames) {
+		return symbolKindNames[k]
+	}
+	return "symbol"
+}
+
+type SymbolInformation struct {
+	Name          string     `json:"name"`
+	Kind          SymbolKind `json:"kind"`
+	Location      Location   `json:"location"`
+	ContainerName string     `json:"containerName,omitempty"`
+}
+
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           SymbolKind       `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type ReferenceParams struct {
+	TextDocumentPositionParams
+	Context struct {
+		IncludeDeclaration bool `json:"includeDeclaration"`
+	} `json:"context"`
+}
+
+// EnsureFileOpen opens the file in the server unless it already is.
+func (c *Client) EnsureFileOpen(path string) error {
+	c.filesMu.Lock()
+	_, open := c.openFiles[path]
+	c.filesMu.Unlock()
+	if open {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	_, err := c.SyncFile(path)
+	return err
+}
+
+// WorkspaceSymbols searches the symbols of the whole workspace.
+func (c *Client) WorkspaceSymbols(ctx context.Context, query string) ([]SymbolInformation, error) {
+	var symbols []SymbolInformation
+	err := c.Call(ctx, "workspace/symbol", map[string]string{"query": query}, &symbols)
+	return symbols, err
+}
+
+// Definition returns the locations where the symbol at pos is defined.
+func (c *Client) Definition(ctx context.Context, path string, pos Position) ([]Location, error) {
+	if err := c.EnsureFileOpen(path); err != nil {
+		return nil, err
+	}
+	var raw json.RawMessage
+	err := c.Call(ctx, "textDocument/definition", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: PathToURI(path)},
+		Position:     pos,
+	}, &raw)
+	if err != nil || len(raw) == 0 {
+		return nil, err
+	}
+
+	// the result is a single location or a list of them
+	var locations []Location
+	if raw[0] != '[' {
+		var location Location
+		if err := json.Unmarshal(raw, &location); err != nil {
+			return nil, err
+		}
+		return []Location{location}, nil
+	}
+	return locations, json.Unmarshal(raw, &locations)
+}
+
+// References returns the locations referencing the symbol at pos, including
+// its declaration.
+func (c *Client) References(ctx context.Context, path string, pos Position) ([]Location, error) {
+	if err := c.EnsureFileOpen(path); err != nil {
+		return nil, err
+	}
+	params := ReferenceParams{
+		TextDocumentPositionParams: TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: PathToURI(path)},
+			Position:     pos,
+		},
+	}
+	params.Context.IncludeDeclaration = true
+	var locations []Location
+	err := c.Call(ctx, "textDocument/references", params, &locations)
+	return locations, err
+}
+
+// DocumentSymbols returns the symbols of a file. Servers answering with a
+// flat list are converted to top level symbols without children.
+func (c *Client) DocumentSymbols(ctx context.Context, path string) ([]DocumentSymbol, error) {
+	if err := c.EnsureFileOpen(path); err != nil {
+		return nil, err
+	}
+	var raw []json.RawMessage
+	err := c.Call(ctx, "textDocument/documentSymbol", map[string]any{
+		"textDocument": TextDocumentIdentifier{URI: PathToURI(path)},
+	}, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := make([]DocumentSymbol, 0, len(raw))
+	for _, r := range raw {
+		var probe struct {
+			Location *Location `json:"location"`
+		}
+		if err := json.Unmarshal(r, &probe); err != nil {
+			return nil, err
+		}
+		if probe.Location != nil {
+			var info SymbolInformation
+			if err := json.Unmarshal(r, &info); err != nil {
+				return nil, err
+			}
+			symbols = append(symbols, DocumentSymbol{
+				Name:           info.Name,
+				Detail:         info.ContainerName,
+				Kind:           info.Kind,
+				Range:          info.Location.Range,
+				SelectionRange: info.Location.Range,
+			})
+			continue
+		}
+		var symbol DocumentSymbol
+		if err := json.Unmarshal(r, &symbol); err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, nil
+}
@@ -0,0 +1,190 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// Only the parts of the Language Server Protocol used by termai are defined
+// here, see https://microsoft.github.io/language-server-protocol/
+
+type DiagnosticSeverity int
+
+const (
+	SeverityError       DiagnosticSeverity = 1
+	SeverityWarning     DiagnosticSeverity = 2
+	SeverityInformation DiagnosticSeverity = 3
+	SeverityHint        DiagnosticSeverity = 4
+)
+
+func (s DiagnosticSeverity) String() string {
+	switch s {
+	case SeverityError:
+		return "Error"
+	case SeverityWarning:
+		return "Warning"
+	case SeverityInformation:
+		return "Info"
+	case SeverityHint:
+		return "Hint"
+	default:
+		return "Unknown"
+	}
+}
+
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+type Diagnostic struct {
+	Range    Range              `json:"range"`
+	Severity DiagnosticSeverity `json:"severity,omitempty"`
+	Code     any                `json:"code,omitempty"`
+	Source   string             `json:"source,omitempty"`
+	Message  string             `json:"message"`
+}
+
+// String formats the diagnostic with 1-based line and column numbers.
+func (d Diagnostic) String() string {
+	s := fmt.Sprintf("%s [%d:%d] %s", d.Severity, d.Range.Start.Line+1, d.Range.Start.Character+1, d.Message)
+	if d.Source != "" {
+		s += fmt.Sprintf(" (%s)", d.Source)
+	}
+	return s
+}
+
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     int          `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
+type TextDocumentContentChangeEvent struct {
+	Text string `json:"text"`
+}
+
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+type DidChangeTextDocumentParams struct {
+	TextDocument   VersionedTextDocumentIdentifier  `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type WorkspaceFolder struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+type InitializeParams struct {
+	ProcessID             int               `json:"processId"`
+	RootURI               string            `json:"rootUri"`
+	WorkspaceFolders      []WorkspaceFolder `json:"workspaceFolders"`
+	Capabilities          map[string]any    `json:"capabilities"`
+	InitializationOptions any               `json:"initializationOptions,omitempty"`
+}
+
+// message is a JSON-RPC 2.0 request, response or notification.
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *ResponseError   `json:"error,omitempty"`
+}
+
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("language server error %d: %s", e.Code, e.Message)
+}
+
+// PathToURI converts an absolute file path to a file:// URI.
+func PathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// URIToPath converts a file:// URI to a file path.
+func URIToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return strings.TrimPrefix(uri, "file://")
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+var languageIDs = map[string]string{
+	".c":    "c",
+	".cc":   "cpp",
+	".cpp":  "cpp",
+	".cs":   "csharp",
+	".css":  "css",
+	".go":   "go",
+	".h":    "c",
+	".hpp":  "cpp",
+	".html": "html",
+	".java": "java",
+	".js":   "javascript",
+	".json": "json",
+	".jsx":  "javascriptreact",
+	".lua":  "lua",
+	".md":   "markdown",
+	".php":  "php",
+	".py":   "python",
+	".rb":   "ruby",
+	".rs":   "rust",
+	".sh":   "shellscript",
+	".ts":   "typescript",
+	".tsx":  "typescriptreact",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".zig":  "zig",
+}
+
+// LanguageID returns the LSP language identifier for a file.
+func LanguageID(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if id, ok := languageIDs[ext]; ok {
+		return id
+	}
+	return strings.TrimPrefix(ext, ".")
+}
@@ -9,6 +9,7 @@ import (
 )
 
 type CreatePermissionRequest struct {
+	SessionID   string `json:"session_id"`
 	ToolName    string `json:"tool_name"`
 	Description string `json:"description"`
 	Action      string `json:"action"`
@@ -37,6 +38,7 @@ type permissionService struct {
 	*pubsub.Broker[PermissionRequest]
 
 	sessionPermissions []PermissionRequest
+	mu                 sync.RWMutex
 	pendingRequests    sync.Map
 }
 
@@ -45,6 +47,8 @@ func (s *permissionService) GrantPersistant(permission PermissionRequest) {
 	if ok {
 		respCh.(chan bool) <- true
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.sessionPermissions = append(s.sessionPermissions, permission)
 }
 
@@ -65,6 +69,7 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	permission := PermissionRequest{
 		ID:          uuid.New().String(),
+		SessionID:   opts.SessionID,
 		Path:        opts.Path,
 		ToolName:    opts.ToolName,
 		Description: opts.Description,
@@ -72,11 +77,14 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 		Params:      opts.Params,
 	}
 
+	s.mu.RLock()
 	for _, p := range s.sessionPermissions {
-		if p.ToolName == permission.ToolName && p.Action == permission.Action {
+		if p.SessionID == permission.SessionID && p.ToolName == permission.ToolName && p.Action == permission.Action {
+			s.mu.RUnlock()
 			return true
 		}
 	}
+	s.mu.RUnlock()
 
 	respCh := make(chan bool, 1)
 
@@ -0,0 +1,302 @@
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+)
+
+const (
+	// minValueLength is the length under which the values of environment
+	// variables are not redacted, short values would match ordinary text
+	minValueLength = 8
+	// minEntropyLength is the length from which tokens are checked for
+	// randomness
+	minEntropyLength = 32
+	// minEntropy is the Shannon entropy in bits per character from which a
+	// token is considered random
+	minEntropy = 4.0
+)
+
+// pattern is a known secret format. When the expression has a group only the
+// group is redacted, the rest is context like the name of the variable.
+type pattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+var patterns = []pattern{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{"anthropic-key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{"openai-key", regexp.MustCompile(`\bsk-(?:proj-|svcacct-)?[A-Za-z0-9_-]{20,}`)},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"stripe-key", regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[A-Za-z0-9]{16,}`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"url-password", regexp.MustCompile(`[a-z][a-z0-9+.-]*://[^/\s:@]+:([^/\s@]+)@`)},
+	{"assigned-secret", regexp.MustCompile(`(?i)[\w.-]*(?:api[_-]?key|secret|token|passw(?:or)?d|credentials?)["']?\s*[:=]\s*["']?([A-Za-z0-9_\-+/=.~]{8,})(?:["'\s,;]|$)`)},
+}
+
+var (
+	// tokenPattern matches the candidates for the entropy check
+	tokenPattern = regexp.MustCompile(`[A-Za-z0-9+/_=-]{32,}`)
+	// hexPattern matches hashes, commit ids and uuids, which are random but
+	// not secret
+	hexPattern = regexp.MustCompile(`^[0-9a-fA-F-]+$`)
+	// secretEnvName matches the names of the environment variables whose
+	// values are redacted without being configured, the words are matched
+	// whole so GIT_AUTHOR_NAME is not taken for a secret
+	secretEnvName = regexp.MustCompile(`(?i)(^|_)(API_?KEY|KEY|TOKEN|SECRET|PASSWORD|PASSWD|CREDENTIALS?|AUTH)($|_)`)
+)
+
+var (
+	totalMu sync.Mutex
+	// totalCounts is the number of secrets redacted by kind since the app
+	// started
+	totalCounts = make(Counts)
+)
+
+// TotalRedacted returns the number of secrets redacted since the app started,
+// by every redactor.
+func TotalRedacted() int {
+	return TotalCounts().Total()
+}
+
+// TotalCounts returns the number of secrets redacted by kind since the app
+// started, by every redactor.
+func TotalCounts() Counts {
+	totalMu.Lock()
+	defer totalMu.Unlock()
+	counts := make(Counts, len(totalCounts))
+	for kind, n := range totalCounts {
+		counts[kind] = n
+	}
+	return counts
+}
+
+// integrityPrefixes precede the checksums of lock files, like go.sum.
+var integrityPrefixes = []string{"h1:", "sha1-", "sha256-", "sha384-", "sha512-"}
+
+// Counts is the number of secrets redacted by kind.
+type Counts map[string]int
+
+// Total returns the number of secrets redacted.
+func (c Counts) Total() int {
+	total := 0
+	for _, n := range c {
+		total += n
+	}
+	return total
+}
+
+// String lists the counts by kind, like "github-token=2, jwt=1".
+func (c Counts) String() string {
+	kinds := make([]string, 0, len(c))
+	for kind := range c {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = fmt.Sprintf("%s=%d", kind, c[kind])
+	}
+	return strings.Join(parts, ", ")
+}
+
+type value struct {
+	name   string
+	secret string
+}
+
+// Redactor replaces the secrets found in text with placeholders. The
+// placeholder of a secret is always the same, so the model can still tell
+// two secrets apart without seeing them.
+type Redactor struct {
+	values []value // longest first, so a value containing another wins
+}
+
+// New returns a redactor for the known secret formats, random tokens and the
+// given values, keyed by the name shown in their placeholder.
+func New(values map[string]string) *Redactor {
+	r := &Redactor{}
+	for name, secret := range values {
+		if len(secret) >= minValueLength {
+			r.values = append(r.values, value{name: name, secret: secret})
+		}
+	}
+	sort.Slice(r.values, func(i, j int) bool {
+		if len(r.values[i].secret) != len(r.values[j].secret) {
+			return len(r.values[i].secret) > len(r.values[j].secret)
+		}
+		return r.values[i].name < r.values[j].name
+	})
+	return r
+}
+
+// FromConfig returns the redactor of the configuration, nil when redaction
+// is disabled. The values of the configured environment variables, of the
+// variables with secret like names and the provider API keys are redacted.
+func FromConfig() *Redactor {
+	cfg := config.Get().Redact
+	if cfg != nil && cfg.Disabled {
+		return nil
+	}
+	values := make(map[string]string)
+	for _, env := range os.Environ() {
+		name, secret, ok := strings.Cut(env, "=")
+		// paths like the one of SSH_AUTH_SOCK are not secret
+		if ok && secretEnvName.MatchString(name) && !filepath.IsAbs(secret) {
+			values[name] = secret
+		}
+	}
+	if cfg != nil {
+		for _, name := range cfg.EnvVars {
+			if secret := os.Getenv(name); secret != "" {
+				values[name] = secret
+			}
+		}
+	}
+	for name, provider := range config.Get().Providers {
+		if provider.APIKey != "" {
+			values[string(name)+"-api-key"] = provider.APIKey
+		}
+	}
+	return New(values)
+}
+
+// Placeholder returns the text replacing a secret of the given kind.
+func Placeholder(kind, secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return fmt.Sprintf("[REDACTED:%s:%s]", kind, hex.EncodeToString(sum[:4]))
+}
+
+// Redact returns text with its secrets replaced and the number of secrets
+// replaced by kind.
+func (r *Redactor) Redact(text string) (string, Counts) {
+	counts := make(Counts)
+	for _, v := range r.values {
+		if n := strings.Count(text, v.secret); n > 0 {
+			text = strings.ReplaceAll(text, v.secret, Placeholder(v.name, v.secret))
+			counts[v.name] += n
+		}
+	}
+	for _, p := range patterns {
+		text = replacePattern(text, p, counts)
+	}
+	text = replaceRandomTokens(text, counts)
+	totalMu.Lock()
+	for kind, n := range counts {
+		totalCounts[kind] += n
+	}
+	totalMu.Unlock()
+	return text, counts
+}
+
+func replacePattern(text string, p pattern, counts Counts) string {
+	matches := p.re.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) > 2 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		secret := text[start:end]
+		if p.kind == "assigned-secret" && !looksLikeSecret(secret) {
+			continue
+		}
+		sb.WriteString(text[last:start])
+		sb.WriteString(Placeholder(p.kind, secret))
+		last = end
+		counts[p.kind]++
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// looksLikeSecret tells apart assigned values like passwords from names and
+// words, a secret mixes letters and digits.
+func looksLikeSecret(s string) bool {
+	if strings.HasPrefix(s, "[REDACTED") {
+		return false
+	}
+	return strings.ContainsAny(s, "0123456789") && strings.IndexFunc(s, isLetter) >= 0
+}
+
+func replaceRandomTokens(text string, counts Counts) string {
+	matches := tokenPattern.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		token := text[m[0]:m[1]]
+		if !isRandomToken(token) || hasIntegrityPrefix(text[:m[0]]) {
+			continue
+		}
+		sb.WriteString(text[last:m[0]])
+		sb.WriteString(Placeholder("random-token", token))
+		last = m[1]
+		counts["random-token"]++
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// isRandomToken reports tokens mixing upper case letters, lower case letters
+// and digits with a high entropy. Hex strings are left out, they are usually
+// hashes.
+func isRandomToken(token string) bool {
+	if len(token) < minEntropyLength || hexPattern.MatchString(token) {
+		return false
+	}
+	if !strings.ContainsAny(token, "0123456789") ||
+		!strings.ContainsAny(token, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") ||
+		!strings.ContainsAny(token, "abcdefghijklmnopqrstuvwxyz") {
+		return false
+	}
+	return entropy(token) >= minEntropy
+}
+
+func hasIntegrityPrefix(before string) bool {
+	for _, prefix := range integrityPrefixes {
+		if strings.HasSuffix(before, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	freq := make(map[rune]int)
+	for _, c := range s {
+		freq[c]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, count := range freq {
+		p := float64(count) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
@@ -0,0 +1,141 @@
+package todo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/imnulhaqueruman/opencode-poc/internal/db"
+	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
+)
+
+type Status string
+
+const (
+	Pending    Status = "pending"
+	InProgress Status = "in_progress"
+	Done       Status = "done"
+)
+
+type Todo struct {
+	ID        string
+	SessionID string
+	Content   string
+	Status    Status
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+// List is the checklist of a session, in the order the agent wrote it.
+type List struct {
+	SessionID string
+	Todos     []Todo
+}
+
+// Counts returns the number of todos with each status.
+func (l List) Counts() map[Status]int {
+	counts := make(map[Status]int)
+	for _, t := range l.Todos {
+		counts[t.Status]++
+	}
+	return counts
+}
+
+// Open reports if some todos are not done.
+func (l List) Open() bool {
+	for _, t := range l.Todos {
+		if t.Status != Done {
+			return true
+		}
+	}
+	return false
+}
+
+type Service interface {
+	pubsub.Suscriber[List]
+	Get(sessionID string) (List, error)
+	// Save replaces the list of a session.
+	Save(sessionID string, todos []Todo) (List, error)
+}
+
+type service struct {
+	*pubsub.Broker[List]
+	conn *sql.DB
+	q    *db.Queries
+	ctx  context.Context
+}
+
+func (s *service) Get(sessionID string) (List, error) {
+	dbTodos, err := s.q.ListTodosBySession(s.ctx, sessionID)
+	if err != nil {
+		return List{}, err
+	}
+	list := List{SessionID: sessionID, Todos: make([]Todo, len(dbTodos))}
+	for i, dbTodo := range dbTodos {
+		list.Todos[i] = s.fromDBItem(dbTodo)
+	}
+	return list, nil
+}
+
+func (s *service) Save(sessionID string, todos []Todo) (List, error) {
+	for _, t := range todos {
+		switch t.Status {
+		case Pending, InProgress, Done:
+		default:
+			return List{}, fmt.Errorf("invalid status %q", t.Status)
+		}
+	}
+
+	// the list is replaced as a whole, a failed insert keeps the old one
+	tx, err := s.conn.BeginTx(s.ctx, nil)
+	if err != nil {
+		return List{}, err
+	}
+	defer tx.Rollback()
+	q := s.q.WithTx(tx)
+
+	if err := q.DeleteSessionTodos(s.ctx, sessionID); err != nil {
+		return List{}, err
+	}
+	list := List{SessionID: sessionID, Todos: make([]Todo, 0, len(todos))}
+	for i, t := range todos {
+		dbTodo, err := q.CreateTodo(s.ctx, db.CreateTodoParams{
+			ID:        uuid.New().String(),
+			SessionID: sessionID,
+			Content:   t.Content,
+			Status:    string(t.Status),
+			Position:  int64(i),
+		})
+		if err != nil {
+			return List{}, err
+		}
+		list.Todos = append(list.Todos, s.fromDBItem(dbTodo))
+	}
+	if err := tx.Commit(); err != nil {
+		return List{}, err
+	}
+	s.Publish(pubsub.UpdatedEvent, list)
+	return list, nil
+}
+
+func (s service) fromDBItem(item db.Todo) Todo {
+	return Todo{
+		ID:        item.ID,
+		SessionID: item.SessionID,
+		Content:   item.Content,
+		Status:    Status(item.Status),
+		CreatedAt: item.CreatedAt,
+		UpdatedAt: item.UpdatedAt,
+	}
+}
+
+func NewService(ctx context.Context, conn *sql.DB, q *db.Queries) Service {
+	broker := pubsub.NewBroker[List]()
+	return &service{
+		broker,
+		conn,
+		q,
+		ctx,
+	}
+}
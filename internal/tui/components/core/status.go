@@ -1,10 +1,14 @@
 package core
 
 import (
+	"fmt"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/imnulhaqueruman/opencode-poc/internal/config"
 	"github.com/imnulhaqueruman/opencode-poc/internal/llm/models"
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools"
+	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
 	"github.com/imnulhaqueruman/opencode-poc/internal/tui/styles"
 	"github.com/imnulhaqueruman/opencode-poc/internal/tui/util"
 	"github.com/imnulhaqueruman/opencode-poc/internal/version"
@@ -14,6 +18,16 @@ type statusCmp struct {
 	err   error
 	info  string
 	width int
+	// changed holds the files changed outside termai that no agent request
+	// has been told about yet
+	changed map[changedFile]bool
+}
+
+// changedFile identifies a file changed outside termai in a session, a file
+// modified then removed is still the same change.
+type changedFile struct {
+	sessionID string
+	path      string
 }
 
 func (m statusCmp) Init() tea.Cmd {
@@ -28,6 +42,13 @@ func (m statusCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.err = msg
 	case util.InfoMsg:
 		m.info = string(msg)
+	case pubsub.Event[tools.ExternalChange]:
+		key := changedFile{sessionID: msg.Payload.SessionID, path: msg.Payload.Path}
+		if msg.Type == pubsub.CreatedEvent {
+			m.changed[key] = true
+		} else {
+			delete(m.changed, key)
+		}
 	}
 	return m, nil
 }
@@ -59,6 +80,7 @@ func (m statusCmp) View() string {
 			Width(m.availableFooterMsgWidth()).
 			Render(m.info)
 	}
+	status += m.externalChanges()
 	status += m.model()
 	status += versionWidget
 	return status
@@ -66,7 +88,25 @@ func (m statusCmp) View() string {
 
 func (m statusCmp) availableFooterMsgWidth() int {
 	// -2 to accommodate padding
-	return max(0, m.width-lipgloss.Width(helpWidget)-lipgloss.Width(versionWidget)-lipgloss.Width(m.model()))
+	return max(0, m.width-lipgloss.Width(helpWidget)-lipgloss.Width(versionWidget)-lipgloss.Width(m.externalChanges())-lipgloss.Width(m.model()))
+}
+
+func (m statusCmp) externalChanges() string {
+	if len(m.changed) == 0 {
+		return ""
+	}
+	paths := make(map[string]bool)
+	for change := range m.changed {
+		paths[change.path] = true
+	}
+	label := "file"
+	if len(paths) > 1 {
+		label = "files"
+	}
+	return styles.Padded.
+		Background(styles.Peach).
+		Foreground(styles.Base).
+		Render(fmt.Sprintf("%d %s changed outside", len(paths), label))
 }
 
 func (m statusCmp) model() string {
@@ -82,5 +122,7 @@ func (m statusCmp) model() string {
 }
 
 func NewStatusCmp() tea.Model {
-	return &statusCmp{}
+	return &statusCmp{
+		changed: make(map[changedFile]bool),
+	}
 }
\ No newline at end of file
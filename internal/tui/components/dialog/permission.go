@@ -2,6 +2,7 @@ package dialog
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -57,6 +58,7 @@ type permissionDialogCmp struct {
 	width           int
 	height          int
 	permission      permission.PermissionRequest
+	sessionTitle    string
 	windowSize      tea.WindowSizeMsg
 	r               *glamour.TermRenderer
 	contentViewPort viewport.Model
@@ -101,10 +103,11 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Get the selected action
 			action := p.form.GetString("action")
 
-			// Close the dialog and return the response
-			return p, tea.Batch(
-				util.CmdHandler(core.DialogCloseMsg{}),
+			// Return the response before closing the dialog, so a close without
+			// a response can be told apart from a regular one
+			return p, tea.Sequence(
 				util.CmdHandler(PermissionResponseMsg{Action: PermissionAction(action), Permission: p.permission}),
+				util.CmdHandler(core.DialogCloseMsg{}),
 			)
 		}
 	}
@@ -117,6 +120,8 @@ func (p *permissionDialogCmp) render() string {
 	valueStyle := lipgloss.NewStyle().Foreground(styles.Peach)
 
 	headerParts := []string{
+		lipgloss.JoinHorizontal(lipgloss.Left, keyStyle.Render("Session:"), " ", valueStyle.Render(p.sessionTitle)),
+		" ",
 		lipgloss.JoinHorizontal(lipgloss.Left, keyStyle.Render("Tool:"), " ", valueStyle.Render(p.permission.ToolName)),
 		" ",
 		lipgloss.JoinHorizontal(lipgloss.Left, keyStyle.Render("Path:"), " ", valueStyle.Render(p.permission.Path)),
@@ -131,16 +136,71 @@ func (p *permissionDialogCmp) render() string {
 	switch p.permission.ToolName {
 	case tools.BashToolName:
 		pr := p.permission.Params.(tools.BashPermissionsParams)
-		headerParts = append(headerParts, keyStyle.Render("Command:"))
-		content, _ = r.Render(fmt.Sprintf("```bash\n%s\n```", pr.Command))
+		label := "Command:"
+		switch {
+		case pr.OutsideSandbox && pr.RunInBackground:
+			label = "Command (background, outside sandbox):"
+		case pr.OutsideSandbox:
+			label = "Command (outside sandbox):"
+		case pr.RunInBackground:
+			label = "Command (background):"
+		}
+		headerParts = append(headerParts, keyStyle.Render(label))
+		md := fmt.Sprintf("```bash\n%s\n```", pr.Command)
+		if len(pr.Commands) > 1 || (len(pr.Commands) == 1 && pr.Commands[0] != strings.TrimSpace(pr.Command)) {
+			// show every command that will run, they can be hidden in
+			// substitutions or nested scripts
+			md += fmt.Sprintf("\n\n**Commands that will run (%d):**\n\n```bash\n%s\n```", len(pr.Commands), strings.Join(pr.Commands, "\n"))
+		}
+		content, _ = r.Render(md)
+	case tools.BashInputToolName:
+		pr := p.permission.Params.(tools.BashInputPermissionsParams)
+		headerParts = append(headerParts,
+			lipgloss.JoinHorizontal(lipgloss.Left, keyStyle.Render("Process:"), " ", valueStyle.Render(pr.ProcessID)),
+			" ",
+			keyStyle.Render("Input:"),
+		)
+		content, _ = r.Render(fmt.Sprintf("```bash\n%s\n```\n\n```\n%s\n```", pr.Command, pr.Input))
 	case tools.EditToolName:
 		pr := p.permission.Params.(tools.EditPermissionsParams)
 		headerParts = append(headerParts, keyStyle.Render("Update:"))
 		content, _ = r.Render(fmt.Sprintf("```diff\n%s\n```", pr.Diff))
+	case tools.MultiEditToolName:
+		pr := p.permission.Params.(tools.MultiEditPermissionsParams)
+		headerParts = append(headerParts, keyStyle.Render(fmt.Sprintf("Update (%d edits):", pr.Edits)))
+		content, _ = r.Render(fmt.Sprintf("```diff\n%s\n```", pr.Diff))
+	case tools.FetchToolName:
+		pr := p.permission.Params.(tools.FetchPermissionsParams)
+		headerParts = append(headerParts,
+			lipgloss.JoinHorizontal(lipgloss.Left, keyStyle.Render("Domain:"), " ", valueStyle.Render(pr.Domain)),
+			" ",
+			keyStyle.Render("URL:"),
+		)
+		content, _ = r.Render(fmt.Sprintf("```\n%s\n```\n\nFormat: %s, timeout: %ds", pr.URL, pr.Format, pr.Timeout))
+	case tools.PatchToolName:
+		pr := p.permission.Params.(tools.PatchPermissionsParams)
+		headerParts = append(headerParts, keyStyle.Render(fmt.Sprintf("Patch (%d files):", len(pr.Files))))
+		content, _ = r.Render(fmt.Sprintf("- %s\n\n```diff\n%s\n```", strings.Join(pr.Files, "\n- "), pr.Diff))
 	case tools.WriteToolName:
 		pr := p.permission.Params.(tools.WritePermissionsParams)
 		headerParts = append(headerParts, keyStyle.Render("Content:"))
 		content, _ = r.Render(fmt.Sprintf("```diff\n%s\n```", pr.Content))
+	case tools.CopyToolName, tools.DeleteToolName, tools.MkdirToolName, tools.MoveToolName:
+		pr := p.permission.Params.(tools.FileOperationPermissionsParams)
+		if pr.Destination != "" {
+			headerParts = append(headerParts,
+				lipgloss.JoinHorizontal(lipgloss.Left, keyStyle.Render("Destination:"), " ", valueStyle.Render(pr.Destination)),
+				" ",
+			)
+		}
+		md := p.permission.Description
+		if len(pr.Files) > 0 {
+			md += fmt.Sprintf("\n\n**Files (%d):**\n\n- %s", pr.TotalFiles, strings.Join(pr.Files, "\n- "))
+			if more := pr.TotalFiles - len(pr.Files); more > 0 {
+				md += fmt.Sprintf("\n\n... and %d more", more)
+			}
+		}
+		content, _ = r.Render(md)
 	default:
 		content, _ = r.Render(p.permission.Description)
 	}
@@ -180,7 +240,7 @@ func (p *permissionDialogCmp) BindingKeys() []key.Binding {
 	return p.form.KeyBinds()
 }
 
-func newPermissionDialogCmp(permission permission.PermissionRequest) PermissionDialog {
+func newPermissionDialogCmp(permission permission.PermissionRequest, sessionTitle string) PermissionDialog {
 	// Create a note field for displaying the content
 
 	// Create select field for the permission options
@@ -207,14 +267,16 @@ func newPermissionDialogCmp(permission permission.PermissionRequest) PermissionD
 
 	return &permissionDialogCmp{
 		permission:   permission,
+		sessionTitle: sessionTitle,
 		form:         form,
 		selectOption: selectOption,
 	}
 }
 
-// NewPermissionDialogCmd creates a new permission dialog command
-func NewPermissionDialogCmd(permission permission.PermissionRequest) tea.Cmd {
-	permDialog := newPermissionDialogCmp(permission)
+// NewPermissionDialogCmd creates a new permission dialog command, labelled with
+// the title of the session the request belongs to
+func NewPermissionDialogCmd(permission permission.PermissionRequest, sessionTitle string) tea.Cmd {
+	permDialog := newPermissionDialogCmp(permission, sessionTitle)
 
 	// Create the dialog layout
 	dialogPane := layout.NewSinglePane(
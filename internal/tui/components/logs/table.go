@@ -2,13 +2,16 @@ package logs
 
 import (
 	"encoding/json"
+	"fmt"
 	"slices"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/imnulhaqueruman/opencode-poc/internal/logging"
 	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
+	"github.com/imnulhaqueruman/opencode-poc/internal/redact"
 	"github.com/imnulhaqueruman/opencode-poc/internal/tui/layout"
 	"github.com/imnulhaqueruman/opencode-poc/internal/tui/styles"
 )
@@ -52,7 +55,22 @@ func (i *tableCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (i *tableCmp) View() string {
-	return i.table.View()
+	header := styles.Regular.Foreground(styles.Peach).Render(redactionSummary(redact.TotalCounts()))
+	return lipgloss.JoinVertical(lipgloss.Left, header, i.table.View())
+}
+
+// redactionSummary describes the secrets redacted since the app started, in
+// the header of the table.
+func redactionSummary(counts redact.Counts) string {
+	total := counts.Total()
+	if total == 0 {
+		return "No secrets redacted"
+	}
+	label := "secret"
+	if total > 1 {
+		label = "secrets"
+	}
+	return fmt.Sprintf("%d %s redacted: %s", total, label, counts)
 }
 
 func (i *tableCmp) Blur() tea.Cmd {
@@ -70,12 +88,13 @@ func (i *tableCmp) IsFocused() bool {
 }
 
 func (i *tableCmp) GetSize() (int, int) {
-	return i.table.Width(), i.table.Height()
+	return i.table.Width(), i.table.Height() + 1
 }
 
 func (i *tableCmp) SetSize(width int, height int) {
 	i.table.SetWidth(width)
-	i.table.SetHeight(height)
+	// the first line is the redaction summary
+	i.table.SetHeight(max(0, height-1))
 	cloumns := i.table.Columns()
 	for i, col := range cloumns {
 		col.Width = (width / len(cloumns)) - 2
@@ -0,0 +1,162 @@
+package processes
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/imnulhaqueruman/opencode-poc/internal/app"
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools/shell"
+	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
+	"github.com/imnulhaqueruman/opencode-poc/internal/tui/layout"
+	"github.com/imnulhaqueruman/opencode-poc/internal/tui/styles"
+	"github.com/imnulhaqueruman/opencode-poc/internal/tui/util"
+)
+
+type TableComponent interface {
+	tea.Model
+	layout.Focusable
+	layout.Sizeable
+	layout.Bindings
+}
+
+type tableCmp struct {
+	app   *app.App
+	table table.Model
+	// rows follow the table order so the selected process can be found
+	infos []shell.ProcessInfo
+}
+
+var killKey = key.NewBinding(
+	key.WithKeys("x"),
+	key.WithHelp("x", "kill process"),
+)
+
+func (i *tableCmp) Init() tea.Cmd {
+	i.setRows()
+	return nil
+}
+
+func (i *tableCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pubsub.Event[shell.ProcessInfo]:
+		i.setRows()
+		return i, nil
+	case tea.KeyMsg:
+		if i.table.Focused() && key.Matches(msg, killKey) {
+			return i, i.killSelected()
+		}
+	}
+	if i.table.Focused() {
+		t, cmd := i.table.Update(msg)
+		i.table = t
+		return i, cmd
+	}
+	return i, nil
+}
+
+func (i *tableCmp) killSelected() tea.Cmd {
+	cursor := i.table.Cursor()
+	if cursor < 0 || cursor >= len(i.infos) {
+		return nil
+	}
+	info := i.infos[cursor]
+	// killing waits for the process to exit, keep it off the update loop
+	return func() tea.Msg {
+		if err := shell.RemoveBackgroundProcess(info.SessionID, info.ID); err != nil {
+			return util.ErrorMsg(err)
+		}
+		return util.InfoMsg(fmt.Sprintf("Killed %s", info.ID))
+	}
+}
+
+func (i *tableCmp) View() string {
+	return i.table.View()
+}
+
+func (i *tableCmp) Blur() tea.Cmd {
+	i.table.Blur()
+	return nil
+}
+
+func (i *tableCmp) Focus() tea.Cmd {
+	i.table.Focus()
+	return nil
+}
+
+func (i *tableCmp) IsFocused() bool {
+	return i.table.Focused()
+}
+
+func (i *tableCmp) GetSize() (int, int) {
+	return i.table.Width(), i.table.Height()
+}
+
+func (i *tableCmp) SetSize(width int, height int) {
+	i.table.SetWidth(width)
+	i.table.SetHeight(height)
+	cloumns := i.table.Columns()
+	for i, col := range cloumns {
+		col.Width = (width / len(cloumns)) - 2
+		cloumns[i] = col
+	}
+	i.table.SetColumns(cloumns)
+}
+
+func (i *tableCmp) BindingKeys() []key.Binding {
+	return append(layout.KeyMapToSlice(i.table.KeyMap), killKey)
+}
+
+func (i *tableCmp) setRows() {
+	i.infos = shell.ListBackgroundProcesses("")
+
+	titles := make(map[string]string)
+	rows := make([]table.Row, 0, len(i.infos))
+	for _, info := range i.infos {
+		title, ok := titles[info.SessionID]
+		if !ok {
+			title = info.SessionID
+			if s, err := i.app.Sessions.Get(info.SessionID); err == nil {
+				title = s.Title
+			}
+			titles[info.SessionID] = title
+		}
+
+		status := string(info.Status)
+		if info.Status == shell.ProcessExited {
+			status = fmt.Sprintf("exited (%d)", info.ExitCode)
+		}
+		rows = append(rows, table.Row{
+			info.ID,
+			title,
+			status,
+			fmt.Sprintf("%d", info.PID),
+			info.StartedAt.Format(time.TimeOnly),
+			info.Command,
+		})
+	}
+	i.table.SetRows(rows)
+}
+
+func NewProcessesTable(app *app.App) TableComponent {
+	columns := []table.Column{
+		{Title: "ID", Width: 4},
+		{Title: "Session", Width: 10},
+		{Title: "Status", Width: 10},
+		{Title: "PID", Width: 10},
+		{Title: "Started", Width: 10},
+		{Title: "Command", Width: 10},
+	}
+	defaultStyles := table.DefaultStyles()
+	defaultStyles.Selected = defaultStyles.Selected.Foreground(styles.Primary)
+	tableModel := table.New(
+		table.WithColumns(columns),
+		table.WithStyles(defaultStyles),
+	)
+	return &tableCmp{
+		app:   app,
+		table: tableModel,
+	}
+}
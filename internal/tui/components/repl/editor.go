@@ -2,13 +2,17 @@ package repl
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/imnulhaqueruman/opencode-poc/internal/app"
-	"github.com/imnulhaqueruman/opencode-poc/internal/llm/agent"
+	"github.com/imnulhaqueruman/opencode-poc/internal/config"
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools/shell"
+	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
 	"github.com/imnulhaqueruman/opencode-poc/internal/tui/layout"
 	"github.com/imnulhaqueruman/opencode-poc/internal/tui/styles"
 	"github.com/imnulhaqueruman/opencode-poc/internal/tui/util"
@@ -31,11 +35,16 @@ type editorCmp struct {
 	focused    bool
 	width      int
 	height     int
+
+	// shellCwd is the current directory of the session shell, empty when
+	// the session has no shell
+	shellCwd string
 }
 
 type editorKeyMap struct {
 	SendMessage    key.Binding
 	SendMessageI   key.Binding
+	Cancel         key.Binding
 	InsertMode     key.Binding
 	NormaMode      key.Binding
 	VisualMode     key.Binding
@@ -51,6 +60,10 @@ var editorKeyMapValue = editorKeyMap{
 		key.WithKeys("ctrl+s"),
 		key.WithHelp("ctrl+s", "send message insert mode"),
 	),
+	Cancel: key.NewBinding(
+		key.WithKeys("ctrl+x"),
+		key.WithHelp("ctrl+x", "cancel request"),
+	),
 	InsertMode: key.NewBinding(
 		key.WithKeys("i"),
 		key.WithHelp("i", "insert mode"),
@@ -80,6 +93,18 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case SelectedSessionMsg:
 		if msg.SessionID != m.sessionID {
 			m.sessionID = msg.SessionID
+			m.shellCwd = ""
+			if shells := shell.ListShells(m.sessionID); len(shells) > 0 {
+				m.shellCwd = shells[0].Cwd
+			}
+		}
+	case pubsub.Event[shell.ShellInfo]:
+		if msg.Payload.SessionID == m.sessionID {
+			if msg.Type == pubsub.DeletedEvent {
+				m.shellCwd = ""
+			} else {
+				m.shellCwd = msg.Payload.Cwd
+			}
 		}
 	}
 	if m.IsFocused() {
@@ -94,6 +119,8 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.editorMode == vimtea.ModeInsert {
 					return m, m.Send()
 				}
+			case key.Matches(msg, editorKeyMapValue.Cancel):
+				return m, m.Cancel()
 			}
 		}
 		u, cmd := m.editor.Update(msg)
@@ -113,9 +140,27 @@ func (m *editorCmp) BorderText() map[layout.BorderPosition]string {
 	if m.focused {
 		title = lipgloss.NewStyle().Foreground(styles.Primary).Render(title)
 	}
-	return map[layout.BorderPosition]string{
+	borderText := map[layout.BorderPosition]string{
 		layout.BottomLeftBorder: title,
 	}
+	if m.shellCwd != "" {
+		borderText[layout.BottomRightBorder] = "shell: " + displayPath(m.shellCwd)
+	}
+	return borderText
+}
+
+// displayPath shortens a path for the borders, relative to the working
+// directory or to the home directory.
+func displayPath(path string) string {
+	if rel, err := filepath.Rel(config.WorkingDirectory(), path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join("~", rel)
+		}
+	}
+	return path
 }
 
 func (m *editorCmp) Focus() tea.Cmd {
@@ -142,27 +187,27 @@ func (m *editorCmp) Send() tea.Cmd {
 		if m.sessionID == "" {
 			return util.ErrorMsg(errors.New("No session selected"))
 		}
-		
-		messages, _ := m.app.Messages.List(m.sessionID)
-		if hasUnfinishedMessages(messages) {
-			return util.InfoMsg("Assistant is still working on the previous message")
+		if m.app.CoderAgent == nil {
+			return util.ErrorMsg(errors.New("No agent configured, check your providers"))
 		}
-		a, err := agent.NewCoderAgent(m.app)
-		if err != nil {
-			return util.ErrorMsg(err)
+		if m.app.CoderAgent.IsSessionBusy(m.sessionID) {
+			return util.InfoMsg("Assistant is still working on the previous message")
 		}
 
 		if m.editor == nil {
 			return util.ErrorMsg(errors.New("Editor is not initialized"))
 		}
-		
+
 		buffer := m.editor.GetBuffer()
 		if buffer == nil {
 			return util.ErrorMsg(errors.New("Editor buffer is not available"))
 		}
-		
+
 		content := strings.Join(buffer.Lines(), "\n")
-		go a.Generate(m.sessionID, content)
+		_, err := m.app.CoderAgent.Run(m.app.Context, m.sessionID, content)
+		if err != nil {
+			return util.ErrorMsg(err)
+		}
 
 		m.editor = vimtea.NewEditor(
 			vimtea.WithFileName("message.md"),
@@ -171,6 +216,16 @@ func (m *editorCmp) Send() tea.Cmd {
 	}
 }
 
+func (m *editorCmp) Cancel() tea.Cmd {
+	return func() tea.Msg {
+		if m.app.CoderAgent == nil || !m.app.CoderAgent.IsSessionBusy(m.sessionID) {
+			return nil
+		}
+		m.app.CoderAgent.Cancel(m.sessionID)
+		return util.InfoMsg("Cancelling request...")
+	}
+}
+
 func (m *editorCmp) View() string {
 	return m.editor.View()
 }
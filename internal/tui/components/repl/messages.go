@@ -132,19 +132,6 @@ func borderText(msgRole message.MessageRole, currentMessage int) map[layout.Bord
 	}
 }
 
-func hasUnfinishedMessages(messages []message.Message) bool {
-	if len(messages) == 0 {
-		return false
-	}
-	for _, msg := range messages {
-		if !msg.Finished {
-			return true
-		}
-	}
-	lastMessage := messages[len(messages)-1]
-	return lastMessage.Role != message.Assistant
-}
-
 func (m *messagesCmp) renderMessageWithToolCall(content string, tools []message.ToolCall, futureMessages []message.Message) string {
 	allParts := []string{content}
 
@@ -418,7 +405,7 @@ func (m *messagesCmp) BorderText() map[layout.BorderPosition]string {
 		layout.TopLeftBorder:     title,
 		layout.BottomRightBorder: formatTokensAndCost(m.session.CompletionTokens+m.session.PromptTokens, m.session.Cost),
 	}
-	if hasUnfinishedMessages(m.messages) {
+	if m.app.CoderAgent != nil && m.app.CoderAgent.IsSessionBusy(m.session.ID) {
 		borderTest[layout.BottomLeftBorder] = lipgloss.NewStyle().Foreground(styles.Peach).Render("Thinking...")
 	} else {
 		borderTest[layout.BottomLeftBorder] = lipgloss.NewStyle().Foreground(styles.Text).Render("Sleeping " + styles.SleepIcon + " ")
@@ -1,16 +1,21 @@
 package repl
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/imnulhaqueruman/opencode-poc/internal/app"
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/agent"
+	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
 	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
 	"github.com/imnulhaqueruman/opencode-poc/internal/session"
+	"github.com/imnulhaqueruman/opencode-poc/internal/tui/components/dialog"
 	"github.com/imnulhaqueruman/opencode-poc/internal/tui/layout"
 	"github.com/imnulhaqueruman/opencode-poc/internal/tui/styles"
 	"github.com/imnulhaqueruman/opencode-poc/internal/tui/util"
@@ -23,18 +28,50 @@ type SessionsCmp interface {
 	layout.Bordered
 	layout.Bindings
 }
+type sessionStatus int
+
+const (
+	sessionIdle sessionStatus = iota
+	sessionBusy
+	sessionWaitingPermission
+	sessionError
+)
+
+type sessionState struct {
+	status sessionStatus
+	err    error
+}
+
 type sessionsCmp struct {
 	app     *app.App
 	list    list.Model
 	focused bool
+
+	states  map[string]sessionState
+	spinner spinner.Model
+	ticking bool
 }
 
 type listItem struct {
 	id, title, desc string
+	// badge and status are derived from the session's agent state
+	badge, status string
+}
+
+func (i listItem) Title() string {
+	if i.badge == "" {
+		return i.title
+	}
+	return i.badge + " " + i.title
+}
+
+func (i listItem) Description() string {
+	if i.status == "" {
+		return i.desc
+	}
+	return i.status
 }
 
-func (i listItem) Title() string       { return i.title }
-func (i listItem) Description() string { return i.desc }
 func (i listItem) FilterValue() string { return i.title }
 
 type InsertSessionsMsg struct {
@@ -87,7 +124,37 @@ func (i *sessionsCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				desc:  formatTokensAndCost(s.PromptTokens+s.CompletionTokens, s.Cost),
 			}
 		}
-		return i, i.list.SetItems(items)
+		return i, i.list.SetItems(i.withStatus(items))
+	case pubsub.Event[agent.AgentEvent]:
+		switch msg.Type {
+		case agent.AgentEventStarted, agent.AgentEventToolRunning:
+			i.states[msg.Payload.SessionID] = sessionState{status: sessionBusy}
+		case agent.AgentEventCompleted:
+			delete(i.states, msg.Payload.SessionID)
+		case agent.AgentEventError:
+			if errors.Is(msg.Payload.Error, agent.ErrRequestCancelled) {
+				delete(i.states, msg.Payload.SessionID)
+			} else {
+				i.states[msg.Payload.SessionID] = sessionState{status: sessionError, err: msg.Payload.Error}
+			}
+		}
+		return i, i.refreshStatus()
+	case pubsub.Event[permission.PermissionRequest]:
+		i.states[msg.Payload.SessionID] = sessionState{status: sessionWaitingPermission}
+		return i, i.refreshStatus()
+	case dialog.PermissionResponseMsg:
+		if i.states[msg.Permission.SessionID].status == sessionWaitingPermission {
+			i.states[msg.Permission.SessionID] = sessionState{status: sessionBusy}
+		}
+		return i, i.refreshStatus()
+	case spinner.TickMsg:
+		if !i.hasActiveSessions() {
+			i.ticking = false
+			return i, nil
+		}
+		var cmd tea.Cmd
+		i.spinner, cmd = i.spinner.Update(msg)
+		return i, tea.Batch(cmd, i.list.SetItems(i.withStatus(i.list.Items())))
 	case pubsub.Event[session.Session]:
 		if msg.Type == pubsub.CreatedEvent && msg.Payload.ParentSessionID == "" {
 			// Check if the session is already in the list
@@ -104,7 +171,7 @@ func (i *sessionsCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				title: msg.Payload.Title,
 				desc:  formatTokensAndCost(msg.Payload.PromptTokens+msg.Payload.CompletionTokens, msg.Payload.Cost),
 			}}, items...)
-			return i, i.list.SetItems(items)
+			return i, i.list.SetItems(i.withStatus(items))
 		} else if msg.Type == pubsub.UpdatedEvent {
 			// update the session in the list
 			items := i.list.Items()
@@ -117,7 +184,7 @@ func (i *sessionsCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					break
 				}
 			}
-			return i, i.list.SetItems(items)
+			return i, i.list.SetItems(i.withStatus(items))
 		}
 
 	case tea.KeyMsg:
@@ -138,6 +205,49 @@ func (i *sessionsCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return i, nil
 }
 
+func (i *sessionsCmp) hasActiveSessions() bool {
+	for _, state := range i.states {
+		if state.status == sessionBusy || state.status == sessionWaitingPermission {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshStatus re-renders the session badges and starts the spinner when a
+// session becomes active.
+func (i *sessionsCmp) refreshStatus() tea.Cmd {
+	cmds := []tea.Cmd{i.list.SetItems(i.withStatus(i.list.Items()))}
+	if !i.ticking && i.hasActiveSessions() {
+		i.ticking = true
+		cmds = append(cmds, i.spinner.Tick)
+	}
+	return tea.Batch(cmds...)
+}
+
+func (i *sessionsCmp) withStatus(items []list.Item) []list.Item {
+	for idx, item := range items {
+		s := item.(listItem)
+		state := i.states[s.id]
+		switch state.status {
+		case sessionBusy:
+			s.badge = i.spinner.View()
+			s.status = "Working..."
+		case sessionWaitingPermission:
+			s.badge = i.spinner.View()
+			s.status = "Waiting for permission"
+		case sessionError:
+			s.badge = styles.ErrorIcon
+			s.status = fmt.Sprintf("Error: %s", state.err)
+		default:
+			s.badge = ""
+			s.status = ""
+		}
+		items[idx] = s
+	}
+	return items
+}
+
 func (i *sessionsCmp) View() string {
 	return i.list.View()
 }
@@ -243,5 +353,7 @@ func NewSessionsCmp(app *app.App) SessionsCmp {
 		app:     app,
 		list:    listComponent,
 		focused: false,
+		states:  make(map[string]sessionState),
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot)),
 	}
 }
\ No newline at end of file
@@ -0,0 +1,150 @@
+package repl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/imnulhaqueruman/opencode-poc/internal/app"
+	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
+	"github.com/imnulhaqueruman/opencode-poc/internal/todo"
+	"github.com/imnulhaqueruman/opencode-poc/internal/tui/layout"
+	"github.com/imnulhaqueruman/opencode-poc/internal/tui/styles"
+	"github.com/imnulhaqueruman/opencode-poc/internal/tui/util"
+)
+
+type TodosCmp interface {
+	tea.Model
+	layout.Sizeable
+	layout.Focusable
+	layout.Bordered
+	layout.Bindings
+}
+
+// todosCmp shows the todo list the agent keeps for the selected session.
+type todosCmp struct {
+	app       *app.App
+	sessionID string
+	list      todo.List
+	viewport  viewport.Model
+	focused   bool
+}
+
+func (t *todosCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (t *todosCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case SelectedSessionMsg:
+		t.sessionID = msg.SessionID
+		list, err := t.app.Todos.Get(msg.SessionID)
+		if err != nil {
+			return t, util.ReportError(err)
+		}
+		t.list = list
+		t.renderView()
+		t.viewport.GotoTop()
+		return t, nil
+	case pubsub.Event[todo.List]:
+		if msg.Payload.SessionID == t.sessionID {
+			t.list = msg.Payload
+			t.renderView()
+		}
+		return t, nil
+	}
+	if t.focused {
+		u, cmd := t.viewport.Update(msg)
+		t.viewport = u
+		return t, cmd
+	}
+	return t, nil
+}
+
+func (t *todosCmp) renderView() {
+	width := t.viewport.Width
+	if width <= 0 {
+		return
+	}
+	if len(t.list.Todos) == 0 {
+		t.viewport.SetContent(lipgloss.NewStyle().Foreground(styles.Grey).Width(width).Render("No todos yet"))
+		return
+	}
+
+	lines := make([]string, 0, len(t.list.Todos))
+	for _, item := range t.list.Todos {
+		icon := "○"
+		style := lipgloss.NewStyle().Foreground(styles.Text)
+		switch item.Status {
+		case todo.InProgress:
+			icon = "◐"
+			style = lipgloss.NewStyle().Foreground(styles.Peach).Bold(true)
+		case todo.Done:
+			icon = styles.CheckIcon
+			style = lipgloss.NewStyle().Foreground(styles.Grey).Strikethrough(true)
+		}
+		iconStyle := style.Strikethrough(false)
+		if item.Status == todo.Done {
+			iconStyle = iconStyle.Foreground(styles.Green)
+		}
+		content := style.Width(max(width-2, 1)).Render(item.Content)
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top, iconStyle.Render(icon+" "), content))
+	}
+	t.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+func (t *todosCmp) View() string {
+	return t.viewport.View()
+}
+
+func (t *todosCmp) Blur() tea.Cmd {
+	t.focused = false
+	return nil
+}
+
+func (t *todosCmp) Focus() tea.Cmd {
+	t.focused = true
+	return nil
+}
+
+func (t *todosCmp) IsFocused() bool {
+	return t.focused
+}
+
+func (t *todosCmp) GetSize() (int, int) {
+	return t.viewport.Width, t.viewport.Height
+}
+
+func (t *todosCmp) SetSize(width int, height int) {
+	t.viewport.Width = width
+	t.viewport.Height = height
+	t.renderView()
+}
+
+func (t *todosCmp) BorderText() map[layout.BorderPosition]string {
+	title := "Todo"
+	if t.focused {
+		title = lipgloss.NewStyle().Foreground(styles.Primary).Render(title)
+	}
+	borderText := map[layout.BorderPosition]string{
+		layout.TopMiddleBorder: title,
+	}
+	if len(t.list.Todos) > 0 {
+		borderText[layout.BottomMiddleBorder] = fmt.Sprintf("%d/%d done", t.list.Counts()[todo.Done], len(t.list.Todos))
+	}
+	return borderText
+}
+
+func (t *todosCmp) BindingKeys() []key.Binding {
+	return layout.KeyMapToSlice(t.viewport.KeyMap)
+}
+
+func NewTodosCmp(app *app.App) TodosCmp {
+	return &todosCmp{
+		app:      app,
+		viewport: viewport.New(0, 0),
+	}
+}
@@ -1,6 +1,8 @@
 package layout
 
 import (
+	"slices"
+
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -10,17 +12,23 @@ type paneID string
 
 const (
 	BentoLeftPane        paneID = "left"
+	BentoLeftBottomPane  paneID = "left-bottom"
 	BentoRightTopPane    paneID = "right-top"
 	BentoRightBottomPane paneID = "right-bottom"
 )
 
+// bentoPaneOrder is the order panes are switched in.
+var bentoPaneOrder = []paneID{BentoLeftPane, BentoLeftBottomPane, BentoRightTopPane, BentoRightBottomPane}
+
 type BentoPanes map[paneID]tea.Model
 
 const (
 	defaultLeftWidthRatio      = 0.2
+	defaultLeftTopHeightRatio  = 0.6
 	defaultRightTopHeightRatio = 0.85
 
 	minLeftWidth         = 10
+	minLeftBottomHeight  = 5
 	minRightBottomHeight = 10
 )
 
@@ -61,6 +69,7 @@ type bentoLayout struct {
 	height int
 
 	leftWidthRatio      float64
+	leftTopHeightRatio  float64
 	rightTopHeightRatio float64
 
 	currentPane paneID
@@ -135,8 +144,14 @@ func (b *bentoLayout) View() string {
 
 	var leftPane, rightTopPane, rightBottomPane string
 
-	if pane, ok := b.panes[BentoLeftPane]; ok && !b.hiddenPanes[BentoLeftPane] {
-		leftPane = pane.View()
+	var leftPanes []string
+	for _, id := range []paneID{BentoLeftPane, BentoLeftBottomPane} {
+		if pane, ok := b.panes[id]; ok && !b.hiddenPanes[id] {
+			leftPanes = append(leftPanes, pane.View())
+		}
+	}
+	if len(leftPanes) > 0 {
+		leftPane = lipgloss.JoinVertical(lipgloss.Top, leftPanes...)
 		leftVisible = true
 	}
 
@@ -188,13 +203,18 @@ func (b *bentoLayout) SetSize(width int, height int) {
 	b.height = height
 
 	// Check which panes are available
-	leftExists := false
+	leftTopExists := false
+	leftBottomExists := false
 	rightTopExists := false
 	rightBottomExists := false
 
 	if _, ok := b.panes[BentoLeftPane]; ok && !b.hiddenPanes[BentoLeftPane] {
-		leftExists = true
+		leftTopExists = true
 	}
+	if _, ok := b.panes[BentoLeftBottomPane]; ok && !b.hiddenPanes[BentoLeftBottomPane] {
+		leftBottomExists = true
+	}
+	leftExists := leftTopExists || leftBottomExists
 	if _, ok := b.panes[BentoRightTopPane]; ok && !b.hiddenPanes[BentoRightTopPane] {
 		rightTopExists = true
 	}
@@ -248,8 +268,22 @@ func (b *bentoLayout) SetSize(width int, height int) {
 		}
 	}
 
+	leftTopHeight := height
+	leftBottomHeight := height
+	if leftTopExists && leftBottomExists {
+		leftTopHeight = int(float64(height) * b.leftTopHeightRatio)
+		leftBottomHeight = height - leftTopHeight
+		if leftBottomHeight < minLeftBottomHeight && height >= minLeftBottomHeight {
+			leftBottomHeight = minLeftBottomHeight
+			leftTopHeight = height - leftBottomHeight
+		}
+	}
+
 	if pane, ok := b.panes[BentoLeftPane]; ok && !b.hiddenPanes[BentoLeftPane] {
-		pane.SetSize(leftWidth, height)
+		pane.SetSize(leftWidth, leftTopHeight)
+	}
+	if pane, ok := b.panes[BentoLeftBottomPane]; ok && !b.hiddenPanes[BentoLeftBottomPane] {
+		pane.SetSize(leftWidth, leftBottomHeight)
 	}
 	if pane, ok := b.panes[BentoRightTopPane]; ok && !b.hiddenPanes[BentoRightTopPane] {
 		pane.SetSize(rightWidth, rightTopHeight)
@@ -271,23 +305,18 @@ func (b *bentoLayout) HidePane(pane paneID) tea.Cmd {
 }
 
 func (b *bentoLayout) SwitchPane(back bool) tea.Cmd {
-	if back {
-		switch b.currentPane {
-		case BentoLeftPane:
-			b.currentPane = BentoRightBottomPane
-		case BentoRightTopPane:
-			b.currentPane = BentoLeftPane
-		case BentoRightBottomPane:
-			b.currentPane = BentoRightTopPane
+	// panes that are not part of the layout are skipped
+	var order []paneID
+	for _, id := range bentoPaneOrder {
+		if _, ok := b.panes[id]; ok {
+			order = append(order, id)
 		}
-	} else {
-		switch b.currentPane {
-		case BentoLeftPane:
-			b.currentPane = BentoRightTopPane
-		case BentoRightTopPane:
-			b.currentPane = BentoRightBottomPane
-		case BentoRightBottomPane:
-			b.currentPane = BentoLeftPane
+	}
+	if idx := slices.Index(order, b.currentPane); idx >= 0 {
+		if back {
+			b.currentPane = order[(idx+len(order)-1)%len(order)]
+		} else {
+			b.currentPane = order[(idx+1)%len(order)]
 		}
 	}
 
@@ -338,6 +367,7 @@ func NewBentoLayout(panes BentoPanes, opts ...BentoLayoutOption) BentoLayout {
 		hiddenPanes:         make(map[paneID]bool),
 		currentPane:         BentoLeftPane,
 		leftWidthRatio:      defaultLeftWidthRatio,
+		leftTopHeightRatio:  defaultLeftTopHeightRatio,
 		rightTopHeightRatio: defaultRightTopHeightRatio,
 	}
 
@@ -356,6 +386,14 @@ func WithBentoLayoutLeftWidthRatio(ratio float64) BentoLayoutOption {
 	}
 }
 
+func WithBentoLayoutLeftTopHeightRatio(ratio float64) BentoLayoutOption {
+	return func(b *bentoLayout) {
+		if ratio > 0 && ratio < 1 {
+			b.leftTopHeightRatio = ratio
+		}
+	}
+}
+
 func WithBentoLayoutRightTopHeightRatio(ratio float64) BentoLayoutOption {
 	return func(b *bentoLayout) {
 		if ratio > 0 && ratio < 1 {
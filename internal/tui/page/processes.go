@@ -0,0 +1,26 @@
+package page
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/imnulhaqueruman/opencode-poc/internal/app"
+	"github.com/imnulhaqueruman/opencode-poc/internal/tui/components/processes"
+	"github.com/imnulhaqueruman/opencode-poc/internal/tui/layout"
+)
+
+var ProcessesPage PageID = "processes"
+
+func NewProcessesPage(app *app.App) tea.Model {
+	p := layout.NewSinglePane(
+		processes.NewProcessesTable(app),
+		layout.WithSinglePaneFocusable(true),
+		layout.WithSinglePaneBordered(true),
+		layout.WithSignlePaneBorderText(
+			map[layout.BorderPosition]string{
+				layout.TopMiddleBorder: "Background Processes",
+			},
+		),
+		layout.WithSinglePanePadding(1),
+	)
+	p.Focus()
+	return p
+}
@@ -13,6 +13,7 @@ func NewReplPage(app *app.App) tea.Model {
 	return layout.NewBentoLayout(
 		layout.BentoPanes{
 			layout.BentoLeftPane:        repl.NewSessionsCmp(app),
+			layout.BentoLeftBottomPane:  repl.NewTodosCmp(app),
 			layout.BentoRightTopPane:    repl.NewMessagesCmp(app),
 			layout.BentoRightBottomPane: repl.NewEditorCmp(app),
 		},
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 
@@ -8,6 +9,9 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/imnulhaqueruman/opencode-poc/internal/app"
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/agent"
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools"
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools/shell"
 	"github.com/imnulhaqueruman/opencode-poc/internal/permission"
 	"github.com/imnulhaqueruman/opencode-poc/internal/pubsub"
 	"github.com/imnulhaqueruman/opencode-poc/internal/tui/components/core"
@@ -20,11 +24,12 @@ import (
 )
 
 type keyMap struct {
-	Logs   key.Binding
-	Return key.Binding
-	Back   key.Binding
-	Quit   key.Binding
-	Help   key.Binding
+	Logs      key.Binding
+	Processes key.Binding
+	Return    key.Binding
+	Back      key.Binding
+	Quit      key.Binding
+	Help      key.Binding
 }
 
 var keys = keyMap{
@@ -32,6 +37,10 @@ var keys = keyMap{
 		key.WithKeys("L"),
 		key.WithHelp("L", "logs"),
 	),
+	Processes: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "background processes"),
+	),
 	Return: key.NewBinding(
 		key.WithKeys("esc"),
 		key.WithHelp("esc", "close"),
@@ -55,6 +64,11 @@ var replKeyMap = key.NewBinding(
 	key.WithHelp("N", "new session"),
 )
 
+var resetShellKey = key.NewBinding(
+	key.WithKeys("S"),
+	key.WithHelp("S", "reset session shell"),
+)
+
 type appModel struct {
 	width, height int
 	currentPage   page.PageID
@@ -66,6 +80,14 @@ type appModel struct {
 	dialog        core.DialogCmp
 	app           *app.App
 	dialogVisible bool
+
+	// sessionID is the session selected in the repl page
+	sessionID string
+
+	// permission requests can arrive from several sessions at once, they are
+	// shown one at a time
+	pendingPermissions []permission.PermissionRequest
+	currentPermission  *permission.PermissionRequest
 	editorMode    vimtea.EditorMode
 	showHelp      bool
 }
@@ -78,8 +100,33 @@ func (a appModel) Init() tea.Cmd {
 
 func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case pubsub.Event[agent.AgentEvent]:
+		if msg.Type == agent.AgentEventError && !errors.Is(msg.Payload.Error, agent.ErrRequestCancelled) {
+			a.status, _ = a.status.Update(util.ErrorMsg(msg.Payload.Error))
+		}
+	case pubsub.Event[shell.ProcessInfo]:
+		// the processes page is kept up to date even when it is not shown
+		if a.currentPage != page.ProcessesPage {
+			p, cmd := a.pages[page.ProcessesPage].Update(msg)
+			a.pages[page.ProcessesPage] = p
+			return a, cmd
+		}
+	case pubsub.Event[shell.ShellInfo]:
+		// the repl page shows the directory of the session shell
+		if a.currentPage != page.ReplPage {
+			p, cmd := a.pages[page.ReplPage].Update(msg)
+			a.pages[page.ReplPage] = p
+			return a, cmd
+		}
+	case pubsub.Event[tools.ExternalChange]:
+		a.status, _ = a.status.Update(msg)
+	case repl.SelectedSessionMsg:
+		a.sessionID = msg.SessionID
 	case pubsub.Event[permission.PermissionRequest]:
-		return a, dialog.NewPermissionDialogCmd(msg.Payload)
+		a.pendingPermissions = append(a.pendingPermissions, msg.Payload)
+		p, cmd := a.pages[a.currentPage].Update(msg)
+		a.pages[a.currentPage] = p
+		return a, tea.Batch(cmd, a.showNextPermission())
 	case dialog.PermissionResponseMsg:
 		switch msg.Action {
 		case dialog.PermissionAllow:
@@ -89,6 +136,13 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case dialog.PermissionDeny:
 			permission.Default.Deny(msg.Permission)
 		}
+		a.currentPermission = nil
+		p, cmd := a.pages[a.currentPage].Update(msg)
+		a.pages[a.currentPage] = p
+		if !a.dialogVisible {
+			return a, tea.Batch(cmd, a.showNextPermission())
+		}
+		return a, cmd
 	case vimtea.EditorModeMsg:
 		a.editorMode = msg.Mode
 	case tea.WindowSizeMsg:
@@ -119,7 +173,16 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		d, cmd := a.dialog.Update(msg)
 		a.dialog = d.(core.DialogCmp)
 		a.dialogVisible = false
-		return a, cmd
+		if a.currentPermission != nil {
+			// closed without answering, treat it as denied
+			denied := *a.currentPermission
+			a.currentPermission = nil
+			return a, tea.Batch(cmd, util.CmdHandler(dialog.PermissionResponseMsg{
+				Permission: denied,
+				Action:     dialog.PermissionDeny,
+			}))
+		}
+		return a, tea.Batch(cmd, a.showNextPermission())
 	case page.PageChangeMsg:
 		return a, a.moveToPage(msg.ID)
 	case util.InfoMsg:
@@ -156,22 +219,60 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 					return a, util.CmdHandler(repl.SelectedSessionMsg{SessionID: s.ID})
 				}
+			case key.Matches(msg, resetShellKey):
+				if a.currentPage == page.ReplPage && a.sessionID != "" {
+					return a, resetShell(a.sessionID)
+				}
 			case key.Matches(msg, keys.Logs):
 				return a, a.moveToPage(page.LogsPage)
+			case key.Matches(msg, keys.Processes):
+				return a, a.moveToPage(page.ProcessesPage)
 			case key.Matches(msg, keys.Help):
 				a.ToggleHelp()
 				return a, nil
 			}
 		}
 	}
+	var cmds []tea.Cmd
 	if a.dialogVisible {
 		d, cmd := a.dialog.Update(msg)
 		a.dialog = d.(core.DialogCmp)
-		return a, cmd
+		cmds = append(cmds, cmd)
+		// keep the page up to date with everything but user input, other
+		// sessions can still be running while a dialog is open
+		switch msg.(type) {
+		case tea.KeyMsg, tea.MouseMsg:
+			return a, tea.Batch(cmds...)
+		}
 	}
 	p, cmd := a.pages[a.currentPage].Update(msg)
 	a.pages[a.currentPage] = p
-	return a, cmd
+	cmds = append(cmds, cmd)
+	return a, tea.Batch(cmds...)
+}
+
+func (a *appModel) showNextPermission() tea.Cmd {
+	if a.currentPermission != nil || len(a.pendingPermissions) == 0 {
+		return nil
+	}
+	next := a.pendingPermissions[0]
+	a.pendingPermissions = a.pendingPermissions[1:]
+	a.currentPermission = &next
+
+	sessionTitle := next.SessionID
+	if s, err := a.app.Sessions.Get(next.SessionID); err == nil {
+		sessionTitle = s.Title
+	}
+	return dialog.NewPermissionDialogCmd(next, sessionTitle)
+}
+
+// resetShell closes the shells of a session, its next command starts in a new
+// shell in the working directory.
+func resetShell(sessionID string) tea.Cmd {
+	return func() tea.Msg {
+		shell.CloseSessionShells(sessionID)
+		return util.InfoMsg("Shell reset, the next command starts in the working directory")
+	}
 }
 
 func (a *appModel) ToggleHelp() {
@@ -217,7 +318,7 @@ func (a appModel) View() string {
 			bindings = append(bindings, a.dialog.BindingKeys()...)
 		}
 		if a.currentPage == page.ReplPage {
-			bindings = append(bindings, replKeyMap)
+			bindings = append(bindings, replKeyMap, resetShellKey)
 		}
 		a.help.SetBindings(bindings)
 		components = append(components, a.help.View())
@@ -261,9 +362,10 @@ func New(app *app.App) tea.Model {
 		dialog:      core.NewDialogCmp(),
 		app:         app,
 		pages: map[page.PageID]tea.Model{
-			page.LogsPage: page.NewLogsPage(),
-			page.InitPage: page.NewInitPage(),
-			page.ReplPage: page.NewReplPage(app),
+			page.LogsPage:      page.NewLogsPage(),
+			page.InitPage:      page.NewInitPage(),
+			page.ReplPage:      page.NewReplPage(app),
+			page.ProcessesPage: page.NewProcessesPage(app),
 		},
 	}
 }
@@ -3,8 +3,13 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 */
 package main
 
-import "github.com/imnulhaqueruman/opencode-poc/cmd"
+import (
+	"github.com/imnulhaqueruman/opencode-poc/cmd"
+	"github.com/imnulhaqueruman/opencode-poc/internal/llm/tools/shell"
+)
 
 func main() {
+	// sandboxed shells are started through termai itself
+	shell.RunSandboxHelper()
 	cmd.Execute()
 }
\ No newline at end of file